@@ -22,6 +22,12 @@ var (
 	reposDir      string
 	mocksDir      string
 	testsDir      string
+	handlersDir   string
+	protoDir      string
+	openAPIDir    string
+	graphQLDir    string
+	jsonSchemaDir string
+	typeScriptDir string
 	tables        []string
 	configFile    string
 	templateDir   string
@@ -67,6 +73,12 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&reposDir, "repos-dir", "", "Output directory for repository implementations (overrides config)")
 	rootCmd.PersistentFlags().StringVar(&mocksDir, "mocks-dir", "", "Output directory for mocks (overrides config)")
 	rootCmd.PersistentFlags().StringVar(&testsDir, "tests-dir", "", "Output directory for tests (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&handlersDir, "handlers-dir", "", "Output directory for HTTP handlers, generated only when set (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&protoDir, "proto-dir", "", "Output directory for protobuf definitions and converters, generated only when set (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&openAPIDir, "openapi-dir", "", "Output directory for the OpenAPI document, generated only when set (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&graphQLDir, "graphql-dir", "", "Output directory for the GraphQL schema and resolvers, generated only when set (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&jsonSchemaDir, "json-schema-dir", "", "Output directory for the JSON Schema documents of the models, generated only when set (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&typeScriptDir, "typescript-dir", "", "Output directory for the TypeScript declarations of the models, generated only when set (overrides config)")
 
 	rootCmd.PersistentFlags().StringSliceVar(&tables, "tables", []string{}, "Comma-separated list of tables to process (optional)")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to configuration file (pgx-goose-conf.yaml or pgx-goose-conf.json)")
@@ -307,6 +319,24 @@ func loadConfig() (*config.Config, error) {
 	if testsDir != "" {
 		cfg.OutputDirs.Tests = testsDir
 	}
+	if handlersDir != "" {
+		cfg.OutputDirs.Handlers = handlersDir
+	}
+	if protoDir != "" {
+		cfg.OutputDirs.Proto = protoDir
+	}
+	if openAPIDir != "" {
+		cfg.OutputDirs.OpenAPI = openAPIDir
+	}
+	if graphQLDir != "" {
+		cfg.OutputDirs.GraphQL = graphQLDir
+	}
+	if jsonSchemaDir != "" {
+		cfg.OutputDirs.JSONSchema = jsonSchemaDir
+	}
+	if typeScriptDir != "" {
+		cfg.OutputDirs.TypeScript = typeScriptDir
+	}
 
 	if len(tables) > 0 {
 		cfg.Tables = tables
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -18,6 +20,12 @@ type OutputDirs struct {
 	Repos      string `yaml:"repositories" json:"repositories"` // Repository implementations directory
 	Mocks      string `yaml:"mocks" json:"mocks"`               // Mocks directory
 	Tests      string `yaml:"tests" json:"tests"`               // Tests directory
+	Handlers   string `yaml:"handlers" json:"handlers"`         // HTTP handlers directory, handlers are only generated when set
+	Proto      string `yaml:"proto" json:"proto"`               // Protobuf definitions and converters directory, only generated when set
+	OpenAPI    string `yaml:"openapi" json:"openapi"`           // OpenAPI document directory, only generated when set
+	GraphQL    string `yaml:"graphql" json:"graphql"`           // GraphQL schema and resolvers directory, only generated when set
+	JSONSchema string `yaml:"json_schema" json:"json_schema"`   // JSON Schema documents directory, only generated when set
+	TypeScript string `yaml:"typescript" json:"typescript"`     // TypeScript declarations directory, only generated when set
 }
 
 // ParallelConfig represents parallel generation configuration
@@ -63,6 +71,78 @@ type GoGenerateConfig struct {
 	UpdateGitignore   bool `yaml:"update_gitignore" json:"update_gitignore"`       // Update .gitignore
 }
 
+// UpsertConfig represents upsert method generation configuration
+type UpsertConfig struct {
+	ExcludeColumns []string `yaml:"exclude_columns" json:"exclude_columns"` // Columns kept as inserted when a conflicting row is updated
+	DoNothing      bool     `yaml:"do_nothing" json:"do_nothing"`           // Also generate ON CONFLICT DO NOTHING variants
+}
+
+// KeysetConfig represents keyset pagination configuration
+type KeysetConfig struct {
+	SortKeys map[string][]string `yaml:"sort_keys" json:"sort_keys"` // Extra sort keys per table, each listing comma separated indexed columns
+}
+
+// SoftDeleteConfig represents soft delete configuration
+type SoftDeleteConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"` // Enable soft delete for tables having one of the columns
+	Columns []string `yaml:"columns" json:"columns"` // Candidate columns, a nullable timestamp or a boolean flag
+}
+
+// OptimisticLockingConfig represents optimistic concurrency control configuration
+type OptimisticLockingConfig struct {
+	Enabled bool              `yaml:"enabled" json:"enabled"` // Guard updates and deletes with the version column
+	Column  string            `yaml:"column" json:"column"`   // Version column, an integer column or the "xmin" system column
+	Tables  map[string]string `yaml:"tables" json:"tables"`   // Version column per table, overriding Column
+}
+
+// NotifyConfig holds configuration for the LISTEN/NOTIFY change subscriptions
+type NotifyConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"` // Generate change notification triggers and listeners
+	Tables  []string `yaml:"tables" json:"tables"`   // Tables whose changes are notified (empty = all tables)
+}
+
+// OutboxConfig holds configuration for the transactional outbox
+type OutboxConfig struct {
+	Enabled    bool     `yaml:"enabled" json:"enabled"`       // Generate the outbox table migration and relay
+	Table      string   `yaml:"table" json:"table"`           // Name of the outbox table
+	Aggregates []string `yaml:"aggregates" json:"aggregates"` // Tables whose writes append an event to the outbox
+}
+
+// AuditConfig holds configuration for the audit trail history tables
+type AuditConfig struct {
+	Tables           map[string]bool `yaml:"tables" json:"tables"`                         // Tables whose changes are recorded in a <table>_history table
+	ChangedBySetting string          `yaml:"changed_by_setting" json:"changed_by_setting"` // Session setting naming the user making the changes, e.g. app.user_id
+}
+
+// TenancyConfig holds configuration for the multi-tenancy enforcement
+type TenancyConfig struct {
+	Mode   string `yaml:"mode" json:"mode"`     // "column" scopes the rows of the tables having Column, "schema" qualifies the tables with a schema per tenant
+	Column string `yaml:"column" json:"column"` // Column holding the tenant of the rows in column mode
+}
+
+// CacheConfig holds configuration for the caching repository decorators
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"` // Generate a Cached<Model>Repository per table and the Cache interface
+}
+
+// ProtoConfig holds configuration for the protobuf definitions
+type ProtoConfig struct {
+	Package   string `yaml:"package" json:"package"`       // Protobuf package of the messages and services
+	GoPackage string `yaml:"go_package" json:"go_package"` // go_package option, the converters are generated in this package
+}
+
+// OpenAPIConfig holds configuration for the OpenAPI document
+type OpenAPIConfig struct {
+	Title   string `yaml:"title" json:"title"`     // Title of the API
+	Version string `yaml:"version" json:"version"` // Version of the API
+}
+
+// HooksConfig holds configuration for the query hook adapters
+type HooksConfig struct {
+	OpenTelemetry bool `yaml:"opentelemetry" json:"opentelemetry"` // Generate OpenTelemetry tracing hooks, built with the "otel" tag
+	Prometheus    bool `yaml:"prometheus" json:"prometheus"`       // Generate Prometheus metrics hooks, built with the "prometheus" tag
+}
+
 // Config represents the configuration for pgx-goose
 type Config struct {
 	DSN          string     `yaml:"dsn" json:"dsn"`
@@ -82,6 +162,18 @@ type Config struct {
 	CrossSchema          CrossSchemaConfig          `yaml:"cross_schema" json:"cross_schema"`
 	Migrations           MigrationConfig            `yaml:"migrations" json:"migrations"`
 	GoGenerate           GoGenerateConfig           `yaml:"go_generate" json:"go_generate"`
+	Upsert               UpsertConfig               `yaml:"upsert" json:"upsert"`
+	Keyset               KeysetConfig               `yaml:"keyset" json:"keyset"`
+	SoftDelete           SoftDeleteConfig           `yaml:"soft_delete" json:"soft_delete"`
+	OptimisticLocking    OptimisticLockingConfig    `yaml:"optimistic_locking" json:"optimistic_locking"`
+	Hooks                HooksConfig                `yaml:"hooks" json:"hooks"`
+	Notify               NotifyConfig               `yaml:"notify" json:"notify"`
+	Outbox               OutboxConfig               `yaml:"outbox" json:"outbox"`
+	Audit                AuditConfig                `yaml:"audit" json:"audit"`
+	Tenancy              TenancyConfig              `yaml:"tenancy" json:"tenancy"`
+	Cache                CacheConfig                `yaml:"cache" json:"cache"`
+	Proto                ProtoConfig                `yaml:"proto" json:"proto"`
+	OpenAPI              OpenAPIConfig              `yaml:"openapi" json:"openapi"`
 }
 
 // LoadFromFile loads configuration from a YAML or JSON file
@@ -200,6 +292,47 @@ func (c *Config) applyAdvancedDefaults() {
 	if c.Migrations.OutputDir == "" {
 		c.Migrations.OutputDir = "./migrations"
 	}
+
+	// Upsert defaults, an explicitly empty list keeps every column updatable
+	if c.Upsert.ExcludeColumns == nil {
+		c.Upsert.ExcludeColumns = []string{"created_at"}
+	}
+
+	// Soft delete defaults
+	if len(c.SoftDelete.Columns) == 0 {
+		c.SoftDelete.Columns = []string{"deleted_at", "is_deleted"}
+	}
+
+	// Optimistic locking defaults
+	if c.OptimisticLocking.Column == "" {
+		c.OptimisticLocking.Column = "version"
+	}
+
+	// Outbox defaults
+	if c.Outbox.Table == "" {
+		c.Outbox.Table = "outbox"
+	}
+
+	// Tenancy defaults
+	if c.Tenancy.Column == "" {
+		c.Tenancy.Column = "tenant_id"
+	}
+
+	// Protobuf defaults
+	if c.Proto.Package == "" {
+		c.Proto.Package = "pgxgoose.v1"
+	}
+	if c.Proto.GoPackage == "" {
+		c.Proto.GoPackage = "github.com/fsvxavier/pgx-goose/pb;pb"
+	}
+
+	// OpenAPI defaults
+	if c.OpenAPI.Title == "" {
+		c.OpenAPI.Title = "pgx-goose API"
+	}
+	if c.OpenAPI.Version == "" {
+		c.OpenAPI.Version = "1.0.0"
+	}
 }
 
 // GetModelsDir returns the models output directory
@@ -242,6 +375,74 @@ func (c *Config) GetTestsDir() string {
 	return filepath.Join(c.GetBaseDir(), "tests")
 }
 
+// GetHandlersDir returns the HTTP handlers output directory, empty unless handlers are generated
+func (c *Config) GetHandlersDir() string {
+	return c.OutputDirs.Handlers
+}
+
+// IsHandlersEnabled returns true if HTTP handlers are generated
+func (c *Config) IsHandlersEnabled() bool {
+	return c.OutputDirs.Handlers != ""
+}
+
+// GetProtoDir returns the protobuf output directory, empty unless protobuf definitions are generated
+func (c *Config) GetProtoDir() string {
+	return c.OutputDirs.Proto
+}
+
+// IsProtoEnabled returns true if protobuf definitions are generated
+func (c *Config) IsProtoEnabled() bool {
+	return c.OutputDirs.Proto != ""
+}
+
+// GetProtoGoPackageName returns the name of the Go package of the protobuf messages
+func (c *Config) GetProtoGoPackageName() string {
+	if _, name, ok := strings.Cut(c.Proto.GoPackage, ";"); ok {
+		return name
+	}
+	return path.Base(c.Proto.GoPackage)
+}
+
+// GetOpenAPIDir returns the OpenAPI output directory, empty unless the OpenAPI document is generated
+func (c *Config) GetOpenAPIDir() string {
+	return c.OutputDirs.OpenAPI
+}
+
+// IsOpenAPIEnabled returns true if the OpenAPI document is generated
+func (c *Config) IsOpenAPIEnabled() bool {
+	return c.OutputDirs.OpenAPI != ""
+}
+
+// GetGraphQLDir returns the GraphQL output directory, empty unless the GraphQL schema is generated
+func (c *Config) GetGraphQLDir() string {
+	return c.OutputDirs.GraphQL
+}
+
+// IsGraphQLEnabled returns true if the GraphQL schema and resolvers are generated
+func (c *Config) IsGraphQLEnabled() bool {
+	return c.OutputDirs.GraphQL != ""
+}
+
+// GetJSONSchemaDir returns the JSON Schema output directory, empty unless the JSON Schemas are generated
+func (c *Config) GetJSONSchemaDir() string {
+	return c.OutputDirs.JSONSchema
+}
+
+// IsJSONSchemaEnabled returns true if the JSON Schema documents of the models are generated
+func (c *Config) IsJSONSchemaEnabled() bool {
+	return c.OutputDirs.JSONSchema != ""
+}
+
+// GetTypeScriptDir returns the TypeScript output directory, empty unless the declarations are generated
+func (c *Config) GetTypeScriptDir() string {
+	return c.OutputDirs.TypeScript
+}
+
+// IsTypeScriptEnabled returns true if the TypeScript declarations of the models are generated
+func (c *Config) IsTypeScriptEnabled() bool {
+	return c.OutputDirs.TypeScript != ""
+}
+
 // GetBaseDir returns the base output directory
 func (c *Config) GetBaseDir() string {
 	if c.OutputDirs.Base != "" {
@@ -265,6 +466,24 @@ func (c *Config) GetAllOutputDirs() []string {
 	if c.WithTests {
 		dirs = append(dirs, c.GetTestsDir())
 	}
+	if c.IsHandlersEnabled() {
+		dirs = append(dirs, c.GetHandlersDir())
+	}
+	if c.IsProtoEnabled() {
+		dirs = append(dirs, c.GetProtoDir())
+	}
+	if c.IsOpenAPIEnabled() {
+		dirs = append(dirs, c.GetOpenAPIDir())
+	}
+	if c.IsGraphQLEnabled() {
+		dirs = append(dirs, c.GetGraphQLDir())
+	}
+	if c.IsJSONSchemaEnabled() {
+		dirs = append(dirs, c.GetJSONSchemaDir())
+	}
+	if c.IsTypeScriptEnabled() {
+		dirs = append(dirs, c.GetTypeScriptDir())
+	}
 
 	return dirs
 }
@@ -324,6 +543,11 @@ func (c *Config) validateAdvancedFeatures() error {
 		}
 	}
 
+	// Validate tenancy configuration
+	if c.Tenancy.Mode != "" && c.Tenancy.Mode != "column" && c.Tenancy.Mode != "schema" {
+		return fmt.Errorf("invalid tenancy mode: %s (must be 'column' or 'schema')", c.Tenancy.Mode)
+	}
+
 	// Validate cross-schema configuration
 	if c.CrossSchema.Enabled && len(c.CrossSchema.Schemas) == 0 {
 		return fmt.Errorf("at least one schema must be specified when cross-schema is enabled")
@@ -365,6 +589,69 @@ func (c *Config) IsGoGenerateEnabled() bool {
 	return c.GoGenerate.Enabled
 }
 
+// IsSoftDeleteEnabled returns true if soft delete support is enabled
+func (c *Config) IsSoftDeleteEnabled() bool {
+	return c.SoftDelete.Enabled
+}
+
+// IsOptimisticLockingEnabled returns true if optimistic locking is enabled
+func (c *Config) IsOptimisticLockingEnabled() bool {
+	return c.OptimisticLocking.Enabled
+}
+
+// IsNotifyEnabled returns true if change notifications are enabled
+func (c *Config) IsNotifyEnabled() bool {
+	return c.Notify.Enabled
+}
+
+// IsCacheEnabled returns true if the caching repository decorators are enabled
+func (c *Config) IsCacheEnabled() bool {
+	return c.Cache.Enabled
+}
+
+// IsOutboxEnabled returns true if the transactional outbox is enabled
+func (c *Config) IsOutboxEnabled() bool {
+	return c.Outbox.Enabled
+}
+
+// IsAuditEnabled returns true if the changes of any table are audited
+func (c *Config) IsAuditEnabled() bool {
+	for _, audited := range c.Audit.Tables {
+		if audited {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAudited returns true if the changes of a table are recorded in a history table
+func (c *Config) IsAudited(table string) bool {
+	return c.Audit.Tables[table]
+}
+
+// IsTenancyEnabled returns true if the queries are scoped to the tenant of their context
+func (c *Config) IsTenancyEnabled() bool {
+	return c.Tenancy.Mode != ""
+}
+
+// IsSchemaTenancy returns true if every tenant has its own schema
+func (c *Config) IsSchemaTenancy() bool {
+	return c.Tenancy.Mode == "schema"
+}
+
+// IsOutboxAggregate returns true if the writes of a table append events to the outbox
+func (c *Config) IsOutboxAggregate(table string) bool {
+	return c.Outbox.Enabled && slices.Contains(c.Outbox.Aggregates, table)
+}
+
+// GetVersionColumn returns the version column configured for a table
+func (c *Config) GetVersionColumn(table string) string {
+	if column, ok := c.OptimisticLocking.Tables[table]; ok {
+		return column
+	}
+	return c.OptimisticLocking.Column
+}
+
 // IsTemplateOptimizationEnabled returns true if template optimization is enabled
 func (c *Config) IsTemplateOptimizationEnabled() bool {
 	return c.TemplateOptimization.Enabled
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// auditMigrationName names the migration creating the history tables and their triggers
+const auditMigrationName = "audit_history"
+
+// Audit describes the history table recording every version of an audited table
+type Audit struct {
+	HistoryTable string
+	HistoryRef   string                // History table as referenced by the SQL of the history repository
+	Columns      []introspector.Column // Columns of the table copied to the history, without system columns
+	ChangedBy    string                // Expression reading the user making the change, NULL when not captured
+	Imports      []string              // Imports of the history repository besides time
+}
+
+// historyTable returns the name of the table recording the versions of a table
+func historyTable(table string) string {
+	return table + "_history"
+}
+
+// newAudit returns the history of an audited table, or nil when the table has no single
+// column primary key to look its versions up with
+func newAudit(table introspector.Table, primaryKeyType, changedBySetting string) *Audit {
+	if len(primaryKeyColumns(table)) != 1 {
+		slog.Warn("Skipping audit", "table", table.Name, "reason", "table has no single column primary key")
+		return nil
+	}
+
+	var columns []introspector.Column
+	for _, col := range table.Columns {
+		if col.Name != xminColumn {
+			columns = append(columns, col)
+		}
+	}
+
+	changedBy := "NULL"
+	if changedBySetting != "" {
+		changedBy = fmt.Sprintf("NULLIF(current_setting('%s', true), '')", strings.ReplaceAll(changedBySetting, "'", "''"))
+	}
+
+	var imports []string
+	for _, path := range goTypeImports(primaryKeyType) {
+		if path != "time" {
+			imports = append(imports, path)
+		}
+	}
+
+	return &Audit{
+		HistoryTable: historyTable(table.Name),
+		HistoryRef:   historyTable(table.Name),
+		Columns:      columns,
+		ChangedBy:    changedBy,
+		Imports:      imports,
+	}
+}
+
+// auditTriggerSQL returns the SQL creating and dropping the history table of a table
+// and the trigger recording its versions. The history table copies the columns of the
+// table, every insert and update records the new row and every delete the deleted one.
+// The existing rows are recorded as inserted by the migration.
+func auditTriggerSQL(table introspector.Table, audit *Audit) (up, down string) {
+	pk := primaryKeyColumns(table)[0]
+	function := table.Name + "_audit"
+	columns := auditColumnNames(audit)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", audit.HistoryTable)
+	b.WriteString("\thistory_id bigserial PRIMARY KEY,\n")
+	b.WriteString("\thistory_operation text NOT NULL,\n")
+	b.WriteString("\thistory_changed_at timestamptz NOT NULL DEFAULT clock_timestamp(),\n")
+	b.WriteString("\thistory_changed_by text,\n")
+	fmt.Fprintf(&b, "\tLIKE %s\n);\n", table.Name)
+	fmt.Fprintf(&b, "CREATE INDEX IF NOT EXISTS %s_%s_idx ON %s (%s, history_id);\n\n", audit.HistoryTable, pk, audit.HistoryTable, pk)
+
+	b.WriteString(auditFunctionSQL(table.Name, audit, columns))
+	fmt.Fprintf(&b, "DROP TRIGGER IF EXISTS %s ON %s;\n", function, table.Name)
+	fmt.Fprintf(&b, "CREATE TRIGGER %s\n\tAFTER INSERT OR UPDATE OR DELETE ON %s\n\tFOR EACH ROW EXECUTE FUNCTION %s();\n\n", function, table.Name, function)
+
+	fmt.Fprintf(&b, "INSERT INTO %s (history_operation, %s)\nSELECT 'INSERT', %s FROM %s;\n",
+		audit.HistoryTable, strings.Join(columns, ", "), strings.Join(columns, ", "), table.Name)
+
+	down = fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;\nDROP FUNCTION IF EXISTS %s();\nDROP TABLE IF EXISTS %s;\n",
+		function, table.Name, function, audit.HistoryTable)
+	return b.String(), down
+}
+
+// auditColumnNames returns the names of the columns copied to the history
+func auditColumnNames(audit *Audit) []string {
+	columns := make([]string, len(audit.Columns))
+	for i, col := range audit.Columns {
+		columns[i] = col.Name
+	}
+	return columns
+}
+
+// auditFunctionSQL returns the SQL creating the trigger function recording the given
+// columns of the versions of a table
+func auditFunctionSQL(table string, audit *Audit, columns []string) string {
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = "rec." + col
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s_audit() RETURNS trigger AS $$\n", table)
+	b.WriteString("DECLARE\n\trec RECORD;\nBEGIN\n")
+	b.WriteString("\tIF TG_OP = 'DELETE' THEN\n\t\trec := OLD;\n\tELSE\n\t\trec := NEW;\n\tEND IF;\n\n")
+	fmt.Fprintf(&b, "\tINSERT INTO %s (history_operation, history_changed_by, %s)\n", audit.HistoryTable, strings.Join(columns, ", "))
+	fmt.Fprintf(&b, "\tVALUES (TG_OP, %s, %s);\n", audit.ChangedBy, strings.Join(values, ", "))
+	b.WriteString("\tRETURN NULL;\nEND;\n$$ LANGUAGE plpgsql;\n\n")
+	return b.String()
+}
+
+// auditMigrationUnit returns the migration unit of the history of a table. Its signature
+// holds the recorded columns, so that a later migration changing them adds the new columns
+// to the history table and records them rather than creating the history again. The
+// columns no longer recorded are kept in the history table, with its earlier versions.
+func auditMigrationUnit(table introspector.Table, audit *Audit) MigrationUnit {
+	up, down := auditTriggerSQL(table, audit)
+	columns := auditColumnNames(audit)
+	function := auditFunctionSQL(table.Name, audit, columns)
+	signature := strings.Join(columns, ",") + "@" + fmt.Sprintf("%x", sha256.Sum256([]byte(function)))[:16]
+
+	return MigrationUnit{
+		Key:       table.Name,
+		Signature: signature,
+		UpSQL:     up,
+		DownSQL:   down,
+		Change: func(previous AppliedMigrationUnit) (string, string) {
+			recorded, _, _ := strings.Cut(previous.Signature, "@")
+			previousColumns := strings.Split(recorded, ",")
+
+			var up, down strings.Builder
+			for _, col := range columns {
+				if slices.Contains(previousColumns, col) {
+					continue
+				}
+				// The column is copied with the type it has in the table, enums and arrays included
+				fmt.Fprintf(&up, "DO $$\nBEGIN\n\tEXECUTE (\n")
+				fmt.Fprintf(&up, "\t\tSELECT format('ALTER TABLE %s ADD COLUMN IF NOT EXISTS %%I %%s', attname, format_type(atttypid, atttypmod))\n", audit.HistoryTable)
+				fmt.Fprintf(&up, "\t\tFROM pg_attribute WHERE attrelid = '%s'::regclass AND attname = '%s'\n", table.Name, col)
+				fmt.Fprintf(&up, "\t);\nEND $$;\n")
+				fmt.Fprintf(&down, "ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", audit.HistoryTable, col)
+			}
+			for _, col := range previousColumns {
+				if col != "" && !slices.Contains(columns, col) {
+					fmt.Fprintf(&up, "ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;\n", audit.HistoryTable, col)
+				}
+			}
+			if up.Len() > 0 {
+				up.WriteString("\n")
+			}
+			up.WriteString(function)
+			return up.String(), auditFunctionSQL(table.Name, audit, previousColumns) + down.String()
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewAudit(t *testing.T) {
+	table := withSystemVersion(testTable("accounts"), xminColumn)
+
+	audit := newAudit(table, "uuid.UUID", "app.user_id")
+	require.NotNil(t, audit)
+	assert.Equal(t, "accounts_history", audit.HistoryTable)
+	assert.Equal(t, []string{"id", "email", "nickname", "plan", "balance", "revision", "version", "deleted_at"}, auditColumnNames(audit), "system columns are not copied")
+	assert.Equal(t, "NULLIF(current_setting('app.user_id', true), '')", audit.ChangedBy)
+	assert.Equal(t, []string{"github.com/google/uuid"}, audit.Imports)
+
+	assert.Equal(t, "NULL", newAudit(table, "uuid.UUID", "").ChangedBy)
+	assert.Nil(t, newAudit(introspector.Table{Name: "logs", Columns: []introspector.Column{{Name: "line", GoType: "string"}}}, "", ""))
+}
+
+func TestAuditTriggerSQL(t *testing.T) {
+	table := testTable("accounts")
+	table.Columns = table.Columns[:3]
+	audit := newAudit(table, "uuid.UUID", "")
+	up, down := auditTriggerSQL(table, audit)
+
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS accounts_history (
+	history_id bigserial PRIMARY KEY,
+	history_operation text NOT NULL,
+	history_changed_at timestamptz NOT NULL DEFAULT clock_timestamp(),
+	history_changed_by text,
+	LIKE accounts
+);
+CREATE INDEX IF NOT EXISTS accounts_history_id_idx ON accounts_history (id, history_id);
+
+`+auditFunctionSQL("accounts", audit, auditColumnNames(audit))+`DROP TRIGGER IF EXISTS accounts_audit ON accounts;
+CREATE TRIGGER accounts_audit
+	AFTER INSERT OR UPDATE OR DELETE ON accounts
+	FOR EACH ROW EXECUTE FUNCTION accounts_audit();
+
+INSERT INTO accounts_history (history_operation, id, email, nickname)
+SELECT 'INSERT', id, email, nickname FROM accounts;
+`, up)
+	assert.Equal(t, "DROP TRIGGER IF EXISTS accounts_audit ON accounts;\nDROP FUNCTION IF EXISTS accounts_audit();\nDROP TABLE IF EXISTS accounts_history;\n", down)
+
+	assert.Equal(t, `CREATE OR REPLACE FUNCTION accounts_audit() RETURNS trigger AS $$
+DECLARE
+	rec RECORD;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		rec := OLD;
+	ELSE
+		rec := NEW;
+	END IF;
+
+	INSERT INTO accounts_history (history_operation, history_changed_by, id, email)
+	VALUES (TG_OP, NULL, rec.id, rec.email);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+`, auditFunctionSQL("accounts", audit, []string{"id", "email"}))
+}
+
+func TestGenerator_GenerateAudit(t *testing.T) {
+	cfg := &config.Config{
+		OutputDir:    t.TempDir(),
+		MockProvider: "testify",
+		Audit:        config.AuditConfig{Tables: map[string]bool{"accounts": true}, ChangedBySetting: "app.user_id"},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	schema := &introspector.Schema{Tables: []introspector.Table{
+		testTable("accounts"),
+		{Name: "accounts_history", Columns: []introspector.Column{{Name: "history_id", GoType: "int64", IsPrimaryKey: true}}},
+	}}
+	require.NoError(t, g.Generate(schema))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "accounts_history_repository.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Contains(t, src.Imports(), "github.com/google/uuid")
+	assert.Equal(t, "func(db DBTX, opts ...Option) *AccountsHistoryRepository", src.Signature("NewAccountsHistoryRepository"))
+	assert.Equal(t, "func(ctx context.Context, id uuid.UUID) ([]*AccountsVersion, error)", src.Signature("AccountsHistoryRepository.History"))
+	assert.Equal(t, "func(ctx context.Context, id uuid.UUID, at time.Time) (*models.Accounts, error)", src.Signature("AccountsHistoryRepository.AsOf"))
+	assert.Contains(t, src.Strings("AccountsHistoryRepository.AsOf"), "SELECT history_operation, id, email, nickname, plan, balance, revision, version, deleted_at FROM accounts_history "+
+		"WHERE id = $1 AND history_changed_at <= $2 ORDER BY history_id DESC LIMIT 1")
+	assert.True(t, src.Uses("AccountsHistoryRepository.AsOf", "ErrNoRows"), "a deleted row has no version")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetReposDir(), "audit.go"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.user_id"}, parseGoSource(t, string(content)).Strings("ChangedBySetting"))
+
+	_, err = os.Stat(filepath.Join(cfg.GetModelsDir(), "accounts_history.go"))
+	assert.True(t, os.IsNotExist(err), "the history table gets no model")
+
+	migrations, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_audit_history.sql"))
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+
+	accounts := testTable("accounts")
+	previous := auditMigrationUnit(accounts, newAudit(accounts, "uuid.UUID", "app.user_id"))
+	content, err = os.ReadFile(migrations[0])
+	require.NoError(t, err)
+	assert.Equal(t, "-- +goose Up\n-- +goose StatementBegin\n"+migrationUnitMarker+"accounts "+previous.Signature+"\n"+previous.UpSQL+
+		"\n-- +goose StatementEnd\n\n-- +goose Down\n-- +goose StatementBegin\n"+previous.DownSQL+"\n-- +goose StatementEnd\n", string(content))
+
+	// A column added to the table is added to the history by a new migration
+	accounts.Columns = append(accounts.Columns, introspector.Column{Name: "phone", Type: "text", GoType: "*string", IsNullable: true})
+	schema.Tables[0] = accounts
+	require.NoError(t, g.Generate(schema))
+
+	again, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_audit_history.sql"))
+	require.NoError(t, err)
+	require.Len(t, again, 2)
+	assert.Equal(t, migrations[0], again[0])
+	rewritten, err := os.ReadFile(again[0])
+	require.NoError(t, err)
+	assert.Equal(t, content, rewritten, "the applied migration is not rewritten")
+
+	unit := auditMigrationUnit(accounts, newAudit(accounts, "uuid.UUID", "app.user_id"))
+	up, down := unit.Change(AppliedMigrationUnit{Signature: previous.Signature, UpSQL: previous.UpSQL})
+	content, err = os.ReadFile(again[1])
+	require.NoError(t, err)
+	assert.Equal(t, "-- +goose Up\n-- +goose StatementBegin\n"+migrationUnitMarker+"accounts "+unit.Signature+"\n"+up+
+		"\n-- +goose StatementEnd\n\n-- +goose Down\n-- +goose StatementBegin\n"+down+"\n-- +goose StatementEnd\n", string(content))
+}
+
+func TestAuditMigrationUnit_Change(t *testing.T) {
+	table := testTable("accounts")
+	table.Columns = table.Columns[:3]
+	audit := newAudit(table, "uuid.UUID", "")
+	previous := auditMigrationUnit(table, audit)
+	assert.Equal(t, "id,email,nickname@", previous.Signature[:len("id,email,nickname@")])
+
+	t.Run("added column", func(t *testing.T) {
+		added := table
+		added.Columns = append(slices.Clip(table.Columns), introspector.Column{Name: "plan", Type: "text", GoType: "string"})
+		audit := newAudit(added, "uuid.UUID", "")
+		unit := auditMigrationUnit(added, audit)
+		assert.NotEqual(t, previous.Signature, unit.Signature)
+
+		up, down := unit.Change(AppliedMigrationUnit{Signature: previous.Signature, UpSQL: previous.UpSQL})
+		assert.Equal(t, "DO $$\nBEGIN\n\tEXECUTE (\n"+
+			"\t\tSELECT format('ALTER TABLE accounts_history ADD COLUMN IF NOT EXISTS %I %s', attname, format_type(atttypid, atttypmod))\n"+
+			"\t\tFROM pg_attribute WHERE attrelid = 'accounts'::regclass AND attname = 'plan'\n"+
+			"\t);\nEND $$;\n\n"+auditFunctionSQL("accounts", audit, []string{"id", "email", "nickname", "plan"}), up, "the existing rows are recorded once")
+		assert.Equal(t, auditFunctionSQL("accounts", audit, []string{"id", "email", "nickname"})+"ALTER TABLE accounts_history DROP COLUMN IF EXISTS plan;\n", down)
+	})
+
+	t.Run("removed column", func(t *testing.T) {
+		removed := table
+		removed.Columns = table.Columns[:2]
+		audit := newAudit(removed, "uuid.UUID", "")
+		unit := auditMigrationUnit(removed, audit)
+		assert.NotEqual(t, previous.Signature, unit.Signature)
+
+		up, down := unit.Change(AppliedMigrationUnit{Signature: previous.Signature, UpSQL: previous.UpSQL})
+		assert.Equal(t, "ALTER TABLE accounts_history ALTER COLUMN nickname DROP NOT NULL;\n\n"+auditFunctionSQL("accounts", audit, []string{"id", "email"}), up,
+			"the history keeps the column with its earlier versions")
+		assert.Equal(t, auditFunctionSQL("accounts", audit, []string{"id", "email", "nickname"}), down)
+	})
+}
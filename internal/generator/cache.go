@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// CacheImports returns the imports of the cached repository, which only declares the
+// methods taking the primary key or the columns of a unique finder
+func (d repositoryTemplateData) CacheImports() []string {
+	goTypes := []string{d.PrimaryKeyType}
+	for _, finder := range d.Finders {
+		if !finder.Unique {
+			continue
+		}
+		for _, col := range finder.Columns {
+			goTypes = append(goTypes, col.GoType)
+		}
+	}
+	return goTypeImports(goTypes...)
+}
+
+// generateCache generates the cached decorator of the repository of every table
+func (g *Generator) generateCache(schema *introspector.Schema) error {
+	slog.Info("Generating cached repositories...")
+
+	tmpl, err := g.getTemplate("cached_repository.tmpl")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range schema.Tables {
+		data := g.newRepositoryTemplateData(table, "postgres")
+		filename := filepath.Join(g.config.GetReposDir(), fmt.Sprintf("%s_cached_repository.go", toSnakeCase(table.Name)))
+		if err := g.writeTemplate(tmpl, filename, data); err != nil {
+			return fmt.Errorf("failed to generate %s cached repository: %w", table.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestRepositoryTemplateData_CacheImports(t *testing.T) {
+	g := New(&config.Config{})
+	data := g.newRepositoryTemplateData(testTable("posts"), "postgres")
+
+	assert.Contains(t, data.Imports, "time", "the list finder on published_at takes a time")
+	assert.Empty(t, data.CacheImports(), "only the unique finders are cached")
+}
+
+func TestCachedRepositoryTemplate(t *testing.T) {
+	cfg := &config.Config{SoftDelete: config.SoftDeleteConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	render := func(t *testing.T, table introspector.Table) goSource {
+		return renderGoSource(t, g, "cached_repository.tmpl", g.newRepositoryTemplateData(table, "postgres"))
+	}
+
+	t.Run("finders", func(t *testing.T) {
+		src := render(t, testTable("posts"))
+
+		assert.Equal(t, []string{"*CachedPostsRepository"}, src.Implements("interfaces.PostsRepository"))
+		assert.Equal(t, "func(repo interfaces.PostsRepository, cache Cache, ttl time.Duration) interfaces.PostsRepository", src.Signature("NewCachedPostsRepository"))
+		assert.Equal(t, "func(ctx context.Context, email string) (*models.Posts, error)", src.Signature("CachedPostsRepository.GetByEmail"))
+		assert.Subset(t, src.Returns("CachedPostsRepository.GetByUserIdAndSlug"), []string{
+			"sameValue(posts.UserId, userId) && sameValue(posts.Slug, slug)",
+			"r.PostsRepository.GetByUserIdAndSlug(ctx, userId, slug)",
+		})
+		assert.False(t, src.Has("CachedPostsRepository.ListByType"), "list finders are not cached")
+		assert.Equal(t, []string{"r.invalidate(ctx, posts.Id)"}, src.Returns("CachedPostsRepository.Update")[1:])
+		assert.Equal(t, []string{`cacheKey("posts", kind, nil, values...)`}, src.Returns("CachedPostsRepository.key"))
+		assert.False(t, src.Has("CachedPostsRepository.HardDelete"))
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		src := render(t, testTable("accounts"))
+
+		assert.Equal(t, "func(ctx context.Context, id uuid.UUID) error", src.Signature("CachedAccountsRepository.HardDelete"))
+		assert.Contains(t, src.Returns("CachedAccountsRepository.HardDelete"), "r.invalidate(ctx, id)")
+		assert.Equal(t, []string{"ctx"}, src.Calls("CachedAccountsRepository.readKey", "includeDeleted"), "soft deleted rows are never cached")
+	})
+}
+
+func TestGenerator_GenerateCache(t *testing.T) {
+	cfg := &config.Config{
+		OutputDir:    t.TempDir(),
+		MockProvider: "testify",
+		Tenancy:      config.TenancyConfig{Mode: "column"},
+		Cache:        config.CacheConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	require.NoError(t, g.Generate(&introspector.Schema{Tables: []introspector.Table{testTable("projects")}}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "cache.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.True(t, src.Has("Cache"))
+	assert.Equal(t, "func(size int) *LRUCache", src.Signature("NewLRUCache"))
+	assert.Equal(t, []string{"TenantID(ctx)"}, src.Returns("cacheScope"))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetReposDir(), "projects_cached_repository.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, "NewCachedProjectsRepository decorates repo with cache, the rows being kept for ttl.\n"+
+		"A write made in a transaction removes the row from the cache before the transaction\n"+
+		"commits, a concurrent read may cache the previous row again until ttl expires.\n"+
+		"The rows of each tenant are cached apart.\n", src.Doc("NewCachedProjectsRepository"))
+	assert.Equal(t, "func(ctx context.Context, slug string) (*models.Projects, error)", src.Signature("CachedProjectsRepository.GetBySlug"))
+	assert.Equal(t, []string{"ctx"}, src.Calls("CachedProjectsRepository.key", "cacheScope"))
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// Constraint describes a unique or foreign key constraint whose columns are reported
+// alongside the PostgreSQL errors violating it
+type Constraint struct {
+	Name    string   // Constraint or unique index name, as found in pgconn.PgError.ConstraintName
+	Columns []string // Constrained columns, in constraint order
+}
+
+// buildConstraints returns the unique indexes and foreign keys of a table sorted by name.
+// A foreign key spanning several columns is introspected once per column, those entries
+// are merged into a single constraint.
+func buildConstraints(table introspector.Table) []Constraint {
+	var constraints []Constraint
+	for _, idx := range table.Indexes {
+		if idx.IsUnique && idx.Name != "" {
+			constraints = append(constraints, Constraint{Name: idx.Name, Columns: idx.Columns})
+		}
+	}
+
+	foreignKeys := make(map[string]int)
+	for _, fk := range table.ForeignKeys {
+		if fk.Name == "" {
+			continue
+		}
+		if i, ok := foreignKeys[fk.Name]; ok {
+			if !slices.Contains(constraints[i].Columns, fk.Column) {
+				constraints[i].Columns = append(constraints[i].Columns, fk.Column)
+			}
+			continue
+		}
+		if slices.ContainsFunc(constraints, func(c Constraint) bool { return c.Name == fk.Name }) {
+			continue
+		}
+		foreignKeys[fk.Name] = len(constraints)
+		constraints = append(constraints, Constraint{Name: fk.Name, Columns: []string{fk.Column}})
+	}
+
+	sort.SliceStable(constraints, func(i, j int) bool {
+		return constraints[i].Name < constraints[j].Name
+	})
+	return constraints
+}
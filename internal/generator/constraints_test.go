@@ -0,0 +1,31 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestBuildConstraints(t *testing.T) {
+	table := introspector.Table{
+		Name: "order_items",
+		Indexes: []introspector.Index{
+			{Name: "order_items_sku_idx", Columns: []string{"sku"}},
+			{Name: "order_items_pkey", Columns: []string{"order_id", "line"}, IsUnique: true},
+		},
+		ForeignKeys: []introspector.ForeignKey{
+			{Name: "order_items_order_fkey", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+			{Name: "order_items_order_fkey", Column: "line", ReferencedTable: "orders", ReferencedColumn: "line"},
+			{Name: "order_items_sku_fkey", Column: "sku", ReferencedTable: "products", ReferencedColumn: "sku"},
+		},
+	}
+
+	// Non-unique indexes are skipped and composite foreign keys are merged
+	assert.Equal(t, []Constraint{
+		{Name: "order_items_order_fkey", Columns: []string{"order_id", "line"}},
+		{Name: "order_items_pkey", Columns: []string{"order_id", "line"}},
+		{Name: "order_items_sku_fkey", Columns: []string{"sku"}},
+	}, buildConstraints(table))
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// FilterField describes a column that can be filtered by the generated Find method
+type FilterField struct {
+	Column string // Column name
+	Field  string // Field name in the filter struct
+	Type   string // Predicate type, e.g. "StringFilter" or "Filter[int64]"
+	Kind   string // "string" and "time" columns support extra predicates
+}
+
+// SortField describes a column the generated Find method can sort by
+type SortField struct {
+	Column string // Column name
+	Const  string // Name of the generated sort field constant
+}
+
+// buildFilterFields returns the filterable columns of a table. Columns whose values
+// cannot be compared in SQL, such as json or untyped columns, are skipped, and so are
+// xid columns such as the xmin version, which have no ordering operators.
+func buildFilterFields(table introspector.Table) []FilterField {
+	var fields []FilterField
+	for _, col := range table.Columns {
+		goType := strings.TrimPrefix(col.GoType, "*")
+		if !isComparableGoType(goType) || goType == "[]byte" || col.Type == "xid" {
+			continue
+		}
+
+		field := FilterField{Column: col.Name, Field: toPascalCase(col.Name)}
+		switch goType {
+		case "string":
+			field.Type, field.Kind = "StringFilter", "string"
+		case "time.Time":
+			field.Type, field.Kind = "TimeFilter", "time"
+		default:
+			field.Type = "Filter[" + goType + "]"
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// buildSortFields returns the columns a table can be sorted by: the primary key and
+// the leading column of every index, so that sorting can always use an index
+func buildSortFields(table introspector.Table) []SortField {
+	sortable := make(map[string]bool)
+	for _, name := range primaryKeyColumns(table) {
+		sortable[name] = true
+	}
+	for _, idx := range table.Indexes {
+		if len(idx.Columns) > 0 {
+			sortable[idx.Columns[0]] = true
+		}
+	}
+
+	structName := toPascalCase(table.Name)
+	var fields []SortField
+	for _, col := range table.Columns {
+		if !sortable[col.Name] || !isComparableGoType(col.GoType) {
+			continue
+		}
+		fields = append(fields, SortField{
+			Column: col.Name,
+			Const:  structName + "SortBy" + toPascalCase(col.Name),
+		})
+	}
+	return fields
+}
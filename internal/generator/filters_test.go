@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFilterFields(t *testing.T) {
+	fields := buildFilterFields(testTable("posts"))
+
+	types := make(map[string]string)
+	kinds := make(map[string]string)
+	for _, f := range fields {
+		types[f.Column] = f.Type
+		kinds[f.Column] = f.Kind
+	}
+
+	assert.Equal(t, "Filter[int64]", types["id"])
+	assert.Equal(t, "StringFilter", types["email"], "nullable columns are filtered by value")
+	assert.Equal(t, "string", kinds["email"])
+	assert.Equal(t, "TimeFilter", types["published_at"])
+	assert.Equal(t, "time", kinds["published_at"])
+	assert.NotContains(t, types, "payload", "json columns cannot be compared")
+
+	withXmin := buildFilterFields(withSystemVersion(testTable("posts"), xminColumn))
+	assert.Len(t, withXmin, len(fields), "xid has no ordering operators")
+}
+
+func TestBuildSortFields(t *testing.T) {
+	fields := buildSortFields(testTable("posts"))
+
+	var columns, consts []string
+	for _, f := range fields {
+		columns = append(columns, f.Column)
+		consts = append(consts, f.Const)
+	}
+
+	// Primary key and leading index columns in table order; slug only trails an index
+	// and payload cannot be compared
+	assert.Equal(t, []string{"id", "user_id", "type", "email", "published_at"}, columns)
+	assert.Equal(t, "PostsSortByUserId", consts[1])
+}
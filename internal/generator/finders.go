@@ -0,0 +1,296 @@
+package generator
+
+import (
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// Finder describes an additional lookup method derived from an index or a foreign key
+type Finder struct {
+	Name    string         // Method suffix, e.g. "Email" or "UserIdAndSlug"
+	Columns []FinderColumn // Columns compared in the WHERE clause, in index order
+	Unique  bool           // Unique finders return a single row, the others a paginated list
+	Source  string         // Name of the index or foreign key the finder comes from
+}
+
+// FinderColumn describes a column used as a finder argument
+type FinderColumn struct {
+	Name      string // Column name
+	Field     string // Model field name
+	Param     string // Go parameter name
+	GoType    string // Go parameter type, the pointer type of the model field for the nullable columns of list finders
+	ValueType string // Go type of the compared values, GoType without the pointer
+	Nullable  bool   // The parameter is a pointer, nil matching the rows where the column is NULL
+}
+
+// Signature returns the Go parameter list of the finder, e.g. "userId int, slug string"
+func (f Finder) Signature() string {
+	parts := make([]string, len(f.Columns))
+	for i, col := range f.Columns {
+		parts[i] = col.Param + " " + col.GoType
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Args returns the comma separated parameter names of the finder
+func (f Finder) Args() string {
+	return strings.Join(f.params(), ", ")
+}
+
+// RecorderSignature returns the parameter list used by gomock recorders
+func (f Finder) RecorderSignature() string {
+	return strings.Join(f.params(), ", ") + " interface{}"
+}
+
+// Nullable reports whether a column of the finder is matched with IS NULL when its argument is nil,
+// the WHERE clause is then built when the finder is called
+func (f Finder) Nullable() bool {
+	for _, col := range f.Columns {
+		if col.Nullable {
+			return true
+		}
+	}
+	return false
+}
+
+// ValueArgs returns the comma separated arguments of the finder given variables of the value
+// types named after its parameters, the address of the nullable ones is taken
+func (f Finder) ValueArgs() string {
+	args := f.params()
+	for i, col := range f.Columns {
+		if col.Nullable {
+			args[i] = "&" + args[i]
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// Where returns the SQL condition matching the finder columns starting at $1
+func (f Finder) Where() string {
+	parts := make([]string, len(f.Columns))
+	for i, col := range f.Columns {
+		parts[i] = col.Name + " = $" + strconv.Itoa(i+1)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// Description returns a human readable list of the finder columns
+func (f Finder) Description() string {
+	names := make([]string, len(f.Columns))
+	for i, col := range f.Columns {
+		names[i] = col.Name
+	}
+	return strings.Join(names, " and ")
+}
+
+// NotFoundFormat returns the fmt format used when a unique finder matches no row
+func (f Finder) NotFoundFormat() string {
+	parts := make([]string, len(f.Columns))
+	for i, col := range f.Columns {
+		parts[i] = col.Name + " %v"
+	}
+	return strings.Join(parts, " and ")
+}
+
+// LimitParam returns the placeholder index of the LIMIT argument of list finders
+func (f Finder) LimitParam() int {
+	return len(f.Columns) + 1
+}
+
+// OffsetParam returns the placeholder index of the OFFSET argument of list finders
+func (f Finder) OffsetParam() int {
+	return len(f.Columns) + 2
+}
+
+func (f Finder) params() []string {
+	params := make([]string, len(f.Columns))
+	for i, col := range f.Columns {
+		params[i] = col.Param
+	}
+	return params
+}
+
+// buildFinders derives finder methods from the indexes and foreign keys of a table.
+// Unique indexes produce GetBy/ExistsBy finders, non-unique indexes and foreign keys
+// produce paginated ListBy finders. The primary key index is skipped because it is
+// already covered by GetByID, and finders sharing the same columns are generated once.
+// List finders take the nullable columns as pointers, nil listing the rows where they are
+// NULL. Unique finders do not since NULLs are distinct in a unique index.
+func buildFinders(table introspector.Table) []Finder {
+	columns := make(map[string]introspector.Column, len(table.Columns))
+	for _, col := range table.Columns {
+		columns[col.Name] = col
+	}
+
+	// The generated methods use the lowercased struct name as a local variable
+	modelVar := strings.ToLower(toPascalCase(table.Name))
+
+	pkKey := strings.Join(primaryKeyColumns(table), ",")
+	seen := make(map[string]bool)
+	var finders []Finder
+
+	add := func(source string, cols []string, unique bool) {
+		key := strings.Join(cols, ",")
+		if len(cols) == 0 || key == pkKey || seen[key] {
+			return
+		}
+
+		finder := Finder{Name: finderName(cols), Unique: unique, Source: source}
+		for _, name := range cols {
+			col, ok := columns[name]
+			if !ok || !isComparableGoType(col.GoType) {
+				return
+			}
+			param := toParamName(col.Name)
+			if param == modelVar {
+				param += "Value"
+			}
+			column := FinderColumn{
+				Name:      col.Name,
+				Field:     toPascalCase(col.Name),
+				Param:     param,
+				GoType:    strings.TrimPrefix(col.GoType, "*"),
+				ValueType: strings.TrimPrefix(col.GoType, "*"),
+			}
+			if !unique && column.GoType != col.GoType {
+				column.GoType = col.GoType
+				column.Nullable = true
+			}
+			finder.Columns = append(finder.Columns, column)
+		}
+
+		seen[key] = true
+		finders = append(finders, finder)
+	}
+
+	indexes := sortedIndexes(table.Indexes)
+
+	// Unique indexes take precedence over non-unique ones covering the same columns
+	for _, idx := range indexes {
+		if idx.IsUnique {
+			add(idx.Name, idx.Columns, true)
+		}
+	}
+	for _, idx := range indexes {
+		if !idx.IsUnique {
+			add(idx.Name, idx.Columns, false)
+		}
+	}
+
+	// Composite foreign keys are reported one row per column under the same name
+	var fkNames []string
+	fkColumns := make(map[string][]string)
+	for _, fk := range table.ForeignKeys {
+		if _, exists := fkColumns[fk.Name]; !exists {
+			fkNames = append(fkNames, fk.Name)
+		}
+		fkColumns[fk.Name] = append(fkColumns[fk.Name], fk.Column)
+	}
+	sort.Strings(fkNames)
+	for _, name := range fkNames {
+		add(name, fkColumns[name], false)
+	}
+
+	sort.SliceStable(finders, func(i, j int) bool {
+		if finders[i].Unique != finders[j].Unique {
+			return finders[i].Unique
+		}
+		return finders[i].Name < finders[j].Name
+	})
+
+	return finders
+}
+
+// sortedIndexes returns a copy of the indexes ordered by name, since the
+// introspector collects them through a map
+func sortedIndexes(indexes []introspector.Index) []introspector.Index {
+	sorted := make([]introspector.Index, len(indexes))
+	copy(sorted, indexes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// primaryKeyColumns returns the primary key columns of a table
+func primaryKeyColumns(table introspector.Table) []string {
+	if len(table.PrimaryKeys) > 0 {
+		return table.PrimaryKeys
+	}
+
+	var pks []string
+	for _, col := range table.Columns {
+		if col.IsPrimaryKey {
+			pks = append(pks, col.Name)
+		}
+	}
+	return pks
+}
+
+// finderName builds the method suffix for a set of columns, e.g. "UserIdAndSlug"
+func finderName(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		parts[i] = toPascalCase(col)
+	}
+	return strings.Join(parts, "And")
+}
+
+// isComparableGoType reports whether values of a Go type can be matched with "=" in SQL
+func isComparableGoType(goType string) bool {
+	switch strings.TrimPrefix(goType, "*") {
+	case "interface{}", "json.RawMessage":
+		return false
+	default:
+		return true
+	}
+}
+
+// reservedParamNames are identifiers already used by the generated method bodies
+var reservedParamNames = map[string]bool{
+	"ctx": true, "limit": true, "offset": true, "query": true,
+	"rows": true, "err": true, "args": true, "exists": true,
+	"m": true, "mr": true, "r": true, "ret": true, "id": true,
+}
+
+// toParamName converts a column name to a safe Go parameter name
+func toParamName(column string) string {
+	name := toPascalCase(column)
+	if name == "" {
+		return "value"
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+
+	if token.IsKeyword(name) || reservedParamNames[name] {
+		name += "Value"
+	}
+	return name
+}
+
+// goTypeImports returns the import paths required by the given Go types
+func goTypeImports(goTypes ...string) []string {
+	known := map[string]string{
+		"time.":    "time",
+		"json.":    "encoding/json",
+		"uuid.":    "github.com/google/uuid",
+		"decimal.": "github.com/shopspring/decimal",
+	}
+
+	seen := make(map[string]bool)
+	var imports []string
+	for _, goType := range goTypes {
+		for prefix, path := range known {
+			if strings.Contains(goType, prefix) && !seen[path] {
+				seen[path] = true
+				imports = append(imports, path)
+			}
+		}
+	}
+
+	sort.Strings(imports)
+	return imports
+}
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestBuildFinders(t *testing.T) {
+	finders := buildFinders(testTable("posts"))
+
+	names := make([]string, len(finders))
+	for i, f := range finders {
+		names[i] = f.Name
+	}
+
+	// Unique finders come first, duplicates and the primary key are skipped,
+	// and json columns cannot be compared with "="
+	assert.Equal(t, []string{"Email", "UserIdAndSlug", "PublishedAt", "Type", "UserId"}, names)
+
+	byName := make(map[string]Finder)
+	for _, f := range finders {
+		byName[f.Name] = f
+	}
+
+	t.Run("unique index", func(t *testing.T) {
+		f := byName["Email"]
+		assert.True(t, f.Unique)
+		assert.Equal(t, "posts_email_key", f.Source)
+		require.Len(t, f.Columns, 1)
+		assert.Equal(t, "string", f.Columns[0].GoType, "nullable columns are looked up by value")
+	})
+
+	t.Run("composite unique index", func(t *testing.T) {
+		f := byName["UserIdAndSlug"]
+		assert.True(t, f.Unique)
+		assert.Equal(t, "userId int, slug string", f.Signature())
+		assert.Equal(t, "userId, slug", f.Args())
+		assert.Equal(t, "userId, slug interface{}", f.RecorderSignature())
+		assert.Equal(t, "user_id = $1 AND slug = $2", f.Where())
+		assert.Equal(t, "user_id and slug", f.Description())
+		assert.Equal(t, "user_id %v and slug %v", f.NotFoundFormat())
+		assert.Equal(t, 3, f.LimitParam())
+		assert.Equal(t, 4, f.OffsetParam())
+	})
+
+	t.Run("nullable column", func(t *testing.T) {
+		f := byName["PublishedAt"]
+		assert.False(t, f.Unique)
+		assert.True(t, f.Nullable())
+		require.Len(t, f.Columns, 1)
+		assert.Equal(t, "*time.Time", f.Columns[0].GoType, "list finders take the model field type")
+		assert.Equal(t, "time.Time", f.Columns[0].ValueType)
+		assert.Equal(t, "publishedAt *time.Time", f.Signature())
+		assert.Equal(t, "&publishedAt", f.ValueArgs())
+		assert.False(t, byName["Email"].Nullable())
+		assert.Equal(t, "userId, slug", byName["UserIdAndSlug"].ValueArgs())
+	})
+
+	t.Run("foreign key", func(t *testing.T) {
+		f := byName["UserId"]
+		assert.False(t, f.Unique)
+		assert.Equal(t, "posts_user_id_fkey", f.Source)
+	})
+
+	t.Run("foreign key covered by an index", func(t *testing.T) {
+		f := byName["Type"]
+		assert.False(t, f.Unique)
+		assert.Equal(t, "posts_type_idx", f.Source)
+		assert.Equal(t, "typeValue string", f.Signature())
+	})
+}
+
+func TestBuildFinders_CompositeForeignKey(t *testing.T) {
+	table := introspector.Table{
+		Name: "order_items",
+		Columns: []introspector.Column{
+			{Name: "id", GoType: "int", IsPrimaryKey: true},
+			{Name: "order_id", GoType: "int"},
+			{Name: "order_version", GoType: "int"},
+		},
+		ForeignKeys: []introspector.ForeignKey{
+			{Name: "order_items_order_fkey", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+			{Name: "order_items_order_fkey", Column: "order_version", ReferencedTable: "orders", ReferencedColumn: "version"},
+		},
+	}
+
+	finders := buildFinders(table)
+	require.Len(t, finders, 1)
+	assert.Equal(t, "OrderIdAndOrderVersion", finders[0].Name)
+	assert.Equal(t, "order_id = $1 AND order_version = $2", finders[0].Where())
+}
+
+func TestBuildFinders_NoIndexes(t *testing.T) {
+	table := introspector.Table{
+		Name: "logs",
+		Columns: []introspector.Column{
+			{Name: "message", GoType: "string"},
+		},
+	}
+
+	assert.Empty(t, buildFinders(table))
+}
+
+func TestToParamName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"email", "email"},
+		{"user_id", "userId"},
+		{"type", "typeValue"},
+		{"limit", "limitValue"},
+		{"ctx", "ctxValue"},
+		{"", "value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.expected, toParamName(test.input))
+		})
+	}
+}
+
+func TestGoTypeImports(t *testing.T) {
+	assert.Empty(t, goTypeImports("int", "string"))
+	assert.Equal(t, []string{"github.com/google/uuid", "time"}, goTypeImports("uuid.UUID", "*time.Time", "time.Time"))
+	assert.Equal(t, []string{"encoding/json", "github.com/shopspring/decimal"}, goTypeImports("decimal.Decimal", "json.RawMessage"))
+}
@@ -0,0 +1,541 @@
+package generator
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// testSchema returns the schema shared by the generator tests, a new one on every call
+// so that the tests may change its tables. Its tables cover what the features depend on:
+//   - accounts: a uuid key and a column with a default, a version and a soft delete column
+//   - authors and books: comments, and foreign keys from books to authors, nullable, of
+//     another type, shadowed by a column or to a missing table
+//   - orders: enums, generated, decimal, date, time, bytea and json columns, with positions
+//     missing the dropped third column
+//   - posts: unique, composite, nullable and json indexed columns, and foreign keys
+//   - projects: a tenant column, alone and first in indexes
+func testSchema() *introspector.Schema {
+	uuidDefault, planDefault := "gen_random_uuid()", "'free'"
+
+	return &introspector.Schema{Tables: []introspector.Table{
+		{
+			Name: "accounts",
+			Columns: []introspector.Column{
+				{Name: "id", Type: "uuid", GoType: "uuid.UUID", IsPrimaryKey: true, DefaultValue: &uuidDefault},
+				{Name: "email", Type: "text", GoType: "string"},
+				{Name: "nickname", Type: "text", GoType: "*string", IsNullable: true},
+				{Name: "plan", Type: "text", GoType: "string", DefaultValue: &planDefault},
+				{Name: "balance", Type: "bigint", GoType: "int64"},
+				{Name: "revision", Type: "integer", GoType: "*int32", IsNullable: true},
+				{Name: "version", Type: "integer", GoType: "int32"},
+				{Name: "deleted_at", Type: "timestamp with time zone", GoType: "*time.Time", IsNullable: true},
+			},
+			PrimaryKeys: []string{"id"},
+			Indexes: []introspector.Index{
+				{Name: "accounts_email_key", Columns: []string{"email"}, IsUnique: true},
+				{Name: "accounts_plan_idx", Columns: []string{"plan"}},
+			},
+		},
+		{
+			Name: "authors",
+			Columns: []introspector.Column{
+				{Name: "id", GoType: "int64", IsPrimaryKey: true, IsGenerated: true},
+				{Name: "name", GoType: "string", Comment: "Display name"},
+			},
+			PrimaryKeys: []string{"id"},
+		},
+		{
+			Name:    "books",
+			Comment: "Published books",
+			Columns: []introspector.Column{
+				{Name: "id", GoType: "uuid.UUID", IsPrimaryKey: true},
+				{Name: "author_id", GoType: "int64"},
+				{Name: "editor_id", GoType: "*int64", IsNullable: true},
+				{Name: "author", GoType: "string"},
+				{Name: "legacy_author_id", GoType: "int32"},
+				{Name: "genre", GoType: "interface{}", EnumValues: []string{"novel", "essay"}},
+				{Name: "published_at", GoType: "*time.Time", IsNullable: true},
+			},
+			PrimaryKeys: []string{"id"},
+			ForeignKeys: []introspector.ForeignKey{
+				{Name: "books_author_id_fkey", Column: "author_id", ReferencedTable: "authors", ReferencedColumn: "id"},
+				{Name: "books_editor_id_fkey", Column: "editor_id", ReferencedTable: "authors", ReferencedColumn: "id"},
+				{Name: "books_legacy_author_id_fkey", Column: "legacy_author_id", ReferencedTable: "authors", ReferencedColumn: "id"},
+				{Name: "books_series_id_fkey", Column: "series_id", ReferencedTable: "series", ReferencedColumn: "id"},
+			},
+			Indexes: []introspector.Index{
+				{Name: "books_author_id_idx", Columns: []string{"author_id"}},
+			},
+		},
+		{
+			Name:    "orders",
+			Comment: "Orders of the shop",
+			Columns: []introspector.Column{
+				{Name: "id", Type: "uuid", GoType: "uuid.UUID", IsPrimaryKey: true, DefaultValue: &uuidDefault, Position: 1},
+				{Name: "number", Type: "bigint", GoType: "int64", IsGenerated: true, Position: 2},
+				{Name: "status", Type: "order_status", GoType: "interface{}", EnumValues: []string{"open", "paid"}, Position: 4},
+				{Name: "refund", Type: "order_refund", GoType: "interface{}", IsNullable: true, EnumValues: []string{"partial", "full"}, Position: 5},
+				{Name: "items", Type: "integer", GoType: "int", Position: 6},
+				{Name: "total", Type: "numeric", GoType: "decimal.Decimal", Position: 7},
+				{Name: "note", Type: "text", GoType: "*string", IsNullable: true, Comment: "Free text, not /* parsed */", Position: 8},
+				{Name: "placed_at", Type: "timestamp without time zone", GoType: "time.Time", Position: 9},
+				{Name: "paid_at", Type: "timestamp with time zone", GoType: "*time.Time", IsNullable: true, Position: 10},
+				{Name: "due_on", Type: "date", GoType: "time.Time", Position: 11},
+				{Name: "receipt", Type: "bytea", GoType: "[]byte", Position: 12},
+				{Name: "ship_to", Type: "jsonb", GoType: "json.RawMessage", Position: 13},
+			},
+			PrimaryKeys: []string{"id"},
+		},
+		{
+			Name: "posts",
+			Columns: []introspector.Column{
+				{Name: "id", GoType: "int64", IsPrimaryKey: true},
+				{Name: "user_id", GoType: "int"},
+				{Name: "slug", GoType: "string"},
+				{Name: "type", GoType: "string"},
+				{Name: "email", GoType: "*string", IsNullable: true},
+				{Name: "payload", GoType: "json.RawMessage"},
+				{Name: "published_at", GoType: "*time.Time", IsNullable: true},
+			},
+			PrimaryKeys: []string{"id"},
+			Indexes: []introspector.Index{
+				{Name: "posts_type_idx", Columns: []string{"type"}},
+				{Name: "posts_pkey", Columns: []string{"id"}, IsUnique: true},
+				{Name: "posts_user_id_slug_key", Columns: []string{"user_id", "slug"}, IsUnique: true},
+				{Name: "posts_email_key", Columns: []string{"email"}, IsUnique: true},
+				{Name: "posts_email_idx", Columns: []string{"email"}},
+				{Name: "posts_payload_idx", Columns: []string{"payload"}},
+				{Name: "posts_published_at_idx", Columns: []string{"published_at"}},
+			},
+			ForeignKeys: []introspector.ForeignKey{
+				{Name: "posts_user_id_fkey", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+				{Name: "posts_type_fkey", Column: "type", ReferencedTable: "post_types", ReferencedColumn: "name"},
+			},
+		},
+		{
+			Name: "projects",
+			Columns: []introspector.Column{
+				{Name: "id", Type: "bigint", GoType: "int64", IsPrimaryKey: true, IsGenerated: true},
+				{Name: "tenant_id", Type: "uuid", GoType: "uuid.UUID"},
+				{Name: "slug", Type: "text", GoType: "string"},
+				{Name: "name", Type: "text", GoType: "string"},
+			},
+			Indexes: []introspector.Index{
+				{Name: "projects_tenant_id_idx", Columns: []string{"tenant_id"}},
+				{Name: "projects_tenant_id_slug_key", Columns: []string{"tenant_id", "slug"}, IsUnique: true},
+			},
+		},
+	}}
+}
+
+// testTable returns the table of testSchema with that name
+func testTable(name string) introspector.Table {
+	for _, table := range testSchema().Tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	panic("no test table named " + name)
+}
+
+// testTables returns a schema holding the tables of testSchema with those names
+func testTables(names ...string) *introspector.Schema {
+	schema := &introspector.Schema{}
+	for _, name := range names {
+		schema.Tables = append(schema.Tables, testTable(name))
+	}
+	return schema
+}
+
+// schemaBlocks splits a generated proto, GraphQL or TypeScript file into the lines of its
+// blocks, keyed by their declaration such as "message Orders" or "type Books", the top
+// level lines being keyed by an empty string
+func schemaBlocks(src string) map[string][]string {
+	blocks := make(map[string][]string)
+	block := ""
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.Contains(line, "Code generated by pgx-goose"):
+		case strings.HasSuffix(line, "{"):
+			block = strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			blocks[block] = []string{}
+		case line == "}":
+			block = ""
+		case block == "" && strings.HasPrefix(line, "//"):
+		default:
+			blocks[block] = append(blocks[block], line)
+		}
+	}
+	return blocks
+}
+
+// goSource is generated Go code parsed by the tests, which check its declarations
+// rather than its text
+type goSource struct {
+	fset  *token.FileSet
+	file  *ast.File
+	decls map[string]ast.Node
+	docs  map[string]*ast.CommentGroup
+}
+
+// parseGoSource parses generated Go code, failing the test when it is not valid Go
+func parseGoSource(t *testing.T, src string) goSource {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+	require.NoError(t, err, src)
+
+	s := goSource{fset: fset, file: file, decls: make(map[string]ast.Node), docs: make(map[string]*ast.CommentGroup)}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				name = receiverName(decl.Recv.List[0].Type) + "." + name
+			}
+			s.decls[name] = decl
+			s.docs[name] = decl.Doc
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					s.decls[spec.Name.Name] = spec
+					s.docs[spec.Name.Name] = spec.Doc
+					if spec.Doc == nil {
+						s.docs[spec.Name.Name] = decl.Doc
+					}
+					if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+						for _, method := range iface.Methods.List {
+							for _, name := range method.Names {
+								s.decls[spec.Name.Name+"."+name.Name] = method
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for i, name := range spec.Names {
+						s.decls[name.Name] = spec
+						if i < len(spec.Values) {
+							s.decls[name.Name] = spec.Values[i]
+						}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// renderGoSource renders an embedded template of the generator and parses the result
+func renderGoSource(t *testing.T, g *Generator, name string, data any) goSource {
+	t.Helper()
+
+	tmpl, err := g.getEmbeddedTemplate(name)
+	require.NoError(t, err)
+
+	var buf strings.Builder
+	require.NoError(t, tmpl.Execute(&buf, data))
+	return parseGoSource(t, buf.String())
+}
+
+// receiverName returns the name of the type of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// Imports returns the paths imported by the file, in their order
+func (s goSource) Imports() []string {
+	paths := make([]string, len(s.file.Imports))
+	for i, spec := range s.file.Imports {
+		paths[i], _ = strconv.Unquote(spec.Path.Value)
+	}
+	return paths
+}
+
+// BuildConstraint returns the expression of the //go:build line of the file, e.g. "otel"
+func (s goSource) BuildConstraint() string {
+	for _, group := range s.file.Comments {
+		if group.Pos() > s.file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if expr, ok := strings.CutPrefix(comment.Text, "//go:build "); ok {
+				return expr
+			}
+		}
+	}
+	return ""
+}
+
+// Has reports whether a type, function, variable or constant is declared, methods and
+// interface methods being named "Type.Method"
+func (s goSource) Has(name string) bool {
+	_, ok := s.decls[name]
+	return ok
+}
+
+// Signature returns the signature of a function or method, e.g. "func(ctx context.Context) error",
+// or an empty string when it is not declared
+func (s goSource) Signature(name string) string {
+	var fn *ast.FuncType
+	switch decl := s.decls[name].(type) {
+	case *ast.FuncDecl:
+		fn = decl.Type
+	case *ast.Field:
+		fn, _ = decl.Type.(*ast.FuncType)
+	}
+	if fn == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, s.fset, fn); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Doc returns the doc comment of a type, function or method
+func (s goSource) Doc(name string) string {
+	return s.docs[name].Text()
+}
+
+// Fields returns the fields of a struct type as "Name Type", e.g. "Email *string"
+func (s goSource) Fields(name string) []string {
+	spec, ok := s.decls[name].(*ast.TypeSpec)
+	if !ok {
+		return nil
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	var fields []string
+	for _, field := range st.Fields.List {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, s.fset, field.Type)
+		for _, name := range field.Names {
+			fields = append(fields, name.Name+" "+buf.String())
+		}
+	}
+	return fields
+}
+
+// Strings returns the string literals of a declaration with their whitespace collapsed,
+// such as the SQL run by a method or held by a constant
+func (s goSource) Strings(name string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var values []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if value, err := strconv.Unquote(lit.Value); err == nil {
+				values = append(values, strings.Join(strings.Fields(value), " "))
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// Calls returns the arguments of the calls to a function or method named fn in a
+// declaration, e.g. "&posts.Id, &posts.Xmin" for the Scan of PostsRepository.Create
+func (s goSource) Calls(name, fn string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var calls []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		called := call.Fun
+		if sel, ok := called.(*ast.SelectorExpr); ok {
+			called = sel.Sel
+		}
+		if ident, ok := called.(*ast.Ident); ok && ident.Name == fn {
+			args := make([]string, len(call.Args))
+			for i, arg := range call.Args {
+				var buf bytes.Buffer
+				_ = printer.Fprint(&buf, s.fset, arg)
+				args[i] = buf.String()
+			}
+			calls = append(calls, strings.Join(args, ", "))
+		}
+		return true
+	})
+	return calls
+}
+
+// Vars returns the variables declared with a type in a declaration as "name Type",
+// e.g. "publishedAt *time.Time"
+func (s goSource) Vars(name string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var vars []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || spec.Type == nil {
+			return true
+		}
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, s.fset, spec.Type)
+		for _, ident := range spec.Names {
+			vars = append(vars, ident.Name+" "+buf.String())
+		}
+		return true
+	})
+	return vars
+}
+
+// Values returns the values of the constants and variables named ident declared in a
+// declaration, e.g. "6" for "const columnsPerRow = 6"
+func (s goSource) Values(name, ident string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var values []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, id := range spec.Names {
+			if id.Name == ident && i < len(spec.Values) {
+				var buf bytes.Buffer
+				_ = printer.Fprint(&buf, s.fset, spec.Values[i])
+				values = append(values, buf.String())
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// Assigns returns the values assigned to an expression in a declaration, e.g. "nil" for
+// "clone.replicas = nil" with the expression "clone.replicas"
+func (s goSource) Assigns(name, expr string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var values []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			var buf bytes.Buffer
+			if printer.Fprint(&buf, s.fset, lhs) == nil && buf.String() == expr {
+				buf.Reset()
+				_ = printer.Fprint(&buf, s.fset, assign.Rhs[i])
+				values = append(values, buf.String())
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// Returns returns the results of the return statements of a declaration, e.g.
+// "nil, mapError(err)"
+func (s goSource) Returns(name string) []string {
+	node, ok := s.decls[name]
+	if !ok {
+		return nil
+	}
+	var returns []string
+	ast.Inspect(node, func(n ast.Node) bool {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok {
+			return true
+		}
+		results := make([]string, len(ret.Results))
+		for i, result := range ret.Results {
+			var buf bytes.Buffer
+			_ = printer.Fprint(&buf, s.fset, result)
+			results[i] = buf.String()
+		}
+		returns = append(returns, strings.Join(results, ", "))
+		return true
+	})
+	return returns
+}
+
+// Implements returns the types asserted to implement an interface by the blank variables
+// of the file, e.g. "*pgxpool.Pool" for "_ DBTX = (*pgxpool.Pool)(nil)"
+func (s goSource) Implements(iface string) []string {
+	var types []string
+	for _, decl := range s.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			var buf bytes.Buffer
+			if spec.Type == nil || printer.Fprint(&buf, s.fset, spec.Type) != nil || buf.String() != iface {
+				continue
+			}
+			for i, name := range spec.Names {
+				if name.Name != "_" || i >= len(spec.Values) {
+					continue
+				}
+				value := spec.Values[i]
+				if call, ok := value.(*ast.CallExpr); ok {
+					if paren, ok := call.Fun.(*ast.ParenExpr); ok {
+						value = paren.X
+					}
+				}
+				buf.Reset()
+				_ = printer.Fprint(&buf, s.fset, value)
+				types = append(types, buf.String())
+			}
+		}
+	}
+	return types
+}
+
+// Uses reports whether a declaration refers to an identifier, such as an error it returns
+func (s goSource) Uses(name, ident string) bool {
+	node, ok := s.decls[name]
+	if !ok {
+		return false
+	}
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == ident {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -32,6 +33,7 @@ func (g *Generator) getTemplate(name string) (*template.Template, error) {
 	funcMap := template.FuncMap{
 		"toPascalCase": toPascalCase,
 		"lower":        strings.ToLower,
+		"contains":     strings.Contains,
 		"add": func(a, b int) int {
 			return a + b
 		},
@@ -47,12 +49,10 @@ func (g *Generator) getTemplate(name string) (*template.Template, error) {
 	}
 
 	// Try to load from custom template directory first
-	if g.config.TemplateDir != "" {
+	if g.isCustomTemplate(name) {
 		templatePath := filepath.Join(g.config.TemplateDir, name)
-		if _, err := os.Stat(templatePath); err == nil {
-			slog.Debug("Loading template from file", "path", templatePath)
-			return template.New(name).Funcs(funcMap).ParseFiles(templatePath)
-		}
+		slog.Debug("Loading template from file", "path", templatePath)
+		return template.New(name).Funcs(funcMap).ParseFiles(templatePath)
 	}
 
 	// Fallback to embedded templates
@@ -60,13 +60,51 @@ func (g *Generator) getTemplate(name string) (*template.Template, error) {
 	return g.getEmbeddedTemplate(name)
 }
 
+// isCustomTemplate reports whether the named template is loaded from the custom template directory
+func (g *Generator) isCustomTemplate(name string) bool {
+	if g.config.TemplateDir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(g.config.TemplateDir, name))
+	return err == nil
+}
+
 // Generate generates all code files
 func (g *Generator) Generate(schema *introspector.Schema) error {
+	schema = g.repositoryTables(schema)
+
 	// Create output directory structure
 	if err := g.createDirectories(); err != nil {
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	if err := g.generateTableFiles(schema); err != nil {
+		return err
+	}
+	return g.generateSchemaFiles(schema)
+}
+
+// repositoryTables returns the schema without the tables that get no repository: the
+// outbox and history tables are written by the generated outbox and audit triggers
+func (g *Generator) repositoryTables(schema *introspector.Schema) *introspector.Schema {
+	if g.config.IsOutboxEnabled() {
+		schema = withoutTables(schema, g.config.Outbox.Table)
+	}
+	if g.config.IsAuditEnabled() {
+		var histories []string
+		for _, table := range schema.Tables {
+			if g.config.IsAudited(table.Name) {
+				histories = append(histories, historyTable(table.Name))
+			}
+		}
+		schema = withoutTables(schema, histories...)
+	}
+	return schema
+}
+
+// generateTableFiles generates the files of each table on its own: the models, the
+// repository interfaces and implementations, the mocks and the tests
+func (g *Generator) generateTableFiles(schema *introspector.Schema) error {
 	// Generate models
 	if err := g.generateModels(schema); err != nil {
 		return fmt.Errorf("failed to generate models: %w", err)
@@ -97,6 +135,91 @@ func (g *Generator) Generate(schema *introspector.Schema) error {
 	return nil
 }
 
+// generateSchemaFiles generates the files spanning the tables of the schema: the shared
+// support files, the migrations and the optional artifacts aggregating or relating the
+// tables. Every generation path calls it with all the tables of the schema.
+func (g *Generator) generateSchemaFiles(schema *introspector.Schema) error {
+	// Generate shared model support files
+	if err := g.generateModelSupport(); err != nil {
+		return fmt.Errorf("failed to generate model support files: %w", err)
+	}
+
+	// Generate shared repository support files
+	if err := g.generateRepositorySupport(schema); err != nil {
+		return fmt.Errorf("failed to generate repository support files: %w", err)
+	}
+
+	// Generate change notifications if enabled
+	if g.config.IsNotifyEnabled() {
+		if err := g.generateNotify(schema); err != nil {
+			return fmt.Errorf("failed to generate change notifications: %w", err)
+		}
+	}
+
+	// Generate the outbox migration if enabled
+	if g.config.IsOutboxEnabled() {
+		if err := g.generateOutbox(); err != nil {
+			return fmt.Errorf("failed to generate outbox: %w", err)
+		}
+	}
+
+	// Generate the audit history tables and repositories if enabled
+	if g.config.IsAuditEnabled() {
+		if err := g.generateAudit(schema); err != nil {
+			return fmt.Errorf("failed to generate audit history: %w", err)
+		}
+	}
+
+	// Generate the cached repositories if enabled
+	if g.config.IsCacheEnabled() {
+		if err := g.generateCache(schema); err != nil {
+			return fmt.Errorf("failed to generate cached repositories: %w", err)
+		}
+	}
+
+	// Generate the HTTP handlers if their directory is set
+	if g.config.IsHandlersEnabled() {
+		if err := g.generateHandlers(schema); err != nil {
+			return fmt.Errorf("failed to generate HTTP handlers: %w", err)
+		}
+	}
+
+	// Generate the protobuf definitions if their directory is set
+	if g.config.IsProtoEnabled() {
+		if err := g.generateProto(schema); err != nil {
+			return fmt.Errorf("failed to generate protobuf definitions: %w", err)
+		}
+	}
+
+	// Generate the OpenAPI document if its directory is set
+	if g.config.IsOpenAPIEnabled() {
+		if err := g.generateOpenAPI(schema); err != nil {
+			return fmt.Errorf("failed to generate OpenAPI document: %w", err)
+		}
+	}
+
+	// Generate the GraphQL schema and resolvers if their directory is set
+	if g.config.IsGraphQLEnabled() {
+		if err := g.generateGraphQL(schema); err != nil {
+			return fmt.Errorf("failed to generate GraphQL schema: %w", err)
+		}
+	}
+
+	// Generate the JSON Schemas and TypeScript declarations of the models if their directories are set
+	if g.config.IsJSONSchemaEnabled() {
+		if err := g.generateJSONSchemas(schema); err != nil {
+			return fmt.Errorf("failed to generate JSON Schemas: %w", err)
+		}
+	}
+	if g.config.IsTypeScriptEnabled() {
+		if err := g.generateTypeScript(schema); err != nil {
+			return fmt.Errorf("failed to generate TypeScript declarations: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // createDirectories creates the necessary directory structure
 func (g *Generator) createDirectories() error {
 	dirs := g.config.GetAllOutputDirs()
@@ -122,16 +245,19 @@ func (g *Generator) generateModels(schema *introspector.Schema) error {
 		return err
 	}
 
+	patchTmpl, err := g.getTemplate("model_patch.tmpl")
+	if err != nil {
+		return err
+	}
+
+	filterTmpl, err := g.getTemplate("model_filter.tmpl")
+	if err != nil {
+		return err
+	}
+
 	for _, table := range schema.Tables {
-		data := struct {
-			Table      introspector.Table
-			StructName string
-			Package    string
-		}{
-			Table:      table,
-			StructName: toPascalCase(table.Name),
-			Package:    "models",
-		}
+		table = withSystemVersion(table, g.getVersionColumnName(table))
+		data := g.newModelTemplateData(table)
 
 		filename := fmt.Sprintf("%s.go", toSnakeCase(table.Name))
 		filepath := filepath.Join(g.config.GetModelsDir(), filename)
@@ -141,6 +267,170 @@ func (g *Generator) generateModels(schema *introspector.Schema) error {
 		}
 
 		slog.Debug("Generated model", "filename", filename)
+
+		if err := g.generateModelPatch(patchTmpl, table); err != nil {
+			return fmt.Errorf("failed to generate patch model for table %s: %w", table.Name, err)
+		}
+
+		if err := g.generateModelFilter(filterTmpl, table); err != nil {
+			return fmt.Errorf("failed to generate filter model for table %s: %w", table.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// modelField describes a model struct field
+type modelField struct {
+	Name string
+	Type string
+}
+
+// modelTemplateData holds the data of the model template
+type modelTemplateData struct {
+	Table           introspector.Table
+	StructName      string
+	Package         string
+	ReceiverName    string
+	Fields          []modelField
+	NeedsValidation bool
+	HasCreatedAt    bool   // created_at is a non-nullable timestamp
+	HasUpdatedAt    bool   // updated_at is a non-nullable timestamp
+	HasSoftDelete   bool   // Soft delete is enabled and uses a nullable deletion timestamp
+	SoftDeleteField string // Model field holding the deletion timestamp
+}
+
+// newModelTemplateData builds the model template data for a table
+func (g *Generator) newModelTemplateData(table introspector.Table) modelTemplateData {
+	structName := toPascalCase(table.Name)
+
+	data := modelTemplateData{
+		Table:        table,
+		StructName:   structName,
+		Package:      "models",
+		ReceiverName: strings.ToLower(structName[:1]),
+	}
+
+	for _, col := range table.Columns {
+		data.Fields = append(data.Fields, modelField{Name: toPascalCase(col.Name), Type: col.GoType})
+
+		switch {
+		case col.Name == "created_at" && col.GoType == "time.Time":
+			data.HasCreatedAt = true
+		case col.Name == "updated_at" && col.GoType == "time.Time":
+			data.HasUpdatedAt = true
+		}
+	}
+
+	// The model helpers set and test a timestamp, boolean flags are only handled by repositories
+	if softDelete := g.getSoftDelete(table); softDelete != nil && !softDelete.Flag {
+		data.HasSoftDelete = true
+		data.SoftDeleteField = softDelete.Field
+	}
+
+	return data
+}
+
+// generateModelPatch generates the struct listing the columns a partial update may change.
+// Tables without updatable columns get no patch struct.
+func (g *Generator) generateModelPatch(tmpl *template.Template, table introspector.Table) error {
+	columns := g.getUpdateColumns(table)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	goTypes := make([]string, len(columns))
+	for i, col := range columns {
+		goTypes[i] = col.GoType
+	}
+
+	data := struct {
+		Table      introspector.Table
+		StructName string
+		Package    string
+		Imports    []string
+		Columns    []introspector.Column
+	}{
+		Table:      table,
+		StructName: toPascalCase(table.Name),
+		Package:    "models",
+		Imports:    goTypeImports(goTypes...),
+		Columns:    columns,
+	}
+
+	filename := fmt.Sprintf("%s_patch.go", toSnakeCase(table.Name))
+	if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetModelsDir(), filename), data); err != nil {
+		return err
+	}
+
+	slog.Debug("Generated patch model", "filename", filename)
+	return nil
+}
+
+// generateModelFilter generates the filter struct and sort fields used by Find
+func (g *Generator) generateModelFilter(tmpl *template.Template, table introspector.Table) error {
+	filters := buildFilterFields(table)
+
+	types := make([]string, len(filters))
+	for i, filter := range filters {
+		types[i] = filter.Type
+	}
+
+	data := struct {
+		Table      introspector.Table
+		StructName string
+		Package    string
+		Imports    []string
+		Filters    []FilterField
+		SortFields []SortField
+	}{
+		Table:      table,
+		StructName: toPascalCase(table.Name),
+		Package:    "models",
+		Imports:    goTypeImports(types...),
+		Filters:    filters,
+		SortFields: buildSortFields(table),
+	}
+
+	filename := fmt.Sprintf("%s_filter.go", toSnakeCase(table.Name))
+	if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetModelsDir(), filename), data); err != nil {
+		return err
+	}
+
+	slog.Debug("Generated filter model", "filename", filename)
+	return nil
+}
+
+// generateModelSupport generates the files shared by all models: the Optional type
+// used by patch structs, the predicate types used by filters and the keyset Page
+func (g *Generator) generateModelSupport() error {
+	data := struct {
+		Package string
+	}{
+		Package: "models",
+	}
+
+	files := []struct {
+		template string
+		filename string
+	}{
+		{"optional.tmpl", "optional.go"},
+		{"filter.tmpl", "filter.go"},
+		{"page.tmpl", "page.go"},
+	}
+
+	for _, file := range files {
+		tmpl, err := g.getTemplate(file.template)
+		if err != nil {
+			return err
+		}
+
+		filepath := filepath.Join(g.config.GetModelsDir(), file.filename)
+		if err := g.writeTemplate(tmpl, filepath, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", file.filename, err)
+		}
+
+		slog.Debug("Generated model support file", "filename", file.filename)
 	}
 
 	return nil
@@ -156,19 +446,7 @@ func (g *Generator) generateRepositoryInterfaces(schema *introspector.Schema) er
 	}
 
 	for _, table := range schema.Tables {
-		data := struct {
-			Table          introspector.Table
-			StructName     string
-			InterfaceName  string
-			Package        string
-			PrimaryKeyType string
-		}{
-			Table:          table,
-			StructName:     toPascalCase(table.Name),
-			InterfaceName:  toPascalCase(table.Name) + "Repository",
-			Package:        "interfaces",
-			PrimaryKeyType: g.getPrimaryKeyType(table),
-		}
+		data := g.newRepositoryTemplateData(table, "interfaces")
 
 		filename := fmt.Sprintf("%s_repository.go", toSnakeCase(table.Name))
 		filepath := filepath.Join(g.config.GetInterfacesDir(), filename)
@@ -193,25 +471,7 @@ func (g *Generator) generateRepositoryImplementations(schema *introspector.Schem
 	}
 
 	for _, table := range schema.Tables {
-		data := struct {
-			Table           introspector.Table
-			StructName      string
-			InterfaceName   string
-			ImplName        string
-			Package         string
-			PrimaryKeyType  string
-			PrimaryKeyCol   string
-			PrimaryKeyField string
-		}{
-			Table:           table,
-			StructName:      toPascalCase(table.Name),
-			InterfaceName:   toPascalCase(table.Name) + "Repository",
-			ImplName:        toPascalCase(table.Name) + "Repository",
-			Package:         "postgres",
-			PrimaryKeyType:  g.getPrimaryKeyType(table),
-			PrimaryKeyCol:   g.getPrimaryKeyColumn(table),
-			PrimaryKeyField: toPascalCase(g.getPrimaryKeyColumn(table)),
-		}
+		data := g.newRepositoryTemplateData(table, "postgres")
 
 		filename := fmt.Sprintf("%s_repository.go", toSnakeCase(table.Name))
 		filepath := filepath.Join(g.config.GetReposDir(), filename)
@@ -226,6 +486,228 @@ func (g *Generator) generateRepositoryImplementations(schema *introspector.Schem
 	return nil
 }
 
+// withoutTables returns the schema without the named tables
+func withoutTables(schema *introspector.Schema, names ...string) *introspector.Schema {
+	tables := slices.DeleteFunc(slices.Clone(schema.Tables), func(table introspector.Table) bool {
+		return slices.Contains(names, table.Name)
+	})
+	return &introspector.Schema{Tables: tables}
+}
+
+// generateRepositorySupport generates the files shared by all repository implementations:
+// the DBTX querier interface, the transaction manager aggregating every repository,
+// the query builder, the keyset cursor helpers, the context options and the errors
+func (g *Generator) generateRepositorySupport(schema *introspector.Schema) error {
+	slog.Info("Generating repository support files...")
+
+	tables := make([]repositoryTemplateData, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		tables = append(tables, g.newRepositoryTemplateData(table, "postgres"))
+	}
+
+	data := struct {
+		Package      string
+		Tables       []repositoryTemplateData
+		Outbox       string // Outbox table, empty unless the outbox is enabled
+		OutboxRef    string // Outbox table as referenced by the SQL
+		Tenancy      string // Tenancy mode, empty unless the queries are scoped to tenants
+		TenantColumn string
+		BatchImports []string // Imports of the primary key and version types taken by the batch
+	}{
+		Package: "postgres",
+		Tables:  tables,
+	}
+
+	var batchTypes []string
+	for _, table := range tables {
+		batchTypes = append(batchTypes, table.PrimaryKeyType)
+		if table.Version != nil {
+			batchTypes = append(batchTypes, table.Version.GoType)
+		}
+	}
+	data.BatchImports = goTypeImports(batchTypes...)
+
+	type supportFile struct {
+		template string
+		filename string
+	}
+
+	files := []supportFile{
+		{"db.tmpl", "db.go"},
+		{"tx_manager.tmpl", "tx_manager.go"},
+		{"query.tmpl", "query.go"},
+		{"stream.tmpl", "stream.go"},
+		{"cursor.tmpl", "cursor.go"},
+		{"context.tmpl", "context.go"},
+		{"errors.tmpl", "errors.go"},
+		{"options.tmpl", "options.go"},
+		{"hooks.tmpl", "hooks.go"},
+		{"replicas.tmpl", "replicas.go"},
+	}
+
+	// The batch queues the statements declared by the embedded repository template, which a
+	// custom one does not declare unless it comes with its own batch
+	if !g.isCustomTemplate("repository_postgres.tmpl") || g.isCustomTemplate("batch.tmpl") {
+		files = append(files, supportFile{"batch.tmpl", "batch.go"})
+	}
+	if g.config.Hooks.OpenTelemetry {
+		files = append(files, supportFile{"hooks_otel.tmpl", "hooks_otel.go"})
+	}
+	if g.config.Hooks.Prometheus {
+		files = append(files, supportFile{"hooks_prometheus.tmpl", "hooks_prometheus.go"})
+	}
+	if g.config.IsOutboxEnabled() {
+		data.Outbox = g.config.Outbox.Table
+		data.OutboxRef = g.getTableRef(g.config.Outbox.Table)
+		files = append(files, supportFile{"outbox.tmpl", "outbox.go"})
+	}
+	if g.config.IsTenancyEnabled() {
+		data.Tenancy = g.config.Tenancy.Mode
+		data.TenantColumn = g.config.Tenancy.Column
+		files = append(files, supportFile{"tenancy.tmpl", "tenancy.go"})
+	}
+	if g.config.IsCacheEnabled() {
+		files = append(files, supportFile{"cache.tmpl", "cache.go"})
+	}
+
+	for _, file := range files {
+		tmpl, err := g.getTemplate(file.template)
+		if err != nil {
+			return err
+		}
+
+		filepath := filepath.Join(g.config.GetReposDir(), file.filename)
+		if err := g.writeTemplate(tmpl, filepath, data); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", file.filename, err)
+		}
+
+		slog.Debug("Generated repository support file", "filename", file.filename)
+	}
+
+	return nil
+}
+
+// generateNotify generates the migration creating the triggers that notify the changes
+// of the tables and the listeners subscribing to them
+func (g *Generator) generateNotify(schema *introspector.Schema) error {
+	slog.Info("Generating change notifications...")
+
+	tables := notifyTables(schema.Tables, g.config.Notify.Tables)
+
+	var units []MigrationUnit
+	for _, table := range tables {
+		up, down := notifyTriggerSQL(table)
+		units = append(units, MigrationUnit{Key: table.Name, UpSQL: up, DownSQL: down})
+	}
+
+	// The triggers of the tables no longer notified are dropped
+	mg := NewMigrationGenerator(g.config)
+	dropped, err := mg.droppedMigrationUnits(notifyMigrationName, units, notifyDropSQL)
+	if err != nil {
+		return fmt.Errorf("failed to read %s migrations: %w", notifyMigrationName, err)
+	}
+
+	description := fmt.Sprintf("Notify the changes of %d tables", len(tables))
+	if err := mg.WriteMigration(notifyMigrationName, description, append(units, dropped...), g.config.Migrations.Format); err != nil {
+		return fmt.Errorf("failed to write %s migration: %w", notifyMigrationName, err)
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	data := struct {
+		Package string
+		Tables  []repositoryTemplateData
+		Imports []string // Imports of the primary key types besides time and encoding/json
+	}{
+		Package: "postgres",
+	}
+
+	var keyTypes []string
+	for _, table := range tables {
+		data.Tables = append(data.Tables, g.newRepositoryTemplateData(table, "postgres"))
+		keyTypes = append(keyTypes, data.Tables[len(data.Tables)-1].PrimaryKeyType)
+	}
+	for _, path := range goTypeImports(keyTypes...) {
+		if path != "time" && path != "encoding/json" {
+			data.Imports = append(data.Imports, path)
+		}
+	}
+
+	tmpl, err := g.getTemplate("notify.tmpl")
+	if err != nil {
+		return err
+	}
+
+	return g.writeTemplate(tmpl, filepath.Join(g.config.GetReposDir(), "notify.go"), data)
+}
+
+// generateOutbox generates the migration creating the outbox table, the outbox and its
+// relay are repository support files
+func (g *Generator) generateOutbox() error {
+	slog.Info("Generating outbox migration...")
+
+	up, down := outboxTableSQL(g.config.Outbox.Table)
+	units := []MigrationUnit{{Key: g.config.Outbox.Table, UpSQL: up, DownSQL: down}}
+	description := fmt.Sprintf("Create the %s table", g.config.Outbox.Table)
+	if err := NewMigrationGenerator(g.config).WriteMigration(outboxMigrationName, description, units, g.config.Migrations.Format); err != nil {
+		return fmt.Errorf("failed to write %s migration: %w", outboxMigrationName, err)
+	}
+	return nil
+}
+
+// generateAudit generates the migration creating the history tables of the audited
+// tables with their triggers, and the repositories reading the recorded versions
+func (g *Generator) generateAudit(schema *introspector.Schema) error {
+	slog.Info("Generating audit history...")
+
+	tmpl, err := g.getTemplate("audit.tmpl")
+	if err != nil {
+		return err
+	}
+
+	var units []MigrationUnit
+	for _, table := range schema.Tables {
+		data := g.newRepositoryTemplateData(table, "postgres")
+		if data.Audit == nil {
+			continue
+		}
+
+		units = append(units, auditMigrationUnit(table, data.Audit))
+
+		filename := filepath.Join(g.config.GetReposDir(), data.Audit.HistoryTable+"_repository.go")
+		if err := g.writeTemplate(tmpl, filename, data); err != nil {
+			return fmt.Errorf("failed to generate %s history repository: %w", table.Name, err)
+		}
+	}
+	if len(units) == 0 {
+		return nil
+	}
+
+	if g.config.Audit.ChangedBySetting != "" {
+		tmpl, err := g.getTemplate("audit_changed_by.tmpl")
+		if err != nil {
+			return err
+		}
+		data := struct {
+			Package string
+			Setting string
+		}{
+			Package: "postgres",
+			Setting: g.config.Audit.ChangedBySetting,
+		}
+		if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetReposDir(), "audit.go"), data); err != nil {
+			return fmt.Errorf("failed to generate audit.go: %w", err)
+		}
+	}
+
+	description := fmt.Sprintf("Record the history of %d tables", len(units))
+	if err := NewMigrationGenerator(g.config).WriteMigration(auditMigrationName, description, units, g.config.Migrations.Format); err != nil {
+		return fmt.Errorf("failed to write %s migration: %w", auditMigrationName, err)
+	}
+	return nil
+}
+
 // generateMocks generates mock implementations
 func (g *Generator) generateMocks(schema *introspector.Schema) error {
 	slog.Info("Generating mocks...")
@@ -247,21 +729,7 @@ func (g *Generator) generateMocks(schema *introspector.Schema) error {
 	}
 
 	for _, table := range schema.Tables {
-		data := struct {
-			Table          introspector.Table
-			StructName     string
-			InterfaceName  string
-			MockName       string
-			Package        string
-			PrimaryKeyType string
-		}{
-			Table:          table,
-			StructName:     toPascalCase(table.Name),
-			InterfaceName:  toPascalCase(table.Name) + "Repository",
-			MockName:       "Mock" + toPascalCase(table.Name) + "Repository",
-			Package:        "mocks",
-			PrimaryKeyType: g.getPrimaryKeyType(table),
-		}
+		data := g.newRepositoryTemplateData(table, "mocks")
 
 		filename := fmt.Sprintf("mock_%s_repository.go", toSnakeCase(table.Name))
 		filepath := filepath.Join(g.config.GetMocksDir(), filename)
@@ -286,21 +754,7 @@ func (g *Generator) generateTests(schema *introspector.Schema) error {
 	}
 
 	for _, table := range schema.Tables {
-		data := struct {
-			Table          introspector.Table
-			StructName     string
-			InterfaceName  string
-			MockName       string
-			Package        string
-			PrimaryKeyType string
-		}{
-			Table:          table,
-			StructName:     toPascalCase(table.Name),
-			InterfaceName:  toPascalCase(table.Name) + "Repository",
-			MockName:       "Mock" + toPascalCase(table.Name) + "Repository",
-			Package:        "tests",
-			PrimaryKeyType: g.getPrimaryKeyType(table),
-		}
+		data := g.newRepositoryTemplateData(table, "tests")
 
 		filename := fmt.Sprintf("%s_repository_test.go", toSnakeCase(table.Name))
 		filepath := filepath.Join(g.config.GetTestsDir(), filename)
@@ -315,6 +769,190 @@ func (g *Generator) generateTests(schema *introspector.Schema) error {
 	return nil
 }
 
+// repositoryTemplateData holds the data shared by the repository, mock and test templates
+type repositoryTemplateData struct {
+	Table           introspector.Table
+	StructName      string
+	InterfaceName   string
+	ImplName        string
+	MockName        string
+	Package         string
+	PrimaryKeyType  string
+	PrimaryKeyCol   string
+	PrimaryKeyField string
+	Imports         []string // Extra imports required by the key and finder parameter types
+	Finders         []Finder
+	Constraints     []Constraint          // Unique and foreign key constraints mapped by the generated errors
+	InsertColumns   []introspector.Column // Columns written by bulk inserts
+	UpdateColumns   []introspector.Column // Columns written by Update and Patch
+	Filters         []FilterField
+	Keysets         []Keyset
+	SoftDelete      *SoftDelete    // Nil unless the table supports soft delete
+	Version         *VersionColumn // Nil unless updates and deletes are guarded by a version
+	Upserts         []Upsert
+	UpsertDoNothing bool    // Generate ON CONFLICT DO NOTHING variants of the upserts
+	Outbox          *Outbox // Nil unless the writes append events to the outbox
+	Audit           *Audit  // Nil unless the versions of the rows are recorded in a history table
+	Tenant          *Tenant // Nil unless the rows are scoped to the tenant of the queries
+	TenantSchema    bool    // Tables are qualified with the schema of the tenant of the queries
+	Tenancy         bool    // Queries are bound to the tenant of their context by withTenancy
+}
+
+// TableRef returns the table as referenced by the SQL
+func (d repositoryTemplateData) TableRef() string {
+	return tenantTable(d.Table.Name, d.TenantSchema)
+}
+
+// IsTenantColumn reports whether the named column holds the tenant of the rows
+func (d repositoryTemplateData) IsTenantColumn(name string) bool {
+	return d.Tenant != nil && d.Tenant.Column == name
+}
+
+// CreateColumns returns the columns always inserted by Create: the primary key, the
+// tenant and the columns written by the database are left out, and so are the columns
+// with a default, only inserted when set
+func (d repositoryTemplateData) CreateColumns() []introspector.Column {
+	var columns []introspector.Column
+	for _, col := range d.Table.Columns {
+		if !d.isCreateColumn(col) || col.DefaultValue != nil {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// CreateDefaults returns the columns with a default inserted by Create when their field is
+// set, the database writes the default of the others
+func (d repositoryTemplateData) CreateDefaults() []introspector.Column {
+	var columns []introspector.Column
+	for _, col := range d.Table.Columns {
+		if d.isCreateColumn(col) && col.DefaultValue != nil {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// InsertDefaults returns the columns with a default written by the bulk inserts
+func (d repositoryTemplateData) InsertDefaults() []introspector.Column {
+	var columns []introspector.Column
+	for _, col := range d.InsertColumns {
+		if col.DefaultValue != nil {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// isCreateColumn reports whether Create inserts a value of the model in a column
+func (d repositoryTemplateData) isCreateColumn(col introspector.Column) bool {
+	return !col.IsPrimaryKey && !col.IsGenerated && !d.IsTenantColumn(col.Name)
+}
+
+// CreateReturning returns the columns Create reads back from the inserted row: the primary
+// key, the tenant and the version, whose values are written or checked by the database,
+// and the columns with a default, which may have been written by the database
+func (d repositoryTemplateData) CreateReturning() []introspector.Column {
+	var columns []introspector.Column
+	for _, col := range d.Table.Columns {
+		if col.Name == d.PrimaryKeyCol || d.IsTenantColumn(col.Name) || (d.Version != nil && col.Name == d.Version.Column) ||
+			(d.isCreateColumn(col) && col.DefaultValue != nil) {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// TestKey returns a Go expression of the i-th primary key used by the generated tests, the
+// zero value of the key when its type has no obvious literal
+func (d repositoryTemplateData) TestKey(i int) string {
+	switch d.PrimaryKeyType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return fmt.Sprintf("%s(%d)", d.PrimaryKeyType, i)
+	case "string":
+		return fmt.Sprintf(`"%d"`, i)
+	case "uuid.UUID":
+		return fmt.Sprintf(`uuid.MustParse("00000000-0000-0000-0000-%012d")`, i)
+	default:
+		return fmt.Sprintf("*new(%s)", d.PrimaryKeyType)
+	}
+}
+
+// newRepositoryTemplateData builds the template data for a table in the given package
+func (g *Generator) newRepositoryTemplateData(table introspector.Table, pkg string) repositoryTemplateData {
+	table = withSystemVersion(table, g.getVersionColumnName(table))
+	structName := toPascalCase(table.Name)
+	primaryKeyType := g.getPrimaryKeyType(table)
+	finders := buildFinders(table)
+
+	var upsertConfig config.UpsertConfig
+	var sortKeys []string
+	if g.config != nil {
+		upsertConfig = g.config.Upsert
+		sortKeys = g.config.Keyset.SortKeys[table.Name]
+	}
+
+	// The version is bumped by the upsert rather than overwritten with the proposed value,
+	// and the soft delete column is only changed by Delete and Restore
+	version := g.getVersionColumn(table)
+	softDelete := g.getSoftDelete(table)
+	excludeColumns := slices.Clip(upsertConfig.ExcludeColumns)
+	if version != nil {
+		excludeColumns = append(excludeColumns, version.Column)
+	}
+	if softDelete != nil {
+		excludeColumns = append(excludeColumns, softDelete.Column)
+	}
+	upserts := buildUpserts(table, excludeColumns)
+	if version != nil {
+		for i := range upserts {
+			upserts[i].Version = version.Increment()
+		}
+	}
+
+	tenant := g.getTenant(table)
+	if tenant != nil {
+		finders = scopeFinders(finders, tenant)
+		upserts = scopeUpserts(upserts, tenant)
+	}
+
+	goTypes := []string{primaryKeyType}
+	for _, finder := range finders {
+		for _, col := range finder.Columns {
+			goTypes = append(goTypes, col.GoType)
+		}
+	}
+
+	return repositoryTemplateData{
+		Table:           table,
+		StructName:      structName,
+		InterfaceName:   structName + "Repository",
+		ImplName:        structName + "Repository",
+		MockName:        "Mock" + structName + "Repository",
+		Package:         pkg,
+		PrimaryKeyType:  primaryKeyType,
+		PrimaryKeyCol:   g.getPrimaryKeyColumn(table),
+		PrimaryKeyField: toPascalCase(g.getPrimaryKeyColumn(table)),
+		Imports:         goTypeImports(goTypes...),
+		Finders:         finders,
+		Constraints:     buildConstraints(table),
+		InsertColumns:   g.getInsertColumns(table),
+		UpdateColumns:   g.getUpdateColumns(table),
+		Filters:         buildFilterFields(table),
+		Keysets:         buildKeysets(table, sortKeys),
+		SoftDelete:      softDelete,
+		Version:         version,
+		Upserts:         upserts,
+		UpsertDoNothing: upsertConfig.DoNothing,
+		Outbox:          g.getOutbox(table),
+		Audit:           g.getAudit(table, primaryKeyType),
+		Tenant:          tenant,
+		TenantSchema:    g.config != nil && g.config.IsSchemaTenancy(),
+		Tenancy:         g.config != nil && g.config.IsTenancyEnabled(),
+	}
+}
+
 // writeTemplate writes a template to a file
 func (g *Generator) writeTemplate(tmpl *template.Template, filepath string, data interface{}) error {
 	file, err := os.Create(filepath)
@@ -346,6 +984,103 @@ func (g *Generator) getPrimaryKeyColumn(table introspector.Table) string {
 	return "id"
 }
 
+// getInsertColumns returns the columns whose values are supplied by the application
+// on insert, skipping identity and generated columns and primary keys with a default
+func (g *Generator) getInsertColumns(table introspector.Table) []introspector.Column {
+	var columns []introspector.Column
+	for _, col := range table.Columns {
+		if col.IsGenerated || (col.IsPrimaryKey && col.DefaultValue != nil) || g.isTenantColumn(table, col) {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// getSoftDelete returns the soft delete column of a table when soft delete is enabled
+func (g *Generator) getSoftDelete(table introspector.Table) *SoftDelete {
+	if g.config == nil || !g.config.IsSoftDeleteEnabled() {
+		return nil
+	}
+	return detectSoftDelete(table, g.config.SoftDelete.Columns)
+}
+
+// getOutbox returns the outbox events of a table when it is an outbox aggregate
+func (g *Generator) getOutbox(table introspector.Table) *Outbox {
+	if g.config == nil || !g.config.IsOutboxAggregate(table.Name) {
+		return nil
+	}
+	return newOutbox(table, g.getTableRef(g.config.Outbox.Table))
+}
+
+// getAudit returns the history of a table when its changes are audited
+func (g *Generator) getAudit(table introspector.Table, primaryKeyType string) *Audit {
+	if g.config == nil || !g.config.IsAudited(table.Name) {
+		return nil
+	}
+	audit := newAudit(table, primaryKeyType, g.config.Audit.ChangedBySetting)
+	if audit != nil {
+		audit.HistoryRef = g.getTableRef(audit.HistoryTable)
+	}
+	return audit
+}
+
+// getTenant returns the tenant column of a table when its rows are scoped to tenants
+func (g *Generator) getTenant(table introspector.Table) *Tenant {
+	if g.config == nil || !g.config.IsTenancyEnabled() || g.config.IsSchemaTenancy() {
+		return nil
+	}
+	return newTenant(table, g.config.Tenancy.Column)
+}
+
+// isTenantColumn reports whether a column holds the tenant of the rows, it is written
+// with the tenant of the queries rather than a value of the model
+func (g *Generator) isTenantColumn(table introspector.Table, col introspector.Column) bool {
+	tenant := g.getTenant(table)
+	return tenant != nil && tenant.Column == col.Name
+}
+
+// getTableRef returns a table as referenced by the generated SQL
+func (g *Generator) getTableRef(name string) string {
+	return tenantTable(name, g.config != nil && g.config.IsSchemaTenancy())
+}
+
+// getVersionColumnName returns the version column configured for a table, or an empty
+// string when optimistic locking is disabled
+func (g *Generator) getVersionColumnName(table introspector.Table) string {
+	if g.config == nil || !g.config.IsOptimisticLockingEnabled() {
+		return ""
+	}
+	return g.config.GetVersionColumn(table.Name)
+}
+
+// getVersionColumn returns the version column of a table when optimistic locking is enabled
+func (g *Generator) getVersionColumn(table introspector.Table) *VersionColumn {
+	return detectVersionColumn(table, g.getVersionColumnName(table))
+}
+
+// getUpdateColumns returns the columns an update may change: every column except the
+// primary key, those generated by the database, the version, which is only bumped, and
+// the soft delete column, which only Delete and Restore change
+func (g *Generator) getUpdateColumns(table introspector.Table) []introspector.Column {
+	var version, softDelete string
+	if v := g.getVersionColumn(table); v != nil {
+		version = v.Column
+	}
+	if s := g.getSoftDelete(table); s != nil {
+		softDelete = s.Column
+	}
+
+	var columns []introspector.Column
+	for _, col := range table.Columns {
+		if col.IsPrimaryKey || col.IsGenerated || col.Name == version || col.Name == softDelete || g.isTenantColumn(table, col) {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
 // Helper functions for naming conventions
 
 // toPascalCase converts snake_case to PascalCase
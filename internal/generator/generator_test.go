@@ -1,10 +1,14 @@
 package generator
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/fsvxavier/pgx-goose/internal/config"
 	"github.com/fsvxavier/pgx-goose/internal/introspector"
 )
 
@@ -99,3 +103,121 @@ func TestGetPrimaryKeyColumn(t *testing.T) {
 	result = g.getPrimaryKeyColumn(tableNoPK)
 	assert.Equal(t, "id", result)
 }
+
+func TestGetInsertColumns(t *testing.T) {
+	g := &Generator{}
+	defaultValue := "nextval('users_id_seq'::regclass)"
+
+	table := introspector.Table{
+		Name: "users",
+		Columns: []introspector.Column{
+			{Name: "id", GoType: "int64", IsPrimaryKey: true, DefaultValue: &defaultValue},
+			{Name: "email", GoType: "string"},
+			{Name: "search", GoType: "*string", IsGenerated: true},
+			{Name: "created_at", GoType: "time.Time", DefaultValue: &defaultValue},
+		},
+	}
+
+	var names []string
+	for _, col := range g.getInsertColumns(table) {
+		names = append(names, col.Name)
+	}
+	assert.Equal(t, []string{"email", "created_at"}, names)
+
+	// Primary keys without a default are supplied by the application
+	table.Columns[0].DefaultValue = nil
+	assert.Len(t, g.getInsertColumns(table), 3)
+}
+
+func TestRepositoryTemplateData_CreateColumns(t *testing.T) {
+	cfg := &config.Config{OptimisticLocking: config.OptimisticLockingConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+	data := New(cfg).newRepositoryTemplateData(testTable("accounts"), "postgres")
+
+	names := func(columns []introspector.Column) []string {
+		var names []string
+		for _, col := range columns {
+			names = append(names, col.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"email", "nickname", "balance", "revision", "version", "deleted_at"}, names(data.CreateColumns()))
+	assert.Equal(t, []string{"plan"}, names(data.CreateDefaults()), "columns with a default are only inserted when set")
+	assert.Equal(t, []string{"id", "plan", "version"}, names(data.CreateReturning()), "the values written by the database are read back")
+}
+
+func TestGenerator_GenerateRepositorySupport(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &config.Config{OutputDir: outputDir}
+	g := New(cfg)
+
+	schema := testTables("posts")
+
+	require.NoError(t, g.createDirectories())
+	require.NoError(t, g.generateRepositorySupport(schema))
+
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "db.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "query.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "stream.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "batch.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "cursor.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "context.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "errors.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "options.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "hooks.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "replicas.go"))
+	assert.NoFileExists(t, filepath.Join(cfg.GetReposDir(), "hooks_otel.go"))
+	assert.NoFileExists(t, filepath.Join(cfg.GetReposDir(), "hooks_prometheus.go"))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "tx_manager.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, []string{"db, opts"}, src.Calls("NewRepositories", "NewPostsRepository"), "the repositories share the options of the manager")
+
+	cfg.Hooks = config.HooksConfig{OpenTelemetry: true, Prometheus: true}
+	require.NoError(t, g.generateRepositorySupport(schema))
+
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "hooks_otel.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "hooks_prometheus.go"))
+}
+
+func TestGenerator_GenerateModels_Patch(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &config.Config{OutputDir: outputDir}
+	g := New(cfg)
+
+	schema := testTables("orders")
+	schema.Tables = append(schema.Tables, introspector.Table{
+		Name: "tags",
+		Columns: []introspector.Column{
+			{Name: "name", GoType: "string", IsPrimaryKey: true},
+		},
+	})
+
+	require.NoError(t, g.createDirectories())
+	require.NoError(t, g.generateModels(schema))
+	require.NoError(t, g.generateModelSupport())
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetModelsDir(), "orders_patch.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, []string{"encoding/json", "github.com/shopspring/decimal", "time"}, src.Imports(), "the key type is not imported")
+	assert.Equal(t, []string{
+		"Status Optional[interface{}]",
+		"Refund Optional[interface{}]",
+		"Items Optional[int]",
+		"Total Optional[decimal.Decimal]",
+		"Note Optional[*string]",
+		"PlacedAt Optional[time.Time]",
+		"PaidAt Optional[*time.Time]",
+		"DueOn Optional[time.Time]",
+		"Receipt Optional[[]byte]",
+		"ShipTo Optional[json.RawMessage]",
+	}, src.Fields("OrdersPatch"), "neither the key nor the generated number are patched")
+
+	assert.NoFileExists(t, filepath.Join(cfg.GetModelsDir(), "tags_patch.go"), "tables without updatable columns have no patch")
+	assert.FileExists(t, filepath.Join(cfg.GetModelsDir(), "optional.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetModelsDir(), "filter.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetModelsDir(), "page.go"))
+	assert.FileExists(t, filepath.Join(cfg.GetModelsDir(), "orders_filter.go"))
+}
@@ -0,0 +1,372 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// graphQLScalars maps the Go types of the models to their GraphQL types. Int64, Time
+// and UUID are built into gqlgen, the other custom scalars need marshalers.
+var graphQLScalars = map[string]string{
+	"int":             "Int",
+	"int16":           "Int",
+	"int32":           "Int",
+	"int64":           "Int64",
+	"uint32":          "Int64",
+	"float32":         "Float",
+	"float64":         "Float",
+	"bool":            "Boolean",
+	"string":          "String",
+	"time.Time":       "Time",
+	"uuid.UUID":       "UUID",
+	"decimal.Decimal": "Decimal",
+	"json.RawMessage": "JSON",
+	"[]byte":          "Bytes",
+	"interface{}":     "JSON",
+}
+
+// GraphQLField is a field of a GraphQL object or input type mapped to a column
+type GraphQLField struct {
+	Name        string // GraphQL name, e.g. "createdAt"
+	Type        string // GraphQL type, e.g. "Time!"
+	Description string // Description as a GraphQL string, empty without column comment
+	Field       string // Model field name
+	GoType      string // Go type of the input field
+	Required    bool   // The input field is non-null
+	Pointer     bool   // The model field is a pointer
+}
+
+// InputType returns the Go type of the input field, a pointer unless required
+func (f GraphQLField) InputType() string {
+	if f.Required || f.GoType == "interface{}" {
+		return f.GoType
+	}
+	return "*" + f.GoType
+}
+
+// GraphQLRelation is a field resolved through a foreign key: the referenced row of a
+// child, or the rows referencing a parent
+type GraphQLRelation struct {
+	Name      string // GraphQL field name, e.g. "user" or "postsByUserId"
+	Method    string // Resolver method name, e.g. "User" or "PostsByUserId"
+	Table     string // Struct name of the related table
+	Column    string // Foreign key column
+	Field     string // Model field of the foreign key column
+	Nullable  bool   // The foreign key column is nullable
+	KeyType   string // Go type of the referenced primary key
+	Filter    string // Filter of the foreign key column of the children, e.g. "Filter[int]"
+	KeyField  string // Model field of the referenced primary key
+	Reference string // Name of the referenced table
+}
+
+// FilterValue returns the filter of the children matching the given key expression
+func (r GraphQLRelation) FilterValue(key string) string {
+	switch r.Filter {
+	case "StringFilter":
+		return "&models.StringFilter{Filter: models.Filter[string]{Eq: " + key + "}}"
+	case "TimeFilter":
+		return "&models.TimeFilter{Filter: models.Filter[time.Time]{Eq: " + key + "}}"
+	default:
+		return "&models." + r.Filter + "{Eq: " + key + "}"
+	}
+}
+
+// graphQLTable holds the data of the GraphQL types and resolvers of a table
+type graphQLTable struct {
+	repositoryTemplateData
+	Query        string         // Name of the root query fields, e.g. "users"
+	Description  string         // Description as a GraphQL string
+	Fields       []GraphQLField // Fields of the object type, one per column
+	CreateFields []GraphQLField // Fields of the create input
+	UpdateFields []GraphQLField // Fields of the update input
+	IDType       string         // GraphQL type of the id arguments
+	HasKey       bool           // The table has a primary key, for the id lookups and the mutations
+	Parents      []GraphQLRelation
+	Children     []GraphQLRelation
+	Loader       bool // Rows are loaded by ID in batches, the table being referenced
+	columns      map[string]introspector.Column
+}
+
+// VersionType returns the GraphQL type of the version arguments, empty without version
+func (t graphQLTable) VersionType() string {
+	if t.Version == nil {
+		return ""
+	}
+	return graphQLScalars[t.Version.GoType] + "!"
+}
+
+// FinderName returns the name of the root query field of a finder, e.g. "usersByEmail"
+func (t graphQLTable) FinderName(finder Finder) string {
+	return t.Query + "By" + finder.Name
+}
+
+// FinderArgs returns the GraphQL arguments of a finder, e.g. "userId: Int!, slug: String!"
+func (t graphQLTable) FinderArgs(finder Finder) string {
+	args := make([]string, len(finder.Columns))
+	for i, col := range finder.Columns {
+		args[i] = lowerFirst(col.Field) + ": " + graphQLScalars[col.GoType] + "!"
+	}
+	return strings.Join(args, ", ")
+}
+
+// ResolverImports returns the imports of the types of the inputs and arguments of the resolvers
+func (t graphQLTable) ResolverImports() []string {
+	goTypes := []string{t.PrimaryKeyType}
+	for _, field := range append(slices.Clip(t.CreateFields), t.UpdateFields...) {
+		goTypes = append(goTypes, field.GoType)
+	}
+	for _, finder := range t.Finders {
+		for _, col := range finder.Columns {
+			goTypes = append(goTypes, col.GoType)
+		}
+	}
+	for _, rel := range t.Children {
+		if rel.Filter == "TimeFilter" {
+			goTypes = append(goTypes, "time.Time")
+		}
+	}
+	return goTypeImports(goTypes...)
+}
+
+// graphQLTemplateData holds the data of the GraphQL schema and resolvers
+type graphQLTemplateData struct {
+	Package string
+	Scalars []string // Custom scalars used by the schema
+	Tables  []graphQLTable
+}
+
+// Loaders returns the tables loaded by ID in batches
+func (d graphQLTemplateData) Loaders() []graphQLTable {
+	var tables []graphQLTable
+	for _, table := range d.Tables {
+		if table.Loader {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// LoaderImports returns the imports of the keys of the loaders
+func (d graphQLTemplateData) LoaderImports() []string {
+	var goTypes []string
+	for _, table := range d.Loaders() {
+		goTypes = append(goTypes, table.PrimaryKeyType)
+	}
+	return goTypeImports(goTypes...)
+}
+
+// newGraphQLTable builds the GraphQL data of a table, without its relations
+func (g *Generator) newGraphQLTable(table introspector.Table) graphQLTable {
+	handlers := g.newHandlerTemplateData(table)
+	data := graphQLTable{
+		repositoryTemplateData: handlers.repositoryTemplateData,
+		Query:                  lowerFirst(toPascalCase(table.Name)),
+		Description:            jsonString(tableDescription(table)),
+		HasKey:                 len(primaryKeyColumns(table)) > 0,
+		columns:                make(map[string]introspector.Column),
+	}
+	data.Package = "graphql"
+	data.IDType = graphQLScalars[data.PrimaryKeyType] + "!"
+
+	for _, col := range data.Table.Columns {
+		data.columns[col.Name] = col
+		field := newGraphQLField(col)
+		if !col.IsNullable {
+			field.Type += "!"
+		}
+		data.Fields = append(data.Fields, field)
+	}
+	for _, hf := range handlers.CreateFields {
+		data.CreateFields = append(data.CreateFields, newGraphQLInputField(data.columns[hf.Column], hf.Required))
+	}
+	for _, hf := range handlers.UpdateFields {
+		data.UpdateFields = append(data.UpdateFields, newGraphQLInputField(data.columns[hf.Column], hf.Required))
+	}
+	return data
+}
+
+// newGraphQLField returns the GraphQL field of a column, nullable
+func newGraphQLField(col introspector.Column) GraphQLField {
+	goType := strings.TrimPrefix(col.GoType, "*")
+	scalar := graphQLScalars[goType]
+	if len(col.EnumValues) > 0 {
+		// Enum labels are scanned as strings
+		scalar = "String"
+	}
+	if scalar == "" {
+		scalar = "JSON"
+	}
+
+	field := GraphQLField{
+		Name:    lowerFirst(toPascalCase(col.Name)),
+		Type:    scalar,
+		Field:   toPascalCase(col.Name),
+		GoType:  goType,
+		Pointer: strings.HasPrefix(col.GoType, "*"),
+	}
+	if col.Comment != "" {
+		field.Description = jsonString(col.Comment)
+	}
+	return field
+}
+
+// newGraphQLInputField returns the input field of a column, non-null when required
+func newGraphQLInputField(col introspector.Column, required bool) GraphQLField {
+	field := newGraphQLField(col)
+	field.Required = required
+	if required {
+		field.Type += "!"
+	}
+	return field
+}
+
+// graphQLRelations adds the relations of the foreign keys between the tables. A foreign
+// key is mapped when it references the primary key of a generated table with the same
+// Go type, the referenced rows being loaded in batches by GetByIDs.
+func graphQLRelations(tables []graphQLTable) {
+	index := make(map[string]int, len(tables))
+	for i, table := range tables {
+		index[table.Table.Name] = i
+	}
+
+	for i := range tables {
+		child := &tables[i]
+		for _, fk := range child.Table.ForeignKeys {
+			j, ok := index[fk.ReferencedTable]
+			if !ok {
+				continue
+			}
+			parent := &tables[j]
+			col, ok := child.columns[fk.Column]
+			goType := strings.TrimPrefix(col.GoType, "*")
+			if !ok || !parent.HasKey || parent.PrimaryKeyCol != fk.ReferencedColumn || parent.PrimaryKeyType != goType || !isLoaderKeyType(goType) {
+				slog.Debug("Foreign key not mapped to a GraphQL relation", "table", child.Table.Name, "foreign_key", fk.Name)
+				continue
+			}
+
+			method := toPascalCase(strings.TrimSuffix(fk.Column, "_id"))
+			if method == toPascalCase(fk.Column) || child.hasField(lowerFirst(method)) {
+				method += parent.StructName
+			}
+			rel := GraphQLRelation{
+				Name:      lowerFirst(method),
+				Method:    method,
+				Table:     parent.StructName,
+				Column:    fk.Column,
+				Field:     toPascalCase(fk.Column),
+				Nullable:  col.IsNullable,
+				KeyType:   parent.PrimaryKeyType,
+				KeyField:  parent.PrimaryKeyField,
+				Reference: parent.Table.Name,
+			}
+			child.Parents = append(child.Parents, rel)
+			parent.Loader = true
+
+			for _, filter := range child.Filters {
+				if filter.Column == fk.Column {
+					rel.Name = child.Query + "By" + rel.Field
+					rel.Method = child.StructName + "By" + rel.Field
+					rel.Table = child.StructName
+					rel.Filter = filter.Type
+					parent.Children = append(parent.Children, rel)
+				}
+			}
+		}
+	}
+}
+
+// hasField reports whether the object type of the table has a field of that name
+func (t graphQLTable) hasField(name string) bool {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	for _, rel := range t.Parents {
+		if rel.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoaderKeyType reports whether values of a Go type can key the loader caches
+func isLoaderKeyType(goType string) bool {
+	switch goType {
+	case "int", "int16", "int32", "int64", "string", "uuid.UUID":
+		return true
+	default:
+		return false
+	}
+}
+
+// lowerFirst lowercases the first letter of a name, e.g. "UserId" becomes "userId"
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+// generateGraphQL generates the GraphQL schema of the tables with their relations, and
+// the resolvers calling the repositories
+func (g *Generator) generateGraphQL(schema *introspector.Schema) error {
+	slog.Info("Generating GraphQL schema and resolvers...")
+
+	data := graphQLTemplateData{Package: "graphql"}
+	for _, table := range schema.Tables {
+		data.Tables = append(data.Tables, g.newGraphQLTable(table))
+	}
+	graphQLRelations(data.Tables)
+
+	used := make(map[string]bool)
+	for _, table := range data.Tables {
+		for _, field := range table.Fields {
+			used[strings.TrimSuffix(field.Type, "!")] = true
+		}
+		for _, finder := range table.Finders {
+			for _, col := range finder.Columns {
+				used[graphQLScalars[col.GoType]] = true
+			}
+		}
+		used[strings.TrimSuffix(table.IDType, "!")] = true
+	}
+	for _, scalar := range []string{"Int64", "Time", "UUID", "Decimal", "JSON", "Bytes"} {
+		if used[scalar] {
+			data.Scalars = append(data.Scalars, scalar)
+		}
+	}
+
+	dir := g.config.GetGraphQLDir()
+	for _, file := range []struct{ template, name string }{
+		{"graphql_schema.tmpl", "schema.graphql"},
+		{"graphql_resolver.tmpl", "resolver.go"},
+		{"graphql_loader.tmpl", "loader.go"},
+	} {
+		tmpl, err := g.getTemplate(file.template)
+		if err != nil {
+			return err
+		}
+		if err := g.writeTemplate(tmpl, filepath.Join(dir, file.name), data); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", file.name, err)
+		}
+	}
+
+	tmpl, err := g.getTemplate("graphql_table_resolver.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, table := range data.Tables {
+		filename := toSnakeCase(table.Table.Name) + "_resolver.go"
+		if err := g.writeTemplate(tmpl, filepath.Join(dir, filename), table); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", filename, err)
+		}
+	}
+	return nil
+}
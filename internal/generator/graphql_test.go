@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewGraphQLField(t *testing.T) {
+	tests := []struct {
+		name      string
+		col       introspector.Column
+		wantType  string
+		wantInput string
+	}{
+		{name: "int", col: introspector.Column{Name: "page_count", GoType: "int32"}, wantType: "Int", wantInput: "*int32"},
+		{name: "nullable time", col: introspector.Column{Name: "published_at", GoType: "*time.Time", IsNullable: true}, wantType: "Time", wantInput: "*time.Time"},
+		{name: "enum", col: introspector.Column{Name: "genre", GoType: "interface{}", EnumValues: []string{"novel"}}, wantType: "String", wantInput: "interface{}"},
+		{name: "tsvector", col: introspector.Column{Name: "search", GoType: "interface{}"}, wantType: "JSON", wantInput: "interface{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := newGraphQLField(tt.col)
+			assert.Equal(t, tt.wantType, field.Type)
+			assert.Equal(t, tt.wantInput, field.InputType())
+		})
+	}
+
+	field := newGraphQLInputField(introspector.Column{Name: "page_count", GoType: "int32", Comment: `Pages, "roughly"`}, true)
+	assert.Equal(t, "pageCount", field.Name)
+	assert.Equal(t, "Int!", field.Type)
+	assert.Equal(t, "int32", field.InputType())
+	assert.Equal(t, `"Pages, \"roughly\""`, field.Description)
+}
+
+func TestNewGraphQLTable_CreateFields(t *testing.T) {
+	cfg := &config.Config{
+		SoftDelete:        config.SoftDeleteConfig{Enabled: true},
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	table := New(cfg).newGraphQLTable(testTable("accounts"))
+
+	var create []string
+	for _, field := range table.CreateFields {
+		create = append(create, field.Name+": "+field.Type)
+	}
+	assert.Equal(t, []string{"email: String!", "nickname: String", "balance: Int64!", "revision: Int", "plan: String"}, create,
+		"the version and deleted_at are maintained by the repository, plan is left to its default when unset")
+	assert.Equal(t, "*string", table.CreateFields[4].InputType())
+}
+
+func TestGraphQLRelations(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	var tables []graphQLTable
+	for _, table := range testTables("authors", "books").Tables {
+		tables = append(tables, g.newGraphQLTable(table))
+	}
+	graphQLRelations(tables)
+	authors, books := tables[0], tables[1]
+
+	require.Len(t, books.Parents, 2, "foreign keys of another type or to other tables are not mapped")
+	assert.Equal(t, "authorAuthors", books.Parents[0].Name, "the relation does not shadow the author column")
+	assert.Equal(t, "AuthorAuthors", books.Parents[0].Method)
+	assert.False(t, books.Parents[0].Nullable)
+	assert.Equal(t, "editor", books.Parents[1].Name)
+	assert.Equal(t, "Editor", books.Parents[1].Method)
+	assert.True(t, books.Parents[1].Nullable)
+
+	assert.True(t, authors.Loader)
+	assert.False(t, books.Loader)
+	require.Len(t, authors.Children, 2)
+	assert.Equal(t, "booksByAuthorId", authors.Children[0].Name)
+	assert.Equal(t, "BooksByAuthorId", authors.Children[0].Method)
+	assert.Equal(t, "&models.Filter[int64]{Eq: &obj.Id}", authors.Children[0].FilterValue("&obj.Id"))
+}
+
+func TestLowerFirst(t *testing.T) {
+	assert.Equal(t, "userId", lowerFirst("UserId"))
+	assert.Equal(t, "éclair", lowerFirst("Éclair"))
+	assert.Equal(t, "", lowerFirst(""))
+}
+
+func TestGenerator_GenerateGraphQL(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{GraphQL: filepath.Join(dir, "graphql")},
+	}
+	cfg.ApplyDefaults()
+
+	require.NoError(t, New(cfg).Generate(testTables("authors", "books")))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "schema.graphql"))
+	require.NoError(t, err)
+	blocks := schemaBlocks(string(content))
+	assert.Subset(t, blocks[""], []string{"scalar Int64", "scalar Time", "scalar UUID", `"Published books"`})
+	assert.NotContains(t, blocks[""], "scalar Decimal", "only the scalars of the columns are declared")
+	assert.Equal(t, []string{
+		"id: Int64!",
+		`"Display name"`,
+		"name: String!",
+		`"The Books referencing this row by author_id"`,
+		"booksByAuthorId(limit: Int = 50, offset: Int = 0): [Books!]!",
+		`"The Books referencing this row by editor_id"`,
+		"booksByEditorId(limit: Int = 50, offset: Int = 0): [Books!]!",
+	}, blocks["type Authors"])
+	assert.Equal(t, []string{
+		"id: UUID!",
+		"authorId: Int64!",
+		"editorId: Int64",
+		"author: String!",
+		"legacyAuthorId: Int!",
+		"genre: String!",
+		"publishedAt: Time",
+		`"The authors row referenced by author_id"`,
+		"authorAuthors: Authors!",
+		`"The authors row referenced by editor_id"`,
+		"editor: Authors",
+	}, blocks["type Books"])
+	assert.Equal(t, blocks["input UpdateBooksInput"], blocks["input CreateBooksInput"], "the id is generated by the database")
+	assert.Contains(t, blocks["type Query"], "booksConnection(first: Int = 50, after: String): BooksConnection!")
+	assert.Contains(t, blocks["type Mutation"], "deleteBooks(id: UUID!): Boolean!")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "loader.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, []string{"AuthorsByID *Loader[int64, *models.Authors]"}, src.Fields("Loaders"), "only the referenced tables have a loader")
+	assert.Equal(t, []string{"ctx, ids"}, src.Calls("NewLoaders", "GetByIDs"))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "books_resolver.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, "graphql", src.file.Name.Name)
+	assert.Contains(t, src.Imports(), "github.com/google/uuid")
+	assert.Equal(t, "func(ctx context.Context, obj *models.Books) (*models.Authors, error)", src.Signature("BooksResolver.Editor"))
+	assert.Equal(t, "func(ctx context.Context, obj *models.Books) (*models.Authors, error)", src.Signature("BooksResolver.AuthorAuthors"))
+	assert.Equal(t, "func(ctx context.Context, id uuid.UUID) (bool, error)", src.Signature("MutationResolver.DeleteBooks"))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "resolver.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, "func() *BooksResolver", src.Signature("Resolver.Books"))
+}
+
+func TestIncrementalGenerator_GraphQL(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{GraphQL: filepath.Join(dir, "graphql")},
+	}
+	cfg.ApplyDefaults()
+
+	schema := testTables("authors", "books")
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	// Only books changes, the schema and resolver still hold both tables
+	schema.Tables[1].Columns = append(schema.Tables[1].Columns, introspector.Column{Name: "isbn", Type: "text", GoType: "*string", IsNullable: true})
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "schema.graphql"))
+	require.NoError(t, err)
+	blocks := schemaBlocks(string(content))
+	assert.Contains(t, blocks["type Books"], "isbn: String")
+	assert.Contains(t, blocks["type Authors"], "booksByAuthorId(limit: Int = 50, offset: Int = 0): [Books!]!")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetGraphQLDir(), "resolver.go"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"AuthorsRepository interfaces.AuthorsRepository",
+		"BooksRepository interfaces.BooksRepository",
+	}, parseGoSource(t, string(content)).Fields("Resolver"))
+}
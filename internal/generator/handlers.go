@@ -0,0 +1,193 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// HandlerField describes a column set by the request bodies of the generated handlers
+type HandlerField struct {
+	Column   string // Column name, also the JSON name of the field
+	Field    string // Model field name
+	GoType   string // Go type of the value (never a pointer)
+	Pointer  bool   // The model field is a pointer
+	Required bool   // Requests must set the field
+}
+
+// handlerTemplateData holds the data of the handler templates of a table
+type handlerTemplateData struct {
+	repositoryTemplateData
+	Path         string         // Path of the resource, e.g. "/users"
+	CreateFields []HandlerField // Fields of the create request
+	UpdateFields []HandlerField // Fields of the update request
+	TestID       string         // Go expression of the ID used by the tests, empty when the type has none
+	TestIDPath   string         // TestID as written in paths
+	MockProvider string
+}
+
+// FinderPath returns the path serving a finder, e.g. "/posts/by/user_id/{user_id}/slug/{slug}"
+func (d handlerTemplateData) FinderPath(finder Finder) string {
+	var path strings.Builder
+	path.WriteString(d.Path + "/by")
+	for _, col := range finder.Columns {
+		path.WriteString("/" + col.Name + "/{" + col.Name + "}")
+	}
+	return path.String()
+}
+
+// FirstRequired returns the first required field of the create request, nil when none is
+func (d handlerTemplateData) FirstRequired() *HandlerField {
+	for _, field := range d.CreateFields {
+		if field.Required {
+			return &field
+		}
+	}
+	return nil
+}
+
+// Expect returns the start of the expectation of a call to a method of the mock repository
+// of the tests, up to its context argument
+func (d handlerTemplateData) Expect(method string) string {
+	if d.MockProvider == "mock" {
+		return "repo.EXPECT()." + method + "(" + d.Any()
+	}
+	return `repo.On("` + method + `", ` + d.Any()
+}
+
+// Any returns the argument matcher of the mock repository of the tests matching any value
+func (d handlerTemplateData) Any() string {
+	if d.MockProvider == "mock" {
+		return "gomock.Any()"
+	}
+	return "mocklib.Anything"
+}
+
+// TestImports returns the imports of the handler tests
+func (d handlerTemplateData) TestImports() []string {
+	if d.TestID == "" {
+		return nil
+	}
+	return goTypeImports(d.PrimaryKeyType)
+}
+
+// ResponseImports returns the imports of the response fields, one per column
+func (d handlerTemplateData) ResponseImports() []string {
+	goTypes := make([]string, len(d.Table.Columns))
+	for i, col := range d.Table.Columns {
+		goTypes[i] = col.GoType
+	}
+	return goTypeImports(goTypes...)
+}
+
+// newHandlerTemplateData builds the template data of the handlers of a table
+func (g *Generator) newHandlerTemplateData(table introspector.Table) handlerTemplateData {
+	data := handlerTemplateData{
+		repositoryTemplateData: g.newRepositoryTemplateData(table, "handlers"),
+		Path:                   "/" + table.Name,
+		MockProvider:           g.config.MockProvider,
+	}
+	data.TestID, data.TestIDPath = testIDValue(data.PrimaryKeyType)
+
+	// The soft delete and version columns are maintained by the repository
+	written := func(col introspector.Column) bool {
+		if data.SoftDelete != nil && col.Name == data.SoftDelete.Column {
+			return false
+		}
+		return data.Version == nil || col.Name != data.Version.Column
+	}
+	// Create leaves the columns with a default to the database while their field is unset,
+	// so they are optional like the nullable ones
+	for _, col := range append(data.CreateColumns(), data.CreateDefaults()...) {
+		if written(col) {
+			field := newHandlerField(col)
+			field.Required = !field.Pointer && col.DefaultValue == nil
+			data.CreateFields = append(data.CreateFields, field)
+		}
+	}
+	for _, col := range data.UpdateColumns {
+		if written(col) {
+			field := newHandlerField(col)
+			field.Required = !field.Pointer
+			data.UpdateFields = append(data.UpdateFields, field)
+		}
+	}
+	return data
+}
+
+// newHandlerField returns the request field of a column
+func newHandlerField(col introspector.Column) HandlerField {
+	return HandlerField{
+		Column:  col.Name,
+		Field:   toPascalCase(col.Name),
+		GoType:  strings.TrimPrefix(col.GoType, "*"),
+		Pointer: strings.HasPrefix(col.GoType, "*"),
+	}
+}
+
+// testIDValue returns a Go expression of an ID of the given type and its path
+// segment, or empty strings when the type has no obvious literal
+func testIDValue(goType string) (string, string) {
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return goType + "(1)", "1"
+	case "string":
+		return `"1"`, "1"
+	case "uuid.UUID":
+		id := "00000000-0000-0000-0000-000000000001"
+		return `uuid.MustParse("` + id + `")`, id
+	default:
+		return "", ""
+	}
+}
+
+// generateHandlers generates the net/http handlers serving the repositories of the
+// tables, and their tests when tests are generated
+func (g *Generator) generateHandlers(schema *introspector.Schema) error {
+	slog.Info("Generating HTTP handlers...")
+
+	support, err := g.getTemplate("handlers.tmpl")
+	if err != nil {
+		return err
+	}
+	supportData := struct {
+		Package string
+		Tenancy bool
+	}{
+		Package: "handlers",
+		Tenancy: g.config.IsTenancyEnabled(),
+	}
+	if err := g.writeTemplate(support, filepath.Join(g.config.GetHandlersDir(), "handlers.go"), supportData); err != nil {
+		return fmt.Errorf("failed to generate handlers.go: %w", err)
+	}
+
+	tmpl, err := g.getTemplate("handler.tmpl")
+	if err != nil {
+		return err
+	}
+	testTmpl, err := g.getTemplate("handler_test.tmpl")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range schema.Tables {
+		data := g.newHandlerTemplateData(table)
+		name := toSnakeCase(table.Name)
+
+		filename := filepath.Join(g.config.GetHandlersDir(), name+"_handler.go")
+		if err := g.writeTemplate(tmpl, filename, data); err != nil {
+			return fmt.Errorf("failed to generate %s handler: %w", table.Name, err)
+		}
+
+		if g.config.WithTests {
+			filename := filepath.Join(g.config.GetHandlersDir(), name+"_handler_test.go")
+			if err := g.writeTemplate(testTmpl, filename, data); err != nil {
+				return fmt.Errorf("failed to generate %s handler test: %w", table.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewHandlerTemplateData(t *testing.T) {
+	cfg := &config.Config{
+		MockProvider:      "mock",
+		SoftDelete:        config.SoftDeleteConfig{Enabled: true},
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	data := New(cfg).newHandlerTemplateData(testTable("accounts"))
+
+	assert.Equal(t, "/accounts", data.Path)
+	assert.Equal(t, `uuid.MustParse("00000000-0000-0000-0000-000000000001")`, data.TestID)
+	assert.Equal(t, []string{"github.com/google/uuid"}, data.TestImports())
+
+	var create []string
+	for _, field := range data.CreateFields {
+		create = append(create, field.Column)
+	}
+	assert.Equal(t, []string{"email", "nickname", "balance", "revision", "plan"}, create,
+		"the repository maintains the id, version and deleted_at columns, the columns with a default come last")
+	require.NotNil(t, data.FirstRequired())
+	assert.Equal(t, "email", data.FirstRequired().Column)
+	assert.False(t, data.CreateFields[4].Required, "create leaves the columns with a default to the database when unset")
+	assert.False(t, data.CreateFields[1].Required)
+	assert.True(t, data.CreateFields[2].Required)
+	assert.True(t, data.UpdateFields[2].Required, "updates replace every column")
+
+	require.Len(t, data.Finders, 2)
+	assert.Equal(t, "/accounts/by/email/{email}", data.FinderPath(data.Finders[0]))
+	assert.Equal(t, "repo.EXPECT().GetByID(gomock.Any()", data.Expect("GetByID"))
+}
+
+func TestTestIDValue(t *testing.T) {
+	id, path := testIDValue("int64")
+	assert.Equal(t, "int64(1)", id)
+	assert.Equal(t, "1", path)
+
+	id, _ = testIDValue("string")
+	assert.Equal(t, `"1"`, id)
+
+	id, path = testIDValue("time.Time")
+	assert.Empty(t, id, "types without an obvious literal are not tested by ID")
+	assert.Empty(t, path)
+}
+
+func TestGenerator_GenerateHandlers(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:         dir,
+		OutputDirs:        config.OutputDirs{Handlers: filepath.Join(dir, "handlers")},
+		MockProvider:      "testify",
+		WithTests:         true,
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	table := testTable("accounts")
+	table.Indexes = append(table.Indexes, introspector.Index{Name: "accounts_nickname_idx", Columns: []string{"nickname"}})
+	require.NoError(t, g.Generate(&introspector.Schema{Tables: []introspector.Table{table}}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetHandlersDir(), "handlers.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, "handlers", src.file.Name.Name)
+	assert.Equal(t, "func(err error) (int, string)", src.Signature("errorStatus"))
+	assert.False(t, src.Uses("errorStatus", "ErrNoTenant"), "tenancy is disabled")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetHandlersDir(), "accounts_handler.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, []string{
+		`"POST /accounts", h.create`,
+		`"GET /accounts", h.list`,
+		`"GET /accounts/{id}", h.get`,
+		`"PUT /accounts/{id}", h.update`,
+		`"PATCH /accounts/{id}", h.patch`,
+		`"DELETE /accounts/{id}", h.delete`,
+		`"GET /accounts/by/email/{email}", h.getByEmail`,
+		`"GET /accounts/by/nickname/{nickname}", h.listByNickname`,
+		`"GET /accounts/by/plan/{plan}", h.listByPlan`,
+	}, src.Calls("AccountsHandler.Register", "HandleFunc"))
+
+	t.Run("create", func(t *testing.T) {
+		assert.Equal(t, []string{"Email *string", "Nickname *string", "Balance *int64", "Revision *int32", "DeletedAt *time.Time", "Plan *string"},
+			src.Fields("CreateAccountsRequest"), "the id and version are left to the database")
+		assert.Equal(t, []string{`"email", "is required"`, `"balance", "is required"`}, src.Calls("CreateAccountsRequest.Validate", "add"),
+			"nullable and defaulted columns are optional")
+		assert.Contains(t, src.Calls("AccountsHandler.create", "Set"), `"ETag", etag(accounts.Version)`, "the version set by the database is returned")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		filters := src.Calls("AccountsHandler.list", "filterParams")
+		assert.Equal(t, []string{`q, "id", &filter.Id`, `q, "balance", &filter.Balance`, `q, "revision", &filter.Revision`, `q, "version", &filter.Version`}, filters)
+		assert.Contains(t, src.Calls("AccountsHandler.list", "stringFilterParams"), `q, "email", &filter.Email`)
+		assert.Equal(t, []string{`q, "deleted_at", &filter.DeletedAt`}, src.Calls("AccountsHandler.list", "timeFilterParams"))
+	})
+
+	t.Run("finders", func(t *testing.T) {
+		assert.Equal(t, []string{`r, "nickname", &nickname`}, src.Calls("AccountsHandler.listByNickname", "pathValue"))
+		assert.Equal(t, []string{"r.Context(), &nickname, limit, offset"}, src.Calls("AccountsHandler.listByNickname", "ListByNickname"),
+			"nullable columns are looked up by pointer")
+		assert.Equal(t, []string{"r.Context(), email"}, src.Calls("AccountsHandler.getByEmail", "GetByEmail"))
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		assert.Equal(t, []string{"r, &version"}, src.Calls("AccountsHandler.patch", "ifMatch"))
+		assert.Equal(t, []string{"r, &accounts.Version"}, src.Calls("AccountsHandler.update", "ifMatch"))
+		assert.Equal(t, []string{`"ETag", etag(accounts.Version)`}, src.Calls("AccountsHandler.get", "Set"))
+		assert.Equal(t, []string{"r.Context(), id, version, req.patch()"}, src.Calls("AccountsHandler.patch", "Patch"))
+		assert.Equal(t, []string{"r.Context(), id, version"}, src.Calls("AccountsHandler.delete", "Delete"))
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"Email models.Optional[string]",
+			"Nickname models.Optional[*string]",
+			"Plan models.Optional[string]",
+			"Balance models.Optional[int64]",
+			"Revision models.Optional[*int32]",
+			"DeletedAt models.Optional[*time.Time]",
+		}, src.Fields("PatchAccountsRequest"))
+		assert.Equal(t, "func() models.AccountsPatch", src.Signature("PatchAccountsRequest.patch"))
+	})
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetHandlersDir(), "accounts_handler_test.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Contains(t, src.Calls("TestAccountsHandler_Delete", "On"), `"Delete", mocklib.Anything, id, int32(1)`)
+	assert.Equal(t, []string{`t, body.Fields, "email"`}, src.Calls("TestAccountsHandler_Create", "Contains"))
+}
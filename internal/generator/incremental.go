@@ -108,7 +108,8 @@ func (ig *IncrementalGenerator) GenerateIncremental(schema *introspector.Schema)
 	}
 
 	// Generate only changed tables
-	changedTables := ig.getChangedTables(schema, changes)
+	full := ig.repositoryTables(schema)
+	changedTables := ig.getChangedTables(full, changes)
 	if len(changedTables) == 0 {
 		slog.Info("No tables need regeneration")
 		return nil
@@ -125,7 +126,13 @@ func (ig *IncrementalGenerator) GenerateIncremental(schema *introspector.Schema)
 	}
 
 	// Generate code for changed tables
-	if err := ig.Generator.Generate(incrementalSchema); err != nil {
+	if err := ig.generateTableFiles(incrementalSchema); err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	// Files spanning the tables aggregate or relate every table, so they are rebuilt
+	// from the full schema
+	if err := ig.generateSchemaFiles(full); err != nil {
 		return fmt.Errorf("failed to generate code: %w", err)
 	}
 
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// jsonSchemaTemplateData holds the data of the JSON Schema document of a model
+type jsonSchemaTemplateData struct {
+	StructName  string
+	Description string            // Description as a JSON string
+	Properties  []OpenAPIProperty // Properties of the model, named after its JSON tags
+	Required    []string          // Properties always encoded, as JSON strings
+}
+
+// newJSONSchemaTemplateData builds the JSON Schema document of the model of a table
+func newJSONSchemaTemplateData(table introspector.Table) jsonSchemaTemplateData {
+	data := jsonSchemaTemplateData{
+		StructName:  toPascalCase(table.Name),
+		Description: jsonString(tableDescription(table)),
+	}
+	for _, col := range table.Columns {
+		data.Properties = append(data.Properties, OpenAPIProperty{Name: col.Name, Schema: openAPIColumnSchema(col, true)})
+		if !omitsEmpty(col.GoType) {
+			data.Required = append(data.Required, jsonString(col.Name))
+		}
+	}
+	return data
+}
+
+// omitsEmpty reports whether encoding/json omits the model fields of a Go type when
+// they hold their zero value, the fields being tagged omitempty. Structs and arrays
+// are always encoded.
+func omitsEmpty(goType string) bool {
+	switch goType {
+	case "time.Time", "decimal.Decimal", "uuid.UUID":
+		return false
+	default:
+		return true
+	}
+}
+
+// tableDescription returns the comment of a table, or a description of its rows
+func tableDescription(table introspector.Table) string {
+	if table.Comment != "" {
+		return table.Comment
+	}
+	return "A row of the " + table.Name + " table"
+}
+
+// generateJSONSchemas generates a JSON Schema document of the JSON encoding of each model
+func (g *Generator) generateJSONSchemas(schema *introspector.Schema) error {
+	slog.Info("Generating JSON Schema documents...")
+
+	tmpl, err := g.getTemplate("json_schema.tmpl")
+	if err != nil {
+		return err
+	}
+
+	for _, table := range schema.Tables {
+		table = withSystemVersion(table, g.getVersionColumnName(table))
+
+		filename := toSnakeCase(table.Name) + ".schema.json"
+		if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetJSONSchemaDir(), filename), newJSONSchemaTemplateData(table)); err != nil {
+			return fmt.Errorf("failed to generate JSON Schema for table %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
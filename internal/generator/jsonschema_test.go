@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewJSONSchemaTemplateData(t *testing.T) {
+	data := newJSONSchemaTemplateData(testTable("orders"))
+
+	assert.Equal(t, "Orders", data.StructName)
+	assert.Equal(t, `"Orders of the shop"`, data.Description)
+	assert.Equal(t, []string{`"id"`, `"total"`, `"placed_at"`, `"due_on"`}, data.Required, "only the fields never omitted by omitempty are required")
+	require.Len(t, data.Properties, 12)
+	assert.Equal(t, `{"type":["string","null"],"enum":["partial","full",null]}`, data.Properties[3].Schema.String())
+}
+
+func TestGenerator_GenerateJSONSchemas(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:         dir,
+		OutputDirs:        config.OutputDirs{JSONSchema: filepath.Join(dir, "schemas")},
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true, Column: "xmin"},
+	}
+	cfg.ApplyDefaults()
+
+	require.NoError(t, New(cfg).Generate(testTables("orders")))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetJSONSchemaDir(), "orders.schema.json"))
+	require.NoError(t, err)
+
+	var document struct {
+		Schema      string                     `json:"$schema"`
+		Title       string                     `json:"title"`
+		Description string                     `json:"description"`
+		Properties  map[string]json.RawMessage `json:"properties"`
+		Required    []string                   `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(content, &document), "the document is valid JSON")
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", document.Schema)
+	assert.Equal(t, "Orders", document.Title)
+	assert.Equal(t, "Orders of the shop", document.Description)
+	assert.Equal(t, []string{"id", "total", "placed_at", "due_on"}, document.Required)
+	assert.JSONEq(t, `{"type":"string","enum":["open","paid"]}`, string(document.Properties["status"]))
+	assert.JSONEq(t, `{"type":"string","contentEncoding":"base64"}`, string(document.Properties["receipt"]))
+	assert.JSONEq(t, `{}`, string(document.Properties["ship_to"]))
+	assert.Contains(t, document.Properties, "xmin", "the version column of the model is described")
+}
+
+func TestIncrementalGenerator_JSONSchemas(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir: dir,
+		OutputDirs: config.OutputDirs{
+			JSONSchema: filepath.Join(dir, "schemas"),
+			TypeScript: filepath.Join(dir, "types"),
+		},
+	}
+	cfg.ApplyDefaults()
+
+	schema := &introspector.Schema{Tables: []introspector.Table{testTable("orders"), testTable("accounts")}}
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+	require.NoError(t, os.RemoveAll(cfg.GetJSONSchemaDir()))
+
+	// Only orders changes, every model is still described
+	schema.Tables[0].Columns = append(schema.Tables[0].Columns, introspector.Column{Name: "coupon", GoType: "*string", IsNullable: true})
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	for _, name := range []string{"orders.schema.json", "accounts.schema.json"} {
+		_, err := os.Stat(filepath.Join(cfg.GetJSONSchemaDir(), name))
+		assert.NoError(t, err, name)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetTypeScriptDir(), "index.d.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by pgx-goose. DO NOT EDIT.\n\nexport * from \"./orders\";\nexport * from \"./accounts\";\n", string(content))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetTypeScriptDir(), "orders.d.ts"))
+	require.NoError(t, err)
+	assert.Contains(t, schemaBlocks(string(content))["export interface Orders"], "coupon?: string | null;")
+}
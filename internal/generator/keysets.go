@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// Keyset describes a cursor paginated list method ordered by a unique sort key
+type Keyset struct {
+	Method  string         // Method name, "ListAfter" for the primary key or e.g. "ListAfterByPublishedAt"
+	Columns []KeysetColumn // Sort key columns followed by the primary key tie-break
+}
+
+// KeysetColumn describes a column of a keyset sort key
+type KeysetColumn struct {
+	Name  string // Column name
+	Field string // Model field name
+}
+
+// ColumnList returns the comma separated sort key columns
+func (k Keyset) ColumnList() string {
+	return strings.Join(k.names(), ", ")
+}
+
+// OrderBy returns the ORDER BY list for the given direction, "ASC" or "DESC"
+func (k Keyset) OrderBy(direction string) string {
+	parts := k.names()
+	for i := range parts {
+		parts[i] += " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Description returns a human readable list of the sort key columns
+func (k Keyset) Description() string {
+	return strings.Join(k.names(), " and ")
+}
+
+func (k Keyset) names() []string {
+	names := make([]string, len(k.Columns))
+	for i, col := range k.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// buildKeysets derives the keyset pagination methods of a table: one ordered by the
+// primary key and one per configured sort key. A sort key lists comma separated columns
+// that must be the leading columns of an index and cannot be NULL, since row comparisons
+// skip NULL values. The primary key is appended to every sort key to make it unique.
+func buildKeysets(table introspector.Table, sortKeys []string) []Keyset {
+	pks := primaryKeyColumns(table)
+	if len(pks) == 0 {
+		return nil
+	}
+
+	columns := make(map[string]introspector.Column, len(table.Columns))
+	for _, col := range table.Columns {
+		columns[col.Name] = col
+	}
+
+	keyset := func(method string, names []string) Keyset {
+		k := Keyset{Method: method}
+		for _, name := range names {
+			k.Columns = append(k.Columns, KeysetColumn{Name: name, Field: toPascalCase(name)})
+		}
+		return k
+	}
+
+	keysets := []Keyset{keyset("ListAfter", pks)}
+	seen := map[string]bool{strings.Join(pks, ","): true}
+
+	for _, sortKey := range sortKeys {
+		var names []string
+		for _, name := range strings.Split(sortKey, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		key := strings.Join(names, ",")
+		if len(names) == 0 || seen[key] {
+			continue
+		}
+
+		if reason := invalidSortKey(table, columns, names); reason != "" {
+			slog.Warn("Skipping keyset sort key", "table", table.Name, "sort_key", sortKey, "reason", reason)
+			continue
+		}
+
+		method := "ListAfterBy" + finderName(names)
+		for _, pk := range pks {
+			if !slices.Contains(names, pk) {
+				names = append(names, pk)
+			}
+		}
+
+		seen[key] = true
+		keysets = append(keysets, keyset(method, names))
+	}
+
+	return keysets
+}
+
+// invalidSortKey explains why columns cannot be used as a keyset sort key,
+// or returns an empty string when they can
+func invalidSortKey(table introspector.Table, columns map[string]introspector.Column, names []string) string {
+	for _, name := range names {
+		col, ok := columns[name]
+		switch {
+		case !ok:
+			return "unknown column " + name
+		case col.IsNullable:
+			return "nullable column " + name
+		case !isComparableGoType(col.GoType):
+			return "column " + name + " cannot be compared"
+		}
+	}
+
+	indexed := false
+	for _, idx := range append([]introspector.Index{{Columns: primaryKeyColumns(table)}}, table.Indexes...) {
+		if len(idx.Columns) >= len(names) && strings.Join(idx.Columns[:len(names)], ",") == strings.Join(names, ",") {
+			indexed = true
+			break
+		}
+	}
+	if !indexed {
+		return "columns are not the leading columns of an index"
+	}
+
+	return ""
+}
@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestBuildKeysets(t *testing.T) {
+	table := testTable("posts")
+	keysets := buildKeysets(table, []string{"user_id, slug", "type", "published_at", "slug", "missing", "id", "type"})
+
+	methods := make([]string, len(keysets))
+	for i, k := range keysets {
+		methods[i] = k.Method
+	}
+
+	// Nullable, unknown and non-leading index columns are skipped, duplicates generated once
+	require.Equal(t, []string{"ListAfter", "ListAfterByUserIdAndSlug", "ListAfterByType"}, methods)
+
+	t.Run("primary key", func(t *testing.T) {
+		k := keysets[0]
+		assert.Equal(t, "id", k.ColumnList())
+		assert.Equal(t, "id ASC", k.OrderBy("ASC"))
+	})
+
+	t.Run("composite sort key", func(t *testing.T) {
+		k := keysets[1]
+		assert.Equal(t, "user_id, slug, id", k.ColumnList(), "the primary key breaks ties")
+		assert.Equal(t, "user_id DESC, slug DESC, id DESC", k.OrderBy("DESC"))
+		assert.Equal(t, "user_id and slug and id", k.Description())
+		assert.Equal(t, "UserId", k.Columns[0].Field)
+	})
+}
+
+func TestBuildKeysets_NoPrimaryKey(t *testing.T) {
+	table := introspector.Table{
+		Name:    "logs",
+		Columns: []introspector.Column{{Name: "message", GoType: "string"}},
+	}
+
+	assert.Empty(t, buildKeysets(table, []string{"message"}))
+}
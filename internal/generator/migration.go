@@ -1,10 +1,14 @@
 package generator
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -513,6 +517,186 @@ func (mg *MigrationGenerator) writeMigrationFiles(migration Migration, config *M
 	}
 }
 
+// migrationUnitMarker starts the SQL of a unit in the up migration, followed by the key
+// and the signature of the unit
+const migrationUnitMarker = "-- pgx-goose:unit "
+
+// MigrationUnit is the part of a migration generated from the schema that creates one
+// object, such as the trigger of a table. Units are marked in the migrations so that
+// the later migrations only hold the units added or changed since.
+type MigrationUnit struct {
+	Key       string // Object created by the unit, e.g. the name of its table
+	Signature string // Definition of the object, a hash of UpSQL when empty
+	UpSQL     string
+	DownSQL   string
+	// Change returns the SQL changing the object from its definition in an earlier
+	// migration. Without it the unit is applied again and reverted to the earlier SQL.
+	Change func(previous AppliedMigrationUnit) (up, down string)
+}
+
+// AppliedMigrationUnit is the latest definition of a unit in the existing migrations
+type AppliedMigrationUnit struct {
+	Signature string
+	UpSQL     string
+}
+
+// signature returns the signature of the unit, a hash of its SQL by default
+func (u MigrationUnit) signature() string {
+	if u.Signature != "" {
+		return u.Signature
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(u.UpSQL)))[:16]
+}
+
+// WriteMigration writes a migration of the units generated from the schema that are
+// missing from the existing migrations of that name, or changed since. Existing migrations
+// are never rewritten since they may have been applied: the new migration gets a later
+// version, and none is written when every unit is up to date.
+func (mg *MigrationGenerator) WriteMigration(name, description string, units []MigrationUnit, format string) error {
+	applied, err := mg.appliedMigrationUnits(name)
+	if err != nil {
+		return err
+	}
+
+	var upSQL, downSQL []string
+	for _, unit := range units {
+		signature := unit.signature()
+		previous, ok := applied[unit.Key]
+		if ok && previous.Signature == signature {
+			continue
+		}
+
+		up, down := unit.UpSQL, unit.DownSQL
+		if ok && unit.Change != nil {
+			up, down = unit.Change(previous)
+		} else if ok {
+			down = previous.UpSQL
+		}
+		upSQL = append(upSQL, migrationUnitMarker+unit.Key+" "+signature+"\n"+up)
+		downSQL = append([]string{down}, downSQL...)
+	}
+	if len(upSQL) == 0 {
+		slog.Debug("Migration up to date", "name", name)
+		return nil
+	}
+
+	if err := os.MkdirAll(mg.migrationDir, 0755); err != nil {
+		return fmt.Errorf("failed to create migration directory: %w", err)
+	}
+	version, err := mg.nextMigrationVersion(time.Now())
+	if err != nil {
+		return err
+	}
+
+	migration := Migration{
+		Version:     version,
+		Name:        name,
+		UpSQL:       strings.Join(upSQL, "\n"),
+		DownSQL:     strings.Join(downSQL, "\n"),
+		Description: description,
+	}
+	return mg.writeMigrationFiles(migration, &MigrationConfig{MigrationFormat: format})
+}
+
+// droppedMigrationSignature marks the units dropping an object that is no longer generated
+const droppedMigrationSignature = "dropped"
+
+// droppedMigrationUnits returns the units dropping the objects of the existing migrations
+// of that name that are missing from units, drop returning the SQL dropping the object
+// of a key. Their down migration creates the object again as it was last defined.
+func (mg *MigrationGenerator) droppedMigrationUnits(name string, units []MigrationUnit, drop func(key string) string) ([]MigrationUnit, error) {
+	applied, err := mg.appliedMigrationUnits(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []MigrationUnit
+	for key, previous := range applied {
+		if previous.Signature == droppedMigrationSignature || slices.ContainsFunc(units, func(unit MigrationUnit) bool { return unit.Key == key }) {
+			continue
+		}
+		dropped = append(dropped, MigrationUnit{Key: key, Signature: droppedMigrationSignature, UpSQL: drop(key), DownSQL: previous.UpSQL})
+	}
+	// Map order is random, the migration is written in a stable one
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i].Key < dropped[j].Key })
+	return dropped, nil
+}
+
+// appliedMigrationUnits returns the latest definition of every unit marked in the
+// existing migrations of that name
+func (mg *MigrationGenerator) appliedMigrationUnits(name string) (map[string]AppliedMigrationUnit, error) {
+	nameSlug := strings.ReplaceAll(name, " ", "_")
+	var files []string
+	for _, suffix := range []string{".sql", ".up.sql"} {
+		matches, err := filepath.Glob(filepath.Join(mg.migrationDir, "*_"+nameSlug+suffix))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	// Versions have the same length, the latest migration is read last
+	sort.Slice(files, func(i, j int) bool { return filepath.Base(files[i]) < filepath.Base(files[j]) })
+
+	applied := make(map[string]AppliedMigrationUnit)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		var key string
+		var unit AppliedMigrationUnit
+		var lines []string
+		flush := func() {
+			if key != "" {
+				unit.UpSQL = strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+				applied[key] = unit
+			}
+			key, lines = "", nil
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if marker, ok := strings.CutPrefix(line, migrationUnitMarker); ok {
+				flush()
+				key, unit.Signature, _ = strings.Cut(marker, " ")
+				continue
+			}
+			if strings.HasPrefix(line, "-- +goose") {
+				flush()
+				if strings.HasPrefix(line, "-- +goose Down") {
+					break
+				}
+				continue
+			}
+			if key != "" {
+				lines = append(lines, line)
+			}
+		}
+		flush()
+	}
+	return applied, nil
+}
+
+// nextMigrationVersion returns the version of a migration written at now, after the
+// versions of the existing migrations so that goose applies it last
+func (mg *MigrationGenerator) nextMigrationVersion(now time.Time) (string, error) {
+	version, err := strconv.ParseInt(now.Format("20060102150405"), 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(mg.migrationDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration directory: %w", err)
+	}
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		if existing, err := strconv.ParseInt(prefix, 10, 64); err == nil && existing >= version {
+			version = existing + 1
+		}
+	}
+	return strconv.FormatInt(version, 10), nil
+}
+
 // writeGooseMigration writes a migration in Goose format
 func (mg *MigrationGenerator) writeGooseMigration(migration Migration) error {
 	filename := fmt.Sprintf("%s_%s.sql", migration.Version, strings.ReplaceAll(migration.Name, " ", "_"))
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -477,3 +478,69 @@ func BenchmarkMigrationGenerator_GenerateCreateTableSQL(b *testing.B) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestMigrationGenerator_WriteMigration(t *testing.T) {
+	cfg := &config.Config{OutputDir: t.TempDir()}
+	mg := NewMigrationGenerator(cfg)
+	users := MigrationUnit{Key: "users", UpSQL: "CREATE TRIGGER users_v1;", DownSQL: "DROP TRIGGER users;"}
+	orders := MigrationUnit{Key: "orders", UpSQL: "CREATE TRIGGER orders;", DownSQL: "DROP TRIGGER orders;"}
+
+	readMigrations := func() []string {
+		entries, err := os.ReadDir(mg.migrationDir)
+		require.NoError(t, err)
+		var contents []string
+		for _, entry := range entries {
+			content, err := os.ReadFile(filepath.Join(mg.migrationDir, entry.Name()))
+			require.NoError(t, err)
+			contents = append(contents, string(content))
+		}
+		return contents
+	}
+	migration := func(up, down string) string {
+		return "-- +goose Up\n-- +goose StatementBegin\n" + up + "\n-- +goose StatementEnd\n\n-- +goose Down\n-- +goose StatementBegin\n" + down + "\n-- +goose StatementEnd\n"
+	}
+
+	require.NoError(t, mg.WriteMigration("notify_changes", "", []MigrationUnit{users}, "goose"))
+	first := readMigrations()
+	require.Len(t, first, 1)
+	assert.Equal(t, migration(migrationUnitMarker+"users "+users.signature()+"\nCREATE TRIGGER users_v1;", "DROP TRIGGER users;"), first[0])
+
+	// Unchanged units write no migration
+	require.NoError(t, mg.WriteMigration("notify_changes", "", []MigrationUnit{users}, "goose"))
+	assert.Len(t, readMigrations(), 1)
+
+	// An added unit gets a new migration holding only that unit
+	require.NoError(t, mg.WriteMigration("notify_changes", "", []MigrationUnit{users, orders}, "goose"))
+	migrations := readMigrations()
+	require.Len(t, migrations, 2)
+	assert.Equal(t, first[0], migrations[0], "applied migrations are never rewritten")
+	assert.Equal(t, migration(migrationUnitMarker+"orders "+orders.signature()+"\nCREATE TRIGGER orders;", "DROP TRIGGER orders;"), migrations[1])
+
+	// A changed unit is applied again and reverted to its previous definition
+	users.UpSQL = "CREATE TRIGGER users_v2;"
+	require.NoError(t, mg.WriteMigration("notify_changes", "", []MigrationUnit{users, orders}, "goose"))
+	migrations = readMigrations()
+	require.Len(t, migrations, 3)
+	assert.Equal(t, migration(migrationUnitMarker+"users "+users.signature()+"\nCREATE TRIGGER users_v2;", "CREATE TRIGGER users_v1;\n"), migrations[2])
+
+	applied, err := mg.appliedMigrationUnits("notify_changes")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TRIGGER users_v2;\n", applied["users"].UpSQL)
+	assert.Equal(t, "CREATE TRIGGER orders;\n", applied["orders"].UpSQL)
+}
+
+func TestMigrationGenerator_NextMigrationVersion(t *testing.T) {
+	cfg := &config.Config{OutputDir: t.TempDir()}
+	mg := NewMigrationGenerator(cfg)
+	require.NoError(t, os.MkdirAll(mg.migrationDir, 0755))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	version, err := mg.nextMigrationVersion(now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240101000000", version)
+
+	require.NoError(t, os.WriteFile(filepath.Join(mg.migrationDir, "20240101000000_notify_changes.sql"), nil, 0644))
+	version, err = mg.nextMigrationVersion(now)
+	require.NoError(t, err)
+	assert.Equal(t, "20240101000001", version, "versions written in the same second follow each other")
+}
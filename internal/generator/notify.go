@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// notifyMigrationName names the migration creating the change notification triggers
+const notifyMigrationName = "notify_changes"
+
+// notifyPayloadLimit is the maximum size of a NOTIFY payload, larger rows are notified
+// without their content
+const notifyPayloadLimit = 8000
+
+// notifyChannel returns the channel the changes of a table are notified on
+func notifyChannel(table string) string {
+	return table + "_changes"
+}
+
+// notifyFunction returns the trigger function notifying the changes of a table, the
+// trigger has the same name
+func notifyFunction(table string) string {
+	return table + "_notify_change"
+}
+
+// notifyTables returns the tables whose changes are notified: the selected tables,
+// or all of them when none are selected. Tables without a single column primary key
+// are skipped since their events could not identify the changed row.
+func notifyTables(tables []introspector.Table, selected []string) []introspector.Table {
+	var result []introspector.Table
+	for _, table := range tables {
+		if len(selected) > 0 && !slices.ContainsFunc(selected, func(name string) bool { return strings.EqualFold(name, table.Name) }) {
+			continue
+		}
+		if len(primaryKeyColumns(table)) != 1 {
+			slog.Warn("Skipping change notifications", "table", table.Name, "reason", "table has no single column primary key")
+			continue
+		}
+		result = append(result, table)
+	}
+	return result
+}
+
+// rowJSON returns the expression encoding the row of a table named row as JSON, the
+// way encoding/json decodes it into the model
+func rowJSON(table introspector.Table, row string) string {
+	var overrides []string
+	for _, col := range table.Columns {
+		if expr := jsonOverride(row, col); expr != "" {
+			overrides = append(overrides, fmt.Sprintf("'%s', %s", col.Name, expr))
+		}
+	}
+	if len(overrides) == 0 {
+		return fmt.Sprintf("to_jsonb(%s)", row)
+	}
+	return fmt.Sprintf("to_jsonb(%s) || jsonb_build_object(%s)", row, strings.Join(overrides, ", "))
+}
+
+// jsonOverride returns the expression encoding a column of row the way encoding/json
+// decodes its Go type, or an empty string when to_jsonb already does
+func jsonOverride(row string, col introspector.Column) string {
+	switch col.Type {
+	case "timestamp", "timestamp without time zone":
+		// pgx reads timestamps without time zone as UTC
+		return fmt.Sprintf(`to_char(%s.%s, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')`, row, col.Name)
+	case "date":
+		return fmt.Sprintf(`to_char(%s.%s, 'YYYY-MM-DD"T00:00:00Z"')`, row, col.Name)
+	case "bytea":
+		return fmt.Sprintf(`encode(%s.%s, 'base64')`, row, col.Name)
+	}
+	return ""
+}
+
+// notifyTriggerSQL returns the SQL creating and dropping the trigger notifying the
+// changes of a table. The payload holds the operation, the primary key and the row,
+// the new one or the deleted one, unless the row is too large for a notification.
+func notifyTriggerSQL(table introspector.Table) (up, down string) {
+	pk := primaryKeyColumns(table)[0]
+	function := notifyFunction(table.Name)
+
+	row := rowJSON(table, "rec")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$\n", function)
+	b.WriteString("DECLARE\n\trec RECORD;\n\tpayload text;\nBEGIN\n")
+	b.WriteString("\tIF TG_OP = 'DELETE' THEN\n\t\trec := OLD;\n\tELSE\n\t\trec := NEW;\n\tEND IF;\n\n")
+	fmt.Fprintf(&b, "\tpayload := json_build_object('op', TG_OP, 'id', rec.%s, 'row', %s)::text;\n", pk, row)
+	fmt.Fprintf(&b, "\tIF octet_length(payload) >= %d THEN\n", notifyPayloadLimit)
+	fmt.Fprintf(&b, "\t\tpayload := json_build_object('op', TG_OP, 'id', rec.%s)::text;\n\tEND IF;\n\n", pk)
+	fmt.Fprintf(&b, "\tPERFORM pg_notify('%s', payload);\n\tRETURN NULL;\nEND;\n$$ LANGUAGE plpgsql;\n\n", notifyChannel(table.Name))
+	fmt.Fprintf(&b, "DROP TRIGGER IF EXISTS %s ON %s;\n", function, table.Name)
+	fmt.Fprintf(&b, "CREATE TRIGGER %s\n\tAFTER INSERT OR UPDATE OR DELETE ON %s\n\tFOR EACH ROW EXECUTE FUNCTION %s();\n", function, table.Name, function)
+
+	return b.String(), notifyDropSQL(table.Name)
+}
+
+// notifyDropSQL returns the SQL dropping the trigger notifying the changes of a table
+func notifyDropSQL(table string) string {
+	function := notifyFunction(table)
+	return fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;\nDROP FUNCTION IF EXISTS %s();\n", function, table, function)
+}
@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNotifyTables(t *testing.T) {
+	tables := []introspector.Table{
+		testTable("orders"),
+		{Name: "users", Columns: []introspector.Column{{Name: "id", GoType: "int", IsPrimaryKey: true}}},
+		{Name: "logs", Columns: []introspector.Column{{Name: "line", GoType: "string"}}},
+	}
+
+	var names []string
+	for _, table := range notifyTables(tables, nil) {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"orders", "users"}, names, "tables without primary key are skipped")
+
+	selected := notifyTables(tables, []string{"USERS"})
+	require.Len(t, selected, 1)
+	assert.Equal(t, "users", selected[0].Name)
+}
+
+func TestRowJSON(t *testing.T) {
+	assert.Equal(t, `to_jsonb(rec) || jsonb_build_object(`+
+		`'placed_at', to_char(rec.placed_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'), `+
+		`'due_on', to_char(rec.due_on, 'YYYY-MM-DD"T00:00:00Z"'), `+
+		`'receipt', encode(rec.receipt, 'base64'))`, rowJSON(testTable("orders"), "rec"), "to_jsonb encodes timestamps with time zone as RFC 3339")
+
+	assert.Equal(t, "to_jsonb(posts)", rowJSON(testTable("posts"), "posts"))
+}
+
+func TestNotifyTriggerSQL(t *testing.T) {
+	table := testTable("orders")
+	up, down := notifyTriggerSQL(table)
+
+	assert.Equal(t, `CREATE OR REPLACE FUNCTION orders_notify_change() RETURNS trigger AS $$
+DECLARE
+	rec RECORD;
+	payload text;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		rec := OLD;
+	ELSE
+		rec := NEW;
+	END IF;
+
+	payload := json_build_object('op', TG_OP, 'id', rec.id, 'row', `+rowJSON(table, "rec")+`)::text;
+	IF octet_length(payload) >= 8000 THEN
+		payload := json_build_object('op', TG_OP, 'id', rec.id)::text;
+	END IF;
+
+	PERFORM pg_notify('orders_changes', payload);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_notify_change ON orders;
+CREATE TRIGGER orders_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON orders
+	FOR EACH ROW EXECUTE FUNCTION orders_notify_change();
+`, up)
+	assert.Equal(t, "DROP TRIGGER IF EXISTS orders_notify_change ON orders;\nDROP FUNCTION IF EXISTS orders_notify_change();\n", down)
+	assert.Equal(t, down, notifyDropSQL("orders"))
+}
+
+func TestGenerator_GenerateNotify(t *testing.T) {
+	cfg := &config.Config{OutputDir: t.TempDir(), Notify: config.NotifyConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	table := testTable("orders")
+	require.NoError(t, g.createDirectories())
+	require.NoError(t, g.generateNotify(&introspector.Schema{Tables: []introspector.Table{table}}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "notify.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, "func(ctx context.Context, conn *pgx.Conn) (<-chan OrdersChangeEvent, error)", src.Signature("SubscribeOrdersChanges"))
+	assert.Equal(t, []string{`ctx, conn, "orders_changes", handle, func() { close(events) }`}, src.Calls("SubscribeOrdersChanges", "subscribe"))
+	assert.Equal(t, []string{
+		"context",
+		"encoding/json",
+		"fmt",
+		"time",
+		"github.com/jackc/pgx/v5",
+		"github.com/google/uuid",
+		"github.com/fsvxavier/pgx-goose/models",
+	}, src.Imports(), "the uuid key is imported once besides time")
+
+	migrations, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_notify_changes.sql"))
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+
+	content, err = os.ReadFile(migrations[0])
+	require.NoError(t, err)
+	up, down := notifyTriggerSQL(table)
+	assert.Equal(t, "-- +goose Up\n-- +goose StatementBegin\n"+migrationUnitMarker+"orders "+MigrationUnit{UpSQL: up}.signature()+"\n"+up+
+		"\n-- +goose StatementEnd\n\n-- +goose Down\n-- +goose StatementBegin\n"+down+"\n-- +goose StatementEnd\n", string(content))
+}
+
+func TestGenerator_GenerateNotify_RemovedTable(t *testing.T) {
+	cfg := &config.Config{OutputDir: t.TempDir(), Notify: config.NotifyConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+
+	users := introspector.Table{Name: "users", Columns: []introspector.Column{{Name: "id", Type: "integer", GoType: "int", IsPrimaryKey: true}}}
+	schema := &introspector.Schema{Tables: []introspector.Table{testTable("orders"), users}}
+	g := New(cfg)
+	require.NoError(t, g.createDirectories())
+	require.NoError(t, g.generateNotify(schema))
+
+	migrations := func() []string {
+		files, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_notify_changes.sql"))
+		require.NoError(t, err)
+		return files
+	}
+	require.Len(t, migrations(), 1)
+
+	// Only users stays notified, the trigger of orders is dropped
+	cfg.Notify.Tables = []string{"users"}
+	require.NoError(t, g.generateNotify(schema))
+	files := migrations()
+	require.Len(t, files, 2)
+
+	content, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	up, down, _ := strings.Cut(string(content), "-- +goose Down")
+	created, _ := notifyTriggerSQL(testTable("orders"))
+	assert.Equal(t, "-- +goose Up\n-- +goose StatementBegin\n"+migrationUnitMarker+"orders "+droppedMigrationSignature+"\n"+notifyDropSQL("orders")+"\n-- +goose StatementEnd\n\n", up)
+	assert.Equal(t, "\n-- +goose StatementBegin\n"+created+"\n-- +goose StatementEnd\n", down, "reverting the drop creates the trigger again")
+
+	// The drop is only written once
+	require.NoError(t, g.generateNotify(schema))
+	assert.Len(t, migrations(), 2)
+
+	applied, err := NewMigrationGenerator(cfg).appliedMigrationUnits(notifyMigrationName)
+	require.NoError(t, err)
+	assert.Equal(t, droppedMigrationSignature, applied["orders"].Signature)
+}
+
+func TestIncrementalGenerator_Notify(t *testing.T) {
+	cfg := &config.Config{OutputDir: t.TempDir(), Notify: config.NotifyConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+
+	users := introspector.Table{Name: "users", Columns: []introspector.Column{{Name: "id", Type: "integer", GoType: "int", IsPrimaryKey: true}}}
+	schema := &introspector.Schema{Tables: []introspector.Table{testTable("orders"), users}}
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	// Only users changes, the subscriptions of orders are kept
+	schema.Tables[1].Columns = append(schema.Tables[1].Columns, introspector.Column{Name: "name", Type: "text", GoType: "string"})
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "notify.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.True(t, src.Has("SubscribeOrdersChanges"))
+	assert.True(t, src.Has("SubscribeUsersChanges"))
+}
@@ -0,0 +1,269 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// OpenAPISchema is the JSON Schema of a value in the OpenAPI document. It is written
+// in JSON, which the YAML document embeds as is.
+type OpenAPISchema struct {
+	Type            any    `json:"type,omitempty"` // A type name, or a list of them for nullable values
+	Format          string `json:"format,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	MaxLength       int    `json:"maxLength,omitempty"`
+	Minimum         *int   `json:"minimum,omitempty"`
+	Maximum         *int   `json:"maximum,omitempty"`
+	Enum            []any  `json:"enum,omitempty"`
+	Description     string `json:"description,omitempty"`
+	ReadOnly        bool   `json:"readOnly,omitempty"`
+}
+
+// String returns the schema in JSON
+func (s OpenAPISchema) String() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// OpenAPIProperty is a property of an object schema
+type OpenAPIProperty struct {
+	Name   string
+	Schema OpenAPISchema
+}
+
+// Key returns the name of the property as a YAML key
+func (p OpenAPIProperty) Key() string {
+	return jsonString(p.Name)
+}
+
+// OpenAPIParameter is a path or query parameter of an operation
+type OpenAPIParameter struct {
+	Name        string
+	In          string // "path" or "query"
+	Description string
+	Schema      OpenAPISchema
+}
+
+// Doc returns the description of the parameter as a YAML string
+func (p OpenAPIParameter) Doc() string {
+	return jsonString(p.Description)
+}
+
+// openAPITable holds the data of the paths and schemas of a table
+type openAPITable struct {
+	handlerTemplateData
+	Properties     []OpenAPIProperty  // Properties of the model, named after its JSON tags
+	Create         []OpenAPIProperty  // Properties of the create request
+	CreateRequired []string           // Required properties of the create request
+	Update         []OpenAPIProperty  // Properties of the update request
+	UpdateRequired []string           // Required properties of the update request
+	Patch          []OpenAPIProperty  // Properties of the patch
+	IDSchema       OpenAPISchema      // Schema of the id path parameter
+	FilterParams   []OpenAPIParameter // Query parameters filtering the list
+	SortFields     []string           // Columns the list can be sorted by
+	columns        map[string]introspector.Column
+}
+
+// Description returns the description of the table as a YAML string
+func (t openAPITable) Description() string {
+	return jsonString(tableDescription(t.Table))
+}
+
+// FinderParameters returns the path parameters of a finder
+func (t openAPITable) FinderParameters(finder Finder) []OpenAPIParameter {
+	params := make([]OpenAPIParameter, len(finder.Columns))
+	for i, col := range finder.Columns {
+		params[i] = OpenAPIParameter{Name: col.Name, In: "path", Schema: openAPIColumnSchema(t.columns[col.Name], false)}
+	}
+	return params
+}
+
+// openAPITemplateData holds the data of the OpenAPI document
+type openAPITemplateData struct {
+	Title   string // Title as a YAML string
+	Version string // Version as a YAML string
+	Tables  []openAPITable
+}
+
+// newOpenAPITable builds the paths and schemas of a table, matching the generated handlers
+func (g *Generator) newOpenAPITable(table introspector.Table) openAPITable {
+	data := openAPITable{
+		handlerTemplateData: g.newHandlerTemplateData(table),
+		columns:             make(map[string]introspector.Column),
+	}
+
+	for _, col := range data.Table.Columns {
+		data.columns[col.Name] = col
+		data.Properties = append(data.Properties, OpenAPIProperty{Name: col.Name, Schema: openAPIColumnSchema(col, true)})
+		if col.IsPrimaryKey && data.IDSchema.Type == nil {
+			data.IDSchema = openAPIColumnSchema(col, false)
+		}
+	}
+	if data.IDSchema.Type == nil {
+		data.IDSchema = openAPIGoTypeSchema(data.PrimaryKeyType)
+	}
+
+	for _, field := range data.CreateFields {
+		data.Create = append(data.Create, data.requestProperty(field))
+		if field.Required {
+			data.CreateRequired = append(data.CreateRequired, field.Column)
+		}
+	}
+	for _, field := range data.UpdateFields {
+		data.Update = append(data.Update, data.requestProperty(field))
+		if field.Required {
+			data.UpdateRequired = append(data.UpdateRequired, field.Column)
+		}
+	}
+	for _, field := range data.UpdateFields {
+		data.Patch = append(data.Patch, data.requestProperty(field))
+	}
+
+	for _, filter := range data.Filters {
+		data.FilterParams = append(data.FilterParams, openAPIFilterParameters(data.columns[filter.Column], filter.Kind)...)
+	}
+	for _, field := range buildSortFields(data.Table) {
+		data.SortFields = append(data.SortFields, field.Column)
+	}
+	return data
+}
+
+// requestProperty returns the property of a request field, without the read only flag
+// of its column
+func (t openAPITable) requestProperty(field HandlerField) OpenAPIProperty {
+	schema := openAPIColumnSchema(t.columns[field.Column], true)
+	schema.ReadOnly = false
+	return OpenAPIProperty{Name: field.Column, Schema: schema}
+}
+
+// openAPIFilterParameters returns the query parameters of the predicates on a column,
+// as read by the filterParams helpers of the handlers
+func openAPIFilterParameters(col introspector.Column, kind string) []OpenAPIParameter {
+	value := openAPIColumnSchema(col, false)
+	value.Description = ""
+	value.ReadOnly = false
+
+	params := []OpenAPIParameter{
+		{Name: col.Name, Description: "Equal to", Schema: value},
+		{Name: col.Name + ".in", Description: "Equal to one of the comma separated values", Schema: OpenAPISchema{Type: "string"}},
+		{Name: col.Name + ".lt", Description: "Less than", Schema: value},
+		{Name: col.Name + ".gt", Description: "Greater than", Schema: value},
+	}
+	if col.IsNullable {
+		params = append(params, OpenAPIParameter{Name: col.Name + ".null", Description: "Is NULL when true, is not NULL when false", Schema: OpenAPISchema{Type: "boolean"}})
+	}
+	switch kind {
+	case "string":
+		params = append(params, OpenAPIParameter{Name: col.Name + ".like", Description: "Matches the LIKE pattern", Schema: OpenAPISchema{Type: "string"}})
+	case "time":
+		params = append(params,
+			OpenAPIParameter{Name: col.Name + ".from", Description: "At or after", Schema: value},
+			OpenAPIParameter{Name: col.Name + ".to", Description: "Before", Schema: value},
+		)
+	}
+	for i := range params {
+		params[i].In = "query"
+	}
+	return params
+}
+
+// openAPIColumnSchema returns the schema of the values of a column as encoded by the
+// models. nullable allows null for nullable columns.
+func openAPIColumnSchema(col introspector.Column, nullable bool) OpenAPISchema {
+	schema := openAPIGoTypeSchema(strings.TrimPrefix(col.GoType, "*"))
+	if len(col.EnumValues) > 0 {
+		schema = OpenAPISchema{Type: "string"}
+		for _, value := range col.EnumValues {
+			schema.Enum = append(schema.Enum, value)
+		}
+	}
+	if col.MaxLength > 0 && schema.Type == "string" {
+		schema.MaxLength = col.MaxLength
+	}
+	schema.Description = col.Comment
+	schema.ReadOnly = col.IsGenerated
+
+	if nullable && col.IsNullable && schema.Type != nil {
+		schema.Type = []string{schema.Type.(string), "null"}
+		if schema.Enum != nil {
+			schema.Enum = append(schema.Enum, nil)
+		}
+	}
+	return schema
+}
+
+// openAPIGoTypeSchema returns the schema of the JSON encoding of a Go type of the models
+func openAPIGoTypeSchema(goType string) OpenAPISchema {
+	switch goType {
+	case "int", "int32":
+		return OpenAPISchema{Type: "integer", Format: "int32"}
+	case "int16":
+		minimum, maximum := -1<<15, 1<<15-1
+		return OpenAPISchema{Type: "integer", Format: "int32", Minimum: &minimum, Maximum: &maximum}
+	case "int64":
+		return OpenAPISchema{Type: "integer", Format: "int64"}
+	case "uint32":
+		minimum := 0
+		return OpenAPISchema{Type: "integer", Format: "int64", Minimum: &minimum}
+	case "float32":
+		return OpenAPISchema{Type: "number", Format: "float"}
+	case "float64":
+		return OpenAPISchema{Type: "number", Format: "double"}
+	case "decimal.Decimal":
+		return OpenAPISchema{Type: "string", Format: "decimal"}
+	case "bool":
+		return OpenAPISchema{Type: "boolean"}
+	case "string":
+		return OpenAPISchema{Type: "string"}
+	case "time.Time":
+		return OpenAPISchema{Type: "string", Format: "date-time"}
+	case "uuid.UUID":
+		return OpenAPISchema{Type: "string", Format: "uuid"}
+	case "[]byte":
+		return OpenAPISchema{Type: "string", ContentEncoding: "base64"}
+	default:
+		// JSON documents and unknown types hold any value
+		return OpenAPISchema{}
+	}
+}
+
+// jsonString returns s as a JSON string, also a YAML double-quoted string
+func jsonString(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return `""`
+	}
+	return string(b)
+}
+
+// generateOpenAPI generates the OpenAPI document describing the tables and the REST API
+// of the generated handlers
+func (g *Generator) generateOpenAPI(schema *introspector.Schema) error {
+	slog.Info("Generating OpenAPI document...")
+
+	tmpl, err := g.getTemplate("openapi.tmpl")
+	if err != nil {
+		return err
+	}
+
+	data := openAPITemplateData{
+		Title:   jsonString(g.config.OpenAPI.Title),
+		Version: jsonString(g.config.OpenAPI.Version),
+	}
+	for _, table := range schema.Tables {
+		data.Tables = append(data.Tables, g.newOpenAPITable(table))
+	}
+
+	if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetOpenAPIDir(), "openapi.yaml"), data); err != nil {
+		return fmt.Errorf("failed to generate openapi.yaml: %w", err)
+	}
+	return nil
+}
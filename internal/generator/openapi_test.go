@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestOpenAPIColumnSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		col      introspector.Column
+		nullable bool
+		want     string
+	}{
+		{
+			name: "varchar",
+			col:  introspector.Column{Name: "email", GoType: "string", MaxLength: 255, Comment: `Login "email"`},
+			want: `{"type":"string","maxLength":255,"description":"Login \"email\""}`,
+		},
+		{
+			name:     "nullable enum",
+			col:      introspector.Column{Name: "status", GoType: "interface{}", IsNullable: true, EnumValues: []string{"draft", "sent"}},
+			nullable: true,
+			want:     `{"type":["string","null"],"enum":["draft","sent",null]}`,
+		},
+		{
+			name: "enum parameter",
+			col:  introspector.Column{Name: "status", GoType: "interface{}", IsNullable: true, EnumValues: []string{"draft", "sent"}},
+			want: `{"type":"string","enum":["draft","sent"]}`,
+		},
+		{
+			name:     "nullable uuid",
+			col:      introspector.Column{Name: "ref", GoType: "*uuid.UUID", IsNullable: true},
+			nullable: true,
+			want:     `{"type":["string","null"],"format":"uuid"}`,
+		},
+		{
+			name: "identity",
+			col:  introspector.Column{Name: "id", GoType: "int64", IsGenerated: true},
+			want: `{"type":"integer","format":"int64","readOnly":true}`,
+		},
+		{
+			name:     "json",
+			col:      introspector.Column{Name: "data", GoType: "*json.RawMessage", IsNullable: true},
+			nullable: true,
+			want:     `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, openAPIColumnSchema(tt.col, tt.nullable).String())
+		})
+	}
+}
+
+func TestNewOpenAPITable(t *testing.T) {
+	cfg := &config.Config{OptimisticLocking: config.OptimisticLockingConfig{Enabled: true}}
+	cfg.ApplyDefaults()
+	table := New(cfg).newOpenAPITable(testTable("accounts"))
+
+	assert.Equal(t, `{"type":"string","format":"uuid"}`, table.IDSchema.String())
+	assert.Equal(t, []string{"email", "balance"}, table.CreateRequired, "nullable and defaulted columns are not required on create")
+	assert.Equal(t, []string{"email", "plan", "balance"}, table.UpdateRequired)
+	assert.Equal(t, []string{"id", "email", "plan"}, table.SortFields)
+	assert.Len(t, table.Properties, len(testTable("accounts").Columns))
+
+	var params []string
+	for _, param := range table.FilterParams {
+		if param.Schema.Type == nil || param.In != "query" {
+			t.Errorf("unexpected parameter %+v", param)
+		}
+		params = append(params, param.Name)
+	}
+	assert.Contains(t, params, "email.like")
+	assert.Contains(t, params, "nickname.null")
+	assert.NotContains(t, params, "email.null", "only nullable columns are filtered by NULL")
+	assert.Contains(t, params, "deleted_at.from")
+}
+
+func TestGenerator_GenerateOpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:         dir,
+		OutputDirs:        config.OutputDirs{OpenAPI: filepath.Join(dir, "api")},
+		OpenAPI:           config.OpenAPIConfig{Title: "Accounts API"},
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+
+	table := testTable("accounts")
+	table.Comment = "Customer accounts"
+	require.NoError(t, New(cfg).Generate(&introspector.Schema{Tables: []introspector.Table{table}}))
+
+	doc := readOpenAPIDocument(t, cfg)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Equal(t, "Accounts API", openAPIValue(doc, "info", "title"))
+	assert.ElementsMatch(t, []string{"/accounts", "/accounts/{id}", "/accounts/by/email/{email}", "/accounts/by/plan/{plan}"}, openAPIKeys(doc, "paths"))
+	assert.Equal(t, "listAccountsByPlan", openAPIValue(doc, "paths", "/accounts/by/plan/{plan}", "get", "operationId"))
+	for _, method := range []string{"put", "patch", "delete"} {
+		assert.Contains(t, openAPIValue(doc, "paths", "/accounts/{id}", method, "parameters"), map[string]any{"$ref": "#/components/parameters/ifMatch"}, method)
+	}
+
+	assert.Equal(t, "Customer accounts", openAPIValue(doc, "components", "schemas", "Accounts", "description"))
+	assert.Equal(t, map[string]any{"type": []any{"string", "null"}}, openAPIValue(doc, "components", "schemas", "Accounts", "properties", "nickname"))
+	assert.Equal(t, []any{"email", "plan", "balance"}, openAPIValue(doc, "components", "schemas", "UpdateAccountsRequest", "required"))
+	assert.Equal(t, []any{"email", "balance"}, openAPIValue(doc, "components", "schemas", "CreateAccountsRequest", "required"))
+	assert.ElementsMatch(t, []string{"Error", "Accounts", "CreateAccountsRequest", "UpdateAccountsRequest", "PatchAccountsRequest", "AccountsList"},
+		openAPIKeys(doc, "components", "schemas"), "the patch of the repository is not exposed")
+}
+
+func TestIncrementalGenerator_OpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{OpenAPI: filepath.Join(dir, "api")},
+	}
+	cfg.ApplyDefaults()
+
+	schema := &introspector.Schema{Tables: []introspector.Table{testTable("accounts"), testTable("orders")}}
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	// Only orders changes, the document still describes both tables
+	schema.Tables[1].Columns = append(schema.Tables[1].Columns, introspector.Column{Name: "coupon", GoType: "*string", IsNullable: true})
+	require.NoError(t, NewIncrementalGenerator(cfg).GenerateIncremental(schema))
+
+	doc := readOpenAPIDocument(t, cfg)
+	assert.Subset(t, openAPIKeys(doc, "paths"), []string{"/accounts/{id}", "/orders/{id}"})
+	assert.NotNil(t, openAPIValue(doc, "components", "schemas", "Orders", "properties", "coupon"))
+}
+
+// readOpenAPIDocument parses the generated OpenAPI document
+func readOpenAPIDocument(t *testing.T, cfg *config.Config) map[string]any {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetOpenAPIDir(), "openapi.yaml"))
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(content, &doc), string(content))
+	return doc
+}
+
+// openAPIValue returns the value at a path of keys of a parsed document, nil when missing
+func openAPIValue(doc map[string]any, keys ...string) any {
+	var value any = doc
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// openAPIKeys returns the keys of the object at a path of a parsed document
+func openAPIKeys(doc map[string]any, keys ...string) []string {
+	m, _ := openAPIValue(doc, keys...).(map[string]any)
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// outboxMigrationName names the migration creating the outbox table
+const outboxMigrationName = "create_outbox"
+
+// Outbox describes the events appended to the outbox by the writes of an aggregate table.
+// The write statements return the written rows in a changed CTE the events are read from.
+type Outbox struct {
+	Table         string // Outbox table the events are appended to
+	AggregateType string // Type of the aggregate, the name of its table
+	AggregateID   string // Column identifying the aggregate, its primary key
+	Payload       string // Expression encoding the written row as JSON
+}
+
+// newOutbox returns the outbox events of an aggregate table, or nil when the table has
+// no single column primary key to identify the aggregate with
+func newOutbox(table introspector.Table, outboxTable string) *Outbox {
+	pk := primaryKeyColumns(table)
+	if len(pk) != 1 {
+		slog.Warn("Skipping outbox events", "table", table.Name, "reason", "table has no single column primary key")
+		return nil
+	}
+	return &Outbox{
+		Table:         outboxTable,
+		AggregateType: table.Name,
+		AggregateID:   pk[0],
+		Payload:       rowJSON(table, table.Name),
+	}
+}
+
+// EventType returns the type of the events appended by the given action
+func (o *Outbox) EventType(action string) string {
+	return o.AggregateType + "." + action
+}
+
+// Returning returns the RETURNING clause of a write statement feeding the changed CTE,
+// the columns read back by the repository follow the aggregate ID
+func (o *Outbox) Returning(columns ...string) string {
+	returning := "RETURNING " + o.AggregateID
+	for _, column := range columns {
+		returning += ", " + column
+	}
+	return returning + ", " + o.Payload + " AS outbox_payload"
+}
+
+// ReturningRow returns the RETURNING clause of a write statement feeding the changed CTE
+// for the statements reading the whole written row back
+func (o *Outbox) ReturningRow(columns []introspector.Column) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return "RETURNING " + strings.Join(names, ", ") + ", " + o.Payload + " AS outbox_payload"
+}
+
+// ReturningUpsert returns the RETURNING clause of an upsert feeding the changed CTE. It also
+// returns whether the row was inserted: the row version written by an insert has no xmax,
+// while the one written by the update of a conflicting row is locked by the transaction.
+func (o *Outbox) ReturningUpsert(columns []introspector.Column) string {
+	return o.ReturningRow(columns) + ", (xmax = 0) AS outbox_inserted"
+}
+
+// Event returns the statement appending an event of the given action for each changed row
+func (o *Outbox) Event(action string) string {
+	return o.event("'" + o.EventType(action) + "'")
+}
+
+// UpsertEvent returns the statement appending a created event for each row inserted by an
+// upsert and an updated event for each row it updated
+func (o *Outbox) UpsertEvent() string {
+	return o.event(fmt.Sprintf("CASE WHEN outbox_inserted THEN '%s' ELSE '%s' END", o.EventType("created"), o.EventType("updated")))
+}
+
+// event returns the statement appending an event of the given type expression for each changed row
+func (o *Outbox) event(eventType string) string {
+	return fmt.Sprintf("INSERT INTO %s (aggregate_type, aggregate_id, event_type, payload) SELECT '%s', %s::text, %s, outbox_payload FROM changed",
+		o.Table, o.AggregateType, o.AggregateID, eventType)
+}
+
+// outboxTableSQL returns the SQL creating and dropping the outbox table
+func outboxTableSQL(table string) (up, down string) {
+	up = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id bigserial PRIMARY KEY,
+	aggregate_type text NOT NULL,
+	aggregate_id text NOT NULL,
+	event_type text NOT NULL,
+	payload jsonb NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+`, table)
+	down = fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", table)
+	return up, down
+}
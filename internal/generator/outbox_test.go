@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewOutbox(t *testing.T) {
+	table := testTable("orders")
+	outbox := newOutbox(table, "outbox")
+	require.NotNil(t, outbox)
+
+	assert.Equal(t, "orders.created", outbox.EventType("created"))
+	assert.Equal(t, rowJSON(table, "orders"), outbox.Payload)
+	assert.Equal(t, "RETURNING id, version, "+outbox.Payload+" AS outbox_payload", outbox.Returning("version"))
+	assert.Equal(t, "INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT 'orders', id::text, 'orders.deleted', outbox_payload FROM changed", outbox.Event("deleted"))
+	assert.Equal(t, "RETURNING id, number, "+outbox.Payload+" AS outbox_payload", outbox.ReturningRow(table.Columns[:2]))
+	assert.Equal(t, "RETURNING id, number, "+outbox.Payload+" AS outbox_payload, (xmax = 0) AS outbox_inserted", outbox.ReturningUpsert(table.Columns[:2]))
+	assert.Equal(t, "INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) SELECT 'orders', id::text, CASE WHEN outbox_inserted THEN 'orders.created' ELSE 'orders.updated' END, outbox_payload FROM changed", outbox.UpsertEvent())
+
+	assert.Nil(t, newOutbox(introspector.Table{Name: "logs", Columns: []introspector.Column{{Name: "line", GoType: "string"}}}, "outbox"))
+}
+
+func TestRepositoryTemplates_Outbox(t *testing.T) {
+	cfg := &config.Config{
+		Outbox:     config.OutboxConfig{Enabled: true, Aggregates: []string{"orders"}},
+		SoftDelete: config.SoftDeleteConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	table := testTable("orders")
+	table.Columns = append(table.Columns,
+		introspector.Column{Name: "code", Type: "text", GoType: "string"},
+		introspector.Column{Name: "deleted_at", Type: "timestamp with time zone", GoType: "*time.Time", IsNullable: true},
+	)
+	table.Indexes = []introspector.Index{{Name: "orders_code_key", Columns: []string{"code"}, IsUnique: true}}
+
+	data := g.newRepositoryTemplateData(table, "postgres")
+	require.NotNil(t, data.Outbox)
+	outbox := data.Outbox
+	src := renderGoSource(t, g, "repository_postgres.tmpl", data)
+
+	columns := "id, number, status, refund, items, total, note, placed_at, paid_at, due_on, receipt, ship_to, code, deleted_at"
+	written := "status, refund, items, total, note, placed_at, paid_at, due_on, receipt, ship_to, code, deleted_at"
+	returning := outbox.ReturningRow(table.Columns)
+
+	t.Run("create", func(t *testing.T) {
+		assert.Equal(t, "Create creates a new Orders, appending a OrdersCreatedEvent to the outbox\n", src.Doc("OrdersRepository.Create"))
+		assert.Equal(t, []string{"WITH changed AS ( INSERT INTO orders (" + written + " ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12 ) " +
+			outbox.Returning() + " ), event AS ( " + outbox.Event("created") + " ) SELECT id FROM changed"}, src.Strings("createOrdersQuery"))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		assert.Equal(t, []string{"WITH changed AS ( UPDATE orders SET status = $1, refund = $2, items = $3, total = $4, note = $5, placed_at = $6, paid_at = $7, due_on = $8, receipt = $9, ship_to = $10, code = $11 " +
+			"WHERE id = $12 AND deleted_at IS NULL " + outbox.Returning() + " ) " + outbox.Event("updated")}, src.Strings("updateOrdersQuery"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, []string{"WITH changed AS (UPDATE orders SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL " + outbox.Returning() + ") " + outbox.Event("deleted")}, src.Strings("deleteOrdersQuery"))
+		assert.Contains(t, src.Doc("OrdersRepository.HardDelete"), "A OrdersDeletedEvent is appended to the outbox in the same statement.")
+		assert.Contains(t, src.Strings("OrdersRepository.HardDelete"), "WITH changed AS (DELETE FROM orders WHERE id = $1 "+outbox.Returning()+") "+outbox.Event("deleted"))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		assert.Contains(t, src.Doc("OrdersRepository.Restore"), "A OrdersRestoredEvent is appended to the outbox in the same statement.")
+		assert.Contains(t, src.Strings("OrdersRepository.Restore"), "WITH changed AS (UPDATE orders SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL "+outbox.Returning()+") "+outbox.Event("restored"))
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		assert.Contains(t, src.Strings("OrdersRepository.Patch"), "WITH changed AS (UPDATE orders SET %s WHERE id = $%d AND deleted_at IS NULL "+returning+"), "+
+			"event AS ("+outbox.Event("updated")+") SELECT "+columns+" FROM changed")
+	})
+
+	t.Run("insert many", func(t *testing.T) {
+		assert.Contains(t, src.Strings("OrdersRepository.InsertMany"), "WITH changed AS (INSERT INTO orders ("+written+") VALUES")
+		assert.Contains(t, src.Strings("OrdersRepository.InsertMany"), returning+"), event AS ("+outbox.Event("created")+") SELECT "+columns+" FROM changed")
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		require.Len(t, data.Upserts, 2)
+		upsert := data.Upserts[1]
+		assert.Equal(t, "UpsertByCode", upsert.Name)
+		assert.Contains(t, src.Strings("OrdersRepository.UpsertByCode"), "WITH changed AS ( INSERT INTO orders ("+upsert.ColumnList()+") VALUES ("+upsert.Placeholders()+") "+
+			"ON CONFLICT (code) DO UPDATE SET "+upsert.SetClause()+" "+outbox.ReturningUpsert(table.Columns)+" ), "+
+			"event AS ( "+outbox.UpsertEvent()+" ) SELECT "+columns+" FROM changed")
+	})
+
+	t.Run("copy is excluded", func(t *testing.T) {
+		assert.Contains(t, src.Doc("OrdersRepository.CreateMany"), "\nNo event is appended to the outbox since COPY cannot return the copied rows, use InsertMany\n"+
+			"for their OrdersCreatedEvents to be appended.\n")
+		assert.False(t, src.Uses("OrdersRepository.CreateMany", "outbox"))
+	})
+
+	// Tables that are not aggregates keep their plain statements
+	data = g.newRepositoryTemplateData(testTable("posts"), "postgres")
+	assert.Nil(t, data.Outbox)
+	src = renderGoSource(t, g, "repository_postgres.tmpl", data)
+	assert.Equal(t, []string{"DELETE FROM posts WHERE id = $1"}, src.Strings("deletePostsQuery"))
+	assert.Equal(t, []string{"INSERT INTO posts (user_id, slug, type, email, payload, published_at ) VALUES ($1, $2, $3, $4, $5, $6 ) RETURNING id"}, src.Strings("createPostsQuery"))
+}
+
+func TestGenerator_GenerateOutbox(t *testing.T) {
+	cfg := &config.Config{
+		OutputDir:    t.TempDir(),
+		MockProvider: "testify",
+		Outbox:       config.OutboxConfig{Enabled: true, Table: "events_outbox", Aggregates: []string{"orders"}},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	schema := &introspector.Schema{Tables: []introspector.Table{
+		testTable("orders"),
+		{Name: "events_outbox", Columns: []introspector.Column{{Name: "id", GoType: "int64", IsPrimaryKey: true}}},
+	}}
+	require.NoError(t, g.Generate(schema))
+
+	_, err := os.Stat(filepath.Join(cfg.GetReposDir(), "events_outbox_repository.go"))
+	assert.True(t, os.IsNotExist(err), "the outbox table gets no repository")
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "outbox.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, []string{"orders.created"}, src.Strings("OrdersCreatedEvent"))
+	assert.Equal(t, []string{"SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at FROM events_outbox ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED"}, src.Strings("lockOutboxEventsQuery"))
+	assert.Equal(t, []string{"DELETE FROM events_outbox WHERE id = ANY($1)"}, src.Strings("deleteOutboxEventsQuery"))
+	assert.Equal(t, "func(db TxBeginner, publisher Publisher, opts ...RelayOption) *Relay", src.Signature("NewRelay"))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetReposDir(), "tx_manager.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, []string{"db, opts"}, src.Calls("NewRepositories", "NewOutbox"))
+	assert.False(t, src.Uses("Repositories", "EventsOutbox"))
+
+	migrations, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_create_outbox.sql"))
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+
+	content, err = os.ReadFile(migrations[0])
+	require.NoError(t, err)
+	up, down := outboxTableSQL("events_outbox")
+	assert.Equal(t, "-- +goose Up\n-- +goose StatementBegin\n"+migrationUnitMarker+"events_outbox "+MigrationUnit{UpSQL: up}.signature()+"\n"+up+
+		"\n-- +goose StatementEnd\n\n-- +goose Down\n-- +goose StatementBegin\n"+down+"\n-- +goose StatementEnd\n", string(content))
+
+	// The applied migration is neither rewritten nor repeated
+	require.NoError(t, g.Generate(schema))
+	again, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*_create_outbox.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, migrations, again)
+	rewritten, err := os.ReadFile(migrations[0])
+	require.NoError(t, err)
+	assert.Equal(t, content, rewritten)
+}
@@ -82,6 +82,7 @@ func NewParallelGenerator(cfg *config.Config, maxWorkers int) *ParallelGenerator
 
 // GenerateParallel generates code using parallel workers
 func (pg *ParallelGenerator) GenerateParallel(schema *introspector.Schema) error {
+	schema = pg.repositoryTables(schema)
 	slog.Info("Starting parallel code generation", "workers", pg.maxWorkers, "tables", len(schema.Tables))
 
 	// Create output directory structure first
@@ -89,6 +90,11 @@ func (pg *ParallelGenerator) GenerateParallel(schema *introspector.Schema) error
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
+	// Files spanning the tables are generated once up front
+	if err := pg.generateSchemaFiles(schema); err != nil {
+		return err
+	}
+
 	// Start result collector
 	go pg.collectResults()
 
@@ -3,6 +3,8 @@ package generator
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -236,3 +238,45 @@ func BenchmarkParallelGenerator_WorkerCountComparison(b *testing.B) {
 		})
 	}
 }
+
+func TestParallelGenerator_GenerateParallel_SchemaFiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{Handlers: filepath.Join(dir, "handlers"), OpenAPI: filepath.Join(dir, "api")},
+		Outbox:     config.OutboxConfig{Enabled: true, Aggregates: []string{"orders"}},
+		Notify:     config.NotifyConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+
+	outbox := introspector.Table{Name: cfg.Outbox.Table, Columns: []introspector.Column{
+		{Name: "id", Type: "bigint", GoType: "int64", IsPrimaryKey: true},
+		{Name: "payload", Type: "jsonb", GoType: "json.RawMessage"},
+	}}
+	schema := &introspector.Schema{Tables: []introspector.Table{testTable("orders"), outbox}}
+
+	pg := NewParallelGenerator(cfg, 2)
+	defer pg.Cleanup()
+	require.NoError(t, pg.GenerateParallel(schema))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "tx_manager.go"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\tOutbox "), "the outbox table gets no repository of its own")
+
+	_, err = os.Stat(filepath.Join(cfg.GetModelsDir(), cfg.Outbox.Table+".go"))
+	assert.True(t, os.IsNotExist(err))
+
+	for _, path := range []string{
+		filepath.Join(cfg.GetReposDir(), "outbox.go"),
+		filepath.Join(cfg.GetReposDir(), "notify.go"),
+		filepath.Join(cfg.GetHandlersDir(), "orders_handler.go"),
+		filepath.Join(cfg.GetOpenAPIDir(), "openapi.yaml"),
+	} {
+		_, err := os.Stat(path)
+		assert.NoError(t, err, path)
+	}
+
+	migrations, err := filepath.Glob(filepath.Join(cfg.GetBaseDir(), "migrations", "*.sql"))
+	require.NoError(t, err)
+	assert.Len(t, migrations, 2, "the outbox and notify migrations")
+}
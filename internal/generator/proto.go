@@ -0,0 +1,293 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// protoType describes how a Go type of the models is carried by a proto field
+type protoType struct {
+	Proto     string // Proto type
+	ToProto   string // Conversion of the model field, %s being the field
+	FromProto string // Conversion of the message field, %s being the field
+	Fallible  bool   // FromProto also returns an error
+	Helpers   string // Groups of the converter helpers used, see protoSupportData
+	Import    string // Import of the converters using ToProto directly
+}
+
+// protoTypes maps the Go types of the models to their proto fields. Nullable columns
+// use the wrapper types, or a nil Timestamp.
+var protoTypes = map[string]protoType{
+	"string":          {Proto: "string", ToProto: "%s", FromProto: "%s"},
+	"int":             {Proto: "int64", ToProto: "int64(%s)", FromProto: "int(%s)"},
+	"int64":           {Proto: "int64", ToProto: "%s", FromProto: "%s"},
+	"int16":           {Proto: "int32", ToProto: "int32(%s)", FromProto: "int16(%s)"},
+	"int32":           {Proto: "int32", ToProto: "%s", FromProto: "%s"},
+	"uint32":          {Proto: "uint32", ToProto: "%s", FromProto: "%s"},
+	"float32":         {Proto: "float", ToProto: "%s", FromProto: "%s"},
+	"float64":         {Proto: "double", ToProto: "%s", FromProto: "%s"},
+	"bool":            {Proto: "bool", ToProto: "%s", FromProto: "%s"},
+	"[]byte":          {Proto: "bytes", ToProto: "%s", FromProto: "%s"},
+	"time.Time":       {Proto: "google.protobuf.Timestamp", ToProto: "timestamppb.New(%s)", FromProto: "timeFromProto(%s)", Helpers: "time", Import: "google.golang.org/protobuf/types/known/timestamppb"},
+	"uuid.UUID":       {Proto: "string", ToProto: "%s.String()", FromProto: "parseUUID(%s)", Fallible: true, Helpers: "uuid"},
+	"decimal.Decimal": {Proto: "string", ToProto: "%s.String()", FromProto: "parseDecimal(%s)", Fallible: true, Helpers: "decimal"},
+	"json.RawMessage": {Proto: "bytes", ToProto: "[]byte(%s)", FromProto: "rawJSON(%s)", Helpers: "json"},
+
+	"*string":          {Proto: "google.protobuf.StringValue", ToProto: "stringValue(%s)", FromProto: "stringPtr(%s)", Helpers: "wrappers"},
+	"*int":             {Proto: "google.protobuf.Int64Value", ToProto: "int64Value(%s)", FromProto: "int64Ptr[int](%s)", Helpers: "wrappers"},
+	"*int64":           {Proto: "google.protobuf.Int64Value", ToProto: "int64Value(%s)", FromProto: "int64Ptr[int64](%s)", Helpers: "wrappers"},
+	"*int16":           {Proto: "google.protobuf.Int32Value", ToProto: "int32Value(%s)", FromProto: "int32Ptr[int16](%s)", Helpers: "wrappers"},
+	"*float32":         {Proto: "google.protobuf.FloatValue", ToProto: "floatValue(%s)", FromProto: "floatPtr(%s)", Helpers: "wrappers"},
+	"*float64":         {Proto: "google.protobuf.DoubleValue", ToProto: "doubleValue(%s)", FromProto: "doublePtr(%s)", Helpers: "wrappers"},
+	"*bool":            {Proto: "google.protobuf.BoolValue", ToProto: "boolValue(%s)", FromProto: "boolPtr(%s)", Helpers: "wrappers"},
+	"*[]byte":          {Proto: "google.protobuf.BytesValue", ToProto: "bytesValue(%s)", FromProto: "bytesPtr(%s)", Helpers: "wrappers"},
+	"*time.Time":       {Proto: "google.protobuf.Timestamp", ToProto: "timestampValue(%s)", FromProto: "timePtr(%s)", Helpers: "time"},
+	"*uuid.UUID":       {Proto: "google.protobuf.StringValue", ToProto: "uuidValue(%s)", FromProto: "uuidPtr(%s)", Fallible: true, Helpers: "uuid wrappers"},
+	"*decimal.Decimal": {Proto: "google.protobuf.StringValue", ToProto: "decimalValue(%s)", FromProto: "decimalPtr(%s)", Fallible: true, Helpers: "decimal wrappers"},
+	"*json.RawMessage": {Proto: "google.protobuf.BytesValue", ToProto: "bytesValue(%s)", FromProto: "rawJSONPtr(%s)", Helpers: "json wrappers"},
+}
+
+// ProtoField describes a column as a field of the proto message of its table
+type ProtoField struct {
+	Column    string // Column name, also the proto field name
+	Number    int    // Field number
+	Type      string // Proto type
+	Field     string // Model field name
+	GoName    string // Message field name in the code generated by protoc-gen-go
+	ToProto   string // Go expression of the message field
+	FromProto string // Go expression of the model field
+	Fallible  bool   // FromProto also returns an error
+}
+
+// protoTemplateData holds the data of the proto templates of a table
+type protoTemplateData struct {
+	repositoryTemplateData
+	ProtoPackage string
+	GoPackage    string                // go_package option
+	Fields       []ProtoField          // Fields of the message, one per mapped column
+	Skipped      []introspector.Column // Columns without proto type
+	PrimaryKey   *ProtoField           // Nil when the table has no mapped primary key
+	Imports      []string              // Proto files imported
+	GoImports    []string              // Go packages imported by the converters
+	Fallible     bool                  // A field conversion from the message can fail
+}
+
+// VersionProto returns the proto type of the version column, empty without version
+func (d protoTemplateData) VersionProto() string {
+	if d.Version == nil {
+		return ""
+	}
+	return protoTypes[d.Version.GoType].Proto
+}
+
+// MessageField returns the name of the fields holding a message in the requests
+func (d protoTemplateData) MessageField() string {
+	return toSnakeCase(d.StructName)
+}
+
+// newProtoTemplateData builds the template data of the proto file and converters of a table
+func (g *Generator) newProtoTemplateData(table introspector.Table, pkg string) protoTemplateData {
+	data := protoTemplateData{
+		repositoryTemplateData: g.newRepositoryTemplateData(table, pkg),
+		ProtoPackage:           g.config.Proto.Package,
+		GoPackage:              g.config.Proto.GoPackage,
+	}
+
+	imports := map[string]bool{"google/protobuf/empty.proto": true}
+	for _, col := range protoFieldNumbers(data.Table.Columns) {
+		mapping, ok := protoTypes[col.GoType]
+		if !ok {
+			data.Skipped = append(data.Skipped, col.Column)
+			continue
+		}
+
+		field := ProtoField{
+			Column:    col.Name,
+			Number:    col.Number,
+			Type:      mapping.Proto,
+			Field:     toPascalCase(col.Name),
+			GoName:    protoGoName(col.Name),
+			Fallible:  mapping.Fallible,
+			ToProto:   fmt.Sprintf(mapping.ToProto, "m."+toPascalCase(col.Name)),
+			FromProto: fmt.Sprintf(mapping.FromProto, "p."+protoGoName(col.Name)),
+		}
+		data.Fields = append(data.Fields, field)
+		data.Fallible = data.Fallible || field.Fallible
+		if col.IsPrimaryKey && data.PrimaryKey == nil {
+			data.PrimaryKey = &field
+		}
+		if mapping.Import != "" && !slices.Contains(data.GoImports, mapping.Import) {
+			data.GoImports = append(data.GoImports, mapping.Import)
+		}
+
+		switch {
+		case strings.HasPrefix(mapping.Proto, "google.protobuf.Timestamp"):
+			imports["google/protobuf/timestamp.proto"] = true
+		case strings.HasPrefix(mapping.Proto, "google.protobuf."):
+			imports["google/protobuf/wrappers.proto"] = true
+		}
+	}
+
+	for _, name := range []string{"google/protobuf/empty.proto", "google/protobuf/timestamp.proto", "google/protobuf/wrappers.proto"} {
+		if imports[name] {
+			data.Imports = append(data.Imports, name)
+		}
+	}
+	return data
+}
+
+// numberedColumn is a column with the number of its proto field
+type numberedColumn struct {
+	introspector.Column
+	Number int
+}
+
+// xminFieldNumber is the proto field number of the xmin system column. Column positions
+// never exceed 1600, the column limit of PostgreSQL tables, and 2047 is the largest
+// number still encoded in a two byte tag.
+const xminFieldNumber = 2047
+
+// protoFieldNumbers numbers the columns of a table by their position, so that adding or
+// dropping columns keeps the numbers of the others. The xmin system column has no position
+// and gets a number of its own, other columns without one are numbered after the last one.
+func protoFieldNumbers(columns []introspector.Column) []numberedColumn {
+	last := 0
+	for _, col := range columns {
+		last = max(last, col.Position)
+	}
+
+	numbered := make([]numberedColumn, len(columns))
+	for i, col := range columns {
+		numbered[i] = numberedColumn{Column: col, Number: col.Position}
+		if col.Name == xminColumn {
+			numbered[i].Number = xminFieldNumber
+		} else if col.Position <= 0 {
+			last++
+			numbered[i].Number = last
+		}
+	}
+	sort.SliceStable(numbered, func(i, j int) bool { return numbered[i].Number < numbered[j].Number })
+	return numbered
+}
+
+// protoGoName returns the name protoc-gen-go gives to the Go field of a proto field
+func protoGoName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_' && i == 0:
+			b.WriteByte('X')
+		case c == '_' && i+1 < len(name) && isASCIILower(name[i+1]):
+			// Skip the underscore, the next letter is capitalized
+		case c >= '0' && c <= '9':
+			b.WriteByte(c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b.WriteByte(c)
+			for ; i+1 < len(name) && isASCIILower(name[i+1]); i++ {
+				b.WriteByte(name[i+1])
+			}
+		}
+	}
+
+	// protoc-gen-go renames the fields conflicting with the methods of the messages
+	switch goName := b.String(); goName {
+	case "Reset", "String", "ProtoMessage", "ProtoReflect", "Descriptor":
+		return goName + "_"
+	default:
+		return goName
+	}
+}
+
+// isASCIILower reports whether c is a lowercase ASCII letter
+func isASCIILower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// protoSupportData holds the data of the converter helpers, the groups of helpers
+// ("wrappers", "time", "uuid", "decimal" and "json") being only generated when used
+type protoSupportData struct {
+	Package string
+	Helpers map[string]bool
+}
+
+// Imports returns the imports of the helpers, an empty string separating the standard
+// library from the other packages
+func (d protoSupportData) Imports() []string {
+	var std, other []string
+	if d.Helpers["json"] {
+		std = append(std, "encoding/json")
+	}
+	if d.Helpers["time"] {
+		std = append(std, "time")
+		other = append(other, "google.golang.org/protobuf/types/known/timestamppb")
+	}
+	if d.Helpers["uuid"] {
+		other = append(other, "github.com/google/uuid")
+	}
+	if d.Helpers["decimal"] {
+		other = append(other, "github.com/shopspring/decimal")
+	}
+	if d.Helpers["wrappers"] {
+		other = append(other, "google.golang.org/protobuf/types/known/wrapperspb")
+	}
+	sort.Strings(other)
+
+	if len(std) > 0 && len(other) > 0 {
+		std = append(std, "")
+	}
+	return append(std, other...)
+}
+
+// generateProto generates the proto definitions of the tables and the Go converters
+// between the models and the proto messages
+func (g *Generator) generateProto(schema *introspector.Schema) error {
+	slog.Info("Generating protobuf definitions...")
+
+	protoTmpl, err := g.getTemplate("proto.tmpl")
+	if err != nil {
+		return err
+	}
+	convertTmpl, err := g.getTemplate("proto_convert.tmpl")
+	if err != nil {
+		return err
+	}
+
+	pkg := g.config.GetProtoGoPackageName()
+	support := protoSupportData{Package: pkg, Helpers: make(map[string]bool)}
+	for _, table := range schema.Tables {
+		data := g.newProtoTemplateData(table, pkg)
+		name := toSnakeCase(table.Name)
+
+		if err := g.writeTemplate(protoTmpl, filepath.Join(g.config.GetProtoDir(), name+".proto"), data); err != nil {
+			return fmt.Errorf("failed to generate %s proto: %w", table.Name, err)
+		}
+		if err := g.writeTemplate(convertTmpl, filepath.Join(g.config.GetProtoDir(), name+"_convert.go"), data); err != nil {
+			return fmt.Errorf("failed to generate %s proto converters: %w", table.Name, err)
+		}
+
+		for _, col := range data.Table.Columns {
+			for _, helper := range strings.Fields(protoTypes[col.GoType].Helpers) {
+				support.Helpers[helper] = true
+			}
+		}
+	}
+
+	tmpl, err := g.getTemplate("proto_support.tmpl")
+	if err != nil {
+		return err
+	}
+	if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetProtoDir(), "convert.go"), support); err != nil {
+		return fmt.Errorf("failed to generate convert.go: %w", err)
+	}
+	return nil
+}
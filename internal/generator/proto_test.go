@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewProtoTemplateData(t *testing.T) {
+	cfg := &config.Config{OptimisticLocking: config.OptimisticLockingConfig{Enabled: true, Column: "xmin"}}
+	cfg.ApplyDefaults()
+	data := New(cfg).newProtoTemplateData(testTable("orders"), "pb")
+
+	var numbers []int
+	for _, field := range data.Fields {
+		numbers = append(numbers, field.Number)
+	}
+	assert.Equal(t, []int{1, 2, 6, 7, 8, 9, 10, 11, 12, 13, xminFieldNumber}, numbers, "fields are numbered by position, the xmin version by its own number")
+
+	var skipped []string
+	for _, col := range data.Skipped {
+		skipped = append(skipped, col.Name)
+	}
+	assert.Equal(t, []string{"status", "refund"}, skipped, "enums have no proto type")
+
+	require.NotNil(t, data.PrimaryKey)
+	assert.Equal(t, "string", data.PrimaryKey.Type)
+	assert.Equal(t, "uint32", data.VersionProto())
+	assert.True(t, data.Fallible)
+	assert.Equal(t, "orders", data.MessageField())
+	assert.Equal(t, "int64(m.Items)", data.Fields[2].ToProto)
+	assert.Equal(t, "int(p.Items)", data.Fields[2].FromProto)
+	assert.Equal(t, "google.protobuf.StringValue", data.Fields[4].Type)
+	assert.Equal(t, "google.protobuf.Timestamp", data.Fields[6].Type)
+	assert.Equal(t, "timestampValue(m.PaidAt)", data.Fields[6].ToProto)
+	assert.Equal(t, []string{
+		"google/protobuf/empty.proto",
+		"google/protobuf/timestamp.proto",
+		"google/protobuf/wrappers.proto",
+	}, data.Imports)
+	assert.Equal(t, []string{"google.golang.org/protobuf/types/known/timestamppb"}, data.GoImports,
+		"the times that are not nullable are converted in place, the nullable ones by the helpers")
+}
+
+func TestProtoFieldNumbers_StableXmin(t *testing.T) {
+	numbers := func(table introspector.Table) map[string]int {
+		byName := make(map[string]int)
+		for _, col := range protoFieldNumbers(withSystemVersion(table, xminColumn).Columns) {
+			byName[col.Name] = col.Number
+		}
+		return byName
+	}
+
+	table := testTable("orders")
+	before := numbers(table)
+
+	table.Columns = append(table.Columns, introspector.Column{Name: "shipped_at", GoType: "time.Time", Position: 14})
+	after := numbers(table)
+	assert.Equal(t, 14, after["shipped_at"])
+	assert.Equal(t, before["xmin"], after["xmin"], "adding a column keeps the number of xmin")
+
+	table.Columns = table.Columns[:len(table.Columns)-2]
+	for name, number := range numbers(table) {
+		assert.Equal(t, before[name], number, "dropping the last columns keeps the number of %s", name)
+	}
+}
+
+func TestProtoGoName(t *testing.T) {
+	tests := map[string]string{
+		"id":          "Id",
+		"customer_id": "CustomerId",
+		"address_2":   "Address_2",
+		"_hidden":     "XHidden",
+		"ABC_def":     "ABCDef",
+		"string":      "String_",
+		"descriptor":  "Descriptor_",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, protoGoName(name), name)
+	}
+}
+
+func TestProtoSupportData_Imports(t *testing.T) {
+	assert.Empty(t, protoSupportData{}.Imports())
+	assert.Equal(t, []string{
+		"time",
+		"",
+		"github.com/shopspring/decimal",
+		"google.golang.org/protobuf/types/known/timestamppb",
+	}, protoSupportData{Helpers: map[string]bool{"time": true, "decimal": true}}.Imports())
+}
+
+func TestGenerator_GenerateProto(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{Proto: filepath.Join(dir, "proto")},
+		Proto:      config.ProtoConfig{Package: "billing.v1", GoPackage: "example.com/billing/pb;billingpb"},
+	}
+	cfg.ApplyDefaults()
+
+	require.NoError(t, New(cfg).Generate(&introspector.Schema{Tables: []introspector.Table{testTable("orders")}}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetProtoDir(), "orders.proto"))
+	require.NoError(t, err)
+	blocks := schemaBlocks(string(content))
+	assert.Equal(t, []string{
+		`syntax = "proto3";`,
+		"package billing.v1;",
+		`import "google/protobuf/empty.proto";`,
+		`import "google/protobuf/timestamp.proto";`,
+		`import "google/protobuf/wrappers.proto";`,
+		`option go_package = "example.com/billing/pb;billingpb";`,
+	}, blocks[""])
+	assert.Equal(t, []string{
+		"string id = 1;",
+		"int64 number = 2;",
+		"int64 items = 6;",
+		"string total = 7;",
+		"google.protobuf.StringValue note = 8;",
+		"google.protobuf.Timestamp placed_at = 9;",
+		"google.protobuf.Timestamp paid_at = 10;",
+		"google.protobuf.Timestamp due_on = 11;",
+		"bytes receipt = 12;",
+		"bytes ship_to = 13;",
+		"// status (order_status) has no proto type",
+		"// refund (order_refund) has no proto type",
+	}, blocks["message Orders"])
+	assert.Contains(t, blocks["service OrdersService"], "rpc DeleteOrders(DeleteOrdersRequest) returns (google.protobuf.Empty);")
+	assert.Equal(t, []string{"repeated Orders items = 1;"}, blocks["message ListOrdersResponse"])
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetProtoDir(), "orders_convert.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, "billingpb", src.file.Name.Name)
+	assert.Equal(t, "func(m *models.Orders) *Orders", src.Signature("OrdersToProto"))
+	assert.Equal(t, []string{"m.PaidAt"}, src.Calls("OrdersToProto", "timestampValue"))
+	assert.Equal(t, []string{"m.Items"}, src.Calls("OrdersToProto", "int64"))
+	assert.Equal(t, []string{"p.Total"}, src.Calls("OrdersFromProto", "parseDecimal"))
+	assert.Equal(t, []string{"p.ShipTo"}, src.Calls("OrdersFromProto", "rawJSON"))
+	assert.False(t, src.Uses("OrdersToProto", "Status"), "skipped columns are not converted")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetProtoDir(), "convert.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Equal(t, "func(s string) (uuid.UUID, error)", src.Signature("parseUUID"))
+	assert.Equal(t, "func(s string) (decimal.Decimal, error)", src.Signature("parseDecimal"))
+	assert.Equal(t, "func(b []byte) json.RawMessage", src.Signature("rawJSON"))
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// SoftDelete describes the column marking the soft deleted rows of a table
+type SoftDelete struct {
+	Column string // Column name
+	Field  string // Model field name
+	Flag   bool   // Boolean flag rather than a nullable deletion timestamp
+}
+
+// Condition returns the SQL condition matching rows that are not deleted
+func (s SoftDelete) Condition() string {
+	if s.Flag {
+		return s.Column + " IS NOT TRUE"
+	}
+	return s.Column + " IS NULL"
+}
+
+// DeletedCondition returns the SQL condition matching deleted rows
+func (s SoftDelete) DeletedCondition() string {
+	if s.Flag {
+		return s.Column + " IS TRUE"
+	}
+	return s.Column + " IS NOT NULL"
+}
+
+// MarkDeleted returns the SET assignment soft deleting a row
+func (s SoftDelete) MarkDeleted() string {
+	if s.Flag {
+		return s.Column + " = TRUE"
+	}
+	return s.Column + " = now()"
+}
+
+// MarkRestored returns the SET assignment restoring a soft deleted row
+func (s SoftDelete) MarkRestored() string {
+	if s.Flag {
+		return s.Column + " = FALSE"
+	}
+	return s.Column + " = NULL"
+}
+
+// detectSoftDelete returns the soft delete column of a table: the first of the candidate
+// columns that is either a nullable timestamp or a boolean. It returns nil when the
+// table has none.
+func detectSoftDelete(table introspector.Table, candidates []string) *SoftDelete {
+	for _, candidate := range candidates {
+		for _, col := range table.Columns {
+			if !strings.EqualFold(col.Name, candidate) || col.IsPrimaryKey || col.IsGenerated {
+				continue
+			}
+
+			switch col.GoType {
+			case "*time.Time":
+				return &SoftDelete{Column: col.Name, Field: toPascalCase(col.Name)}
+			case "bool", "*bool":
+				return &SoftDelete{Column: col.Name, Field: toPascalCase(col.Name), Flag: true}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestDetectSoftDelete(t *testing.T) {
+	candidates := []string{"deleted_at", "is_deleted"}
+
+	t.Run("timestamp", func(t *testing.T) {
+		table := introspector.Table{Name: "users", Columns: []introspector.Column{
+			{Name: "id", GoType: "int64", IsPrimaryKey: true},
+			{Name: "is_deleted", GoType: "bool"},
+			{Name: "deleted_at", GoType: "*time.Time", IsNullable: true},
+		}}
+
+		sd := detectSoftDelete(table, candidates)
+		require.NotNil(t, sd)
+		assert.Equal(t, "deleted_at", sd.Column, "candidates are tried in order")
+		assert.Equal(t, "DeletedAt", sd.Field)
+		assert.False(t, sd.Flag)
+		assert.Equal(t, "deleted_at IS NULL", sd.Condition())
+		assert.Equal(t, "deleted_at IS NOT NULL", sd.DeletedCondition())
+		assert.Equal(t, "deleted_at = now()", sd.MarkDeleted())
+		assert.Equal(t, "deleted_at = NULL", sd.MarkRestored())
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		table := introspector.Table{Name: "posts", Columns: []introspector.Column{
+			{Name: "id", GoType: "int64", IsPrimaryKey: true},
+			{Name: "deleted_at", GoType: "string"},
+			{Name: "is_deleted", GoType: "bool"},
+		}}
+
+		sd := detectSoftDelete(table, candidates)
+		require.NotNil(t, sd)
+		assert.Equal(t, "is_deleted", sd.Column, "columns of another type are ignored")
+		assert.True(t, sd.Flag)
+		assert.Equal(t, "is_deleted IS NOT TRUE", sd.Condition())
+		assert.Equal(t, "is_deleted IS TRUE", sd.DeletedCondition())
+		assert.Equal(t, "is_deleted = TRUE", sd.MarkDeleted())
+		assert.Equal(t, "is_deleted = FALSE", sd.MarkRestored())
+	})
+
+	t.Run("none", func(t *testing.T) {
+		assert.Nil(t, detectSoftDelete(testTable("posts"), candidates))
+		assert.Nil(t, detectSoftDelete(introspector.Table{Columns: []introspector.Column{
+			{Name: "deleted_at", GoType: "*time.Time", IsGenerated: true},
+		}}, candidates))
+	})
+}
@@ -1,12 +1,15 @@
 package generator
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fsvxavier/pgx-goose/internal/config"
 	"github.com/fsvxavier/pgx-goose/internal/introspector"
 )
 
@@ -24,16 +27,7 @@ func TestRepositoryPostgresTemplate_SQLGeneration(t *testing.T) {
 	}
 
 	// Test data for template (matching the actual structure used in generator.go)
-	data := struct {
-		Table           introspector.Table
-		StructName      string
-		InterfaceName   string
-		ImplName        string
-		Package         string
-		PrimaryKeyType  string
-		PrimaryKeyCol   string
-		PrimaryKeyField string
-	}{
+	data := repositoryTemplateData{
 		Table:           table,
 		StructName:      "User",
 		InterfaceName:   "UserRepository",
@@ -42,6 +36,7 @@ func TestRepositoryPostgresTemplate_SQLGeneration(t *testing.T) {
 		PrimaryKeyType:  "int",
 		PrimaryKeyCol:   "id",
 		PrimaryKeyField: "ID",
+		UpdateColumns:   table.Columns[1:],
 	}
 
 	// Get template
@@ -150,16 +145,7 @@ func TestRepositoryPostgresTemplate_NoLeadingCommas(t *testing.T) {
 		PrimaryKeys: []string{"id"},
 	}
 
-	data := struct {
-		Table           introspector.Table
-		StructName      string
-		InterfaceName   string
-		ImplName        string
-		Package         string
-		PrimaryKeyType  string
-		PrimaryKeyCol   string
-		PrimaryKeyField string
-	}{
+	data := repositoryTemplateData{
 		Table:           table,
 		StructName:      "Simple",
 		InterfaceName:   "SimpleRepository",
@@ -218,3 +204,726 @@ func TestRepositoryPostgresTemplate_NoLeadingCommas(t *testing.T) {
 	updateSQL := strings.Join(updateLines, "\n")
 	assert.NotContains(t, updateSQL, "SET, name =") // leading comma in update set
 }
+
+func TestRepositoryTemplates_Finders(t *testing.T) {
+	g := &Generator{}
+	data := func(pkg string) repositoryTemplateData {
+		return g.newRepositoryTemplateData(testTable("posts"), pkg)
+	}
+
+	t.Run("interface", func(t *testing.T) {
+		src := renderGoSource(t, g, "repository_interface.tmpl", data("interfaces"))
+
+		assert.Contains(t, src.Imports(), "time")
+		assert.Equal(t, "func(ctx context.Context, email string) (*models.Posts, error)", src.Signature("PostsRepository.GetByEmail"))
+		assert.Equal(t, "func(ctx context.Context, email string) (bool, error)", src.Signature("PostsRepository.ExistsByEmail"))
+		assert.Equal(t, "func(ctx context.Context, userId int, slug string) (*models.Posts, error)", src.Signature("PostsRepository.GetByUserIdAndSlug"))
+		assert.Equal(t, "func(ctx context.Context, userId int, limit, offset int) ([]*models.Posts, error)", src.Signature("PostsRepository.ListByUserId"))
+		assert.Equal(t, "func(ctx context.Context, publishedAt *time.Time, limit, offset int) ([]*models.Posts, error)", src.Signature("PostsRepository.ListByPublishedAt"))
+		assert.False(t, src.Has("PostsRepository.ListByEmail"), "unique columns only get a GetBy")
+		assert.False(t, src.Has("PostsRepository.GetById"), "the primary key index is left to GetByID")
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		src := renderGoSource(t, g, "repository_postgres.tmpl", data("postgres"))
+		columns := "id, user_id, slug, type, email, payload, published_at"
+
+		assert.Equal(t, "func(ctx context.Context, userId int, slug string) (*models.Posts, error)", src.Signature("PostsRepository.GetByUserIdAndSlug"))
+		assert.Contains(t, src.Strings("PostsRepository.GetByUserIdAndSlug"), "SELECT "+columns+" FROM posts WHERE user_id = $1 AND slug = $2")
+		assert.Equal(t, []string{"ctx, query, userId, slug"}, src.Calls("PostsRepository.GetByUserIdAndSlug", "QueryRow"))
+		assert.Contains(t, src.Calls("PostsRepository.GetByUserIdAndSlug", "Errorf"), `"posts with user_id %v and slug %v: %w", userId, slug, ErrNotFound`)
+		assert.Contains(t, src.Strings("PostsRepository.ExistsByEmail"), "SELECT EXISTS(SELECT 1 FROM posts WHERE email = $1)")
+		assert.Contains(t, src.Strings("PostsRepository.ListByType"), "SELECT "+columns+" FROM posts WHERE type = $1 ORDER BY id LIMIT $2 OFFSET $3")
+		assert.Equal(t, []string{"ctx, query, typeValue, limit, offset"}, src.Calls("PostsRepository.ListByType", "Query"))
+
+		// Nullable columns are matched with IS NULL when their argument is nil
+		assert.Contains(t, src.Doc("PostsRepository.ListByPublishedAt"), "\nA nil publishedAt matches the Postss whose published_at is NULL.\n")
+		assert.Equal(t, []string{`"published_at = %s", *publishedAt`}, src.Calls("PostsRepository.ListByPublishedAt", "add"))
+		assert.Equal(t, []string{"ListByPublishedAt", "published_at IS NULL", "published_at = %s", "SELECT " + columns + " FROM posts", "ORDER BY id", "LIMIT", "OFFSET"},
+			src.Strings("PostsRepository.ListByPublishedAt"))
+		assert.Equal(t, []string{"limit", "offset"}, src.Calls("PostsRepository.ListByPublishedAt", "arg"))
+	})
+
+	t.Run("testify mock", func(t *testing.T) {
+		src := renderGoSource(t, g, "mock_testify.tmpl", data("mocks"))
+
+		assert.Equal(t, "func(ctx context.Context, email string) (*models.Posts, error)", src.Signature("MockPostsRepository.GetByEmail"))
+		assert.Equal(t, []string{"ctx, userId, slug"}, src.Calls("MockPostsRepository.GetByUserIdAndSlug", "Called"))
+		assert.Equal(t, []string{"0"}, src.Calls("MockPostsRepository.ExistsByEmail", "Bool"))
+		assert.Equal(t, []string{"ctx, userId, limit, offset"}, src.Calls("MockPostsRepository.ListByUserId", "Called"))
+	})
+
+	t.Run("gomock mock", func(t *testing.T) {
+		src := renderGoSource(t, g, "mock_gomock.tmpl", data("mocks"))
+
+		assert.Equal(t, []string{`m, "GetByUserIdAndSlug", ctx, userId, slug`}, src.Calls("MockPostsRepository.GetByUserIdAndSlug", "Call"))
+		assert.Equal(t, "func(ctx, userId, slug interface{}) *gomock.Call", src.Signature("MockPostsRepositoryMockRecorder.GetByUserIdAndSlug"))
+		assert.Equal(t, "func(ctx, typeValue, limit, offset interface{}) *gomock.Call", src.Signature("MockPostsRepositoryMockRecorder.ListByType"))
+	})
+
+	t.Run("tests", func(t *testing.T) {
+		src := renderGoSource(t, g, "test.tmpl", data("tests"))
+
+		for _, name := range []string{"GetByEmail", "ExistsByUserIdAndSlug", "ListByPublishedAt"} {
+			assert.Equal(t, "func(t *testing.T)", src.Signature("TestPostsRepository_"+name), name)
+		}
+		assert.Contains(t, src.Vars("TestPostsRepository_ListByPublishedAt"), "publishedAt *time.Time")
+	})
+}
+
+func TestRepositoryPostgresTemplate_Querier(t *testing.T) {
+	g := &Generator{}
+	src := renderGoSource(t, g, "repository_postgres.tmpl", g.newRepositoryTemplateData(testTable("posts"), "postgres"))
+
+	assert.Contains(t, src.Fields("PostsRepository"), "db DBTX")
+	assert.Equal(t, "func(db DBTX, opts ...Option) interfaces.PostsRepository", src.Signature("NewPostsRepository"))
+	assert.Equal(t, "func(tx pgx.Tx, opts ...Option) interfaces.PostsRepository", src.Signature("NewPostsRepositoryWithTx"))
+	assert.Equal(t, "func(tx pgx.Tx) interfaces.PostsRepository", src.Signature("PostsRepository.WithTx"))
+	assert.Equal(t, []string{`db, o.hooks, "posts"`}, src.Calls("NewPostsRepository", "withHooks"))
+	assert.Equal(t, []string{`tx, o.hooks, "posts"`}, src.Calls("NewPostsRepositoryWithTx", "withHooks"))
+	assert.Equal(t, []string{`tx, r.hooks, "posts"`}, src.Calls("PostsRepository.WithTx", "withHooks"))
+	assert.Equal(t, []string{`ctx, r.hooks, "GetByID"`}, src.Calls("PostsRepository.GetByID", "withOperation"))
+	assert.Equal(t, []string{`o.replicas, o.hooks, "posts"`}, src.Calls("NewPostsRepository", "newReplicaSet"))
+	assert.Equal(t, []string{"nil"}, src.Assigns("PostsRepository.WithTx", "clone.replicas"), "a transaction reads from its own connection")
+
+	// Reads may go to a replica, writes always go to the primary
+	assert.Equal(t, []string{"ctx, query, id"}, src.Calls("PostsRepository.GetByID", "QueryRow"))
+	assert.Equal(t, []string{"ctx"}, src.Calls("PostsRepository.GetByID", "reader"))
+	assert.Equal(t, []string{"ctx, query, w.args"}, src.Calls("PostsRepository.Find", "Query"))
+	assert.Equal(t, []string{"ctx"}, src.Calls("PostsRepository.Find", "reader"))
+	assert.Equal(t, []string{"ctx, query, id"}, src.Calls("PostsRepository.Delete", "Exec"))
+	assert.False(t, src.Uses("PostsRepository.Delete", "reader"))
+	assert.NotContains(t, src.Imports(), "github.com/jackc/pgx/v5/pgxpool")
+}
+
+func TestRepositorySupportTemplates(t *testing.T) {
+	gen := &Generator{}
+	data := struct {
+		Package string
+		Tables  []repositoryTemplateData
+		Outbox  string
+		Tenancy string
+	}{
+		Package: "postgres",
+		Tables: []repositoryTemplateData{
+			gen.newRepositoryTemplateData(introspector.Table{Name: "users"}, "postgres"),
+			gen.newRepositoryTemplateData(testTable("posts"), "postgres"),
+		},
+	}
+
+	t.Run("db", func(t *testing.T) {
+		src := renderGoSource(t, gen, "db.tmpl", data)
+
+		assert.Equal(t, "func(ctx context.Context, b *pgx.Batch) pgx.BatchResults", src.Signature("DBTX.SendBatch"))
+		assert.Equal(t, "func(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)", src.Signature("DBTX.CopyFrom"))
+		assert.Equal(t, []string{"*pgxpool.Pool", "*pgx.Conn", "pgx.Tx"}, src.Implements("DBTX"))
+	})
+
+	t.Run("tx manager", func(t *testing.T) {
+		src := renderGoSource(t, gen, "tx_manager.tmpl", data)
+
+		assert.Equal(t, []string{"Users interfaces.UsersRepository", "Posts interfaces.PostsRepository"}, src.Fields("Repositories"))
+		assert.Equal(t, []string{"db, opts"}, src.Calls("NewRepositories", "NewPostsRepository"))
+		assert.Equal(t, "func(ctx context.Context, fn func(repos *Repositories) error) error", src.Signature("TxManager.RunInTx"))
+		assert.Equal(t, []string{"NewRepositories(tx, m.options...)"}, src.Calls("TxManager.RunInTxWithOptions", "fn"))
+		assert.Equal(t, "func(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context, repos *Repositories) error) error", src.Signature("TxManager.RunInTxWithRetry"))
+		assert.Equal(t, []string{"context.WithValue(ctx, txAttemptKey, attempt)"}, src.Assigns("TxManager.RunInTxWithRetry", "attemptCtx"))
+		assert.Equal(t, "func(ctx context.Context) int", src.Signature("TxAttempt"))
+		assert.Equal(t, []string{"slices.Clip(opts), WithReplicas()"}, src.Calls("NewTxManager", "append"))
+		assert.Equal(t, []string{"40001", "40P01"}, src.Strings("isRetryable"))
+		assert.False(t, src.Uses("NewRepositories", "NewOutbox"), "there is no outbox table")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		src := renderGoSource(t, gen, "errors.tmpl", data)
+
+		assert.Equal(t, []string{"not found"}, src.Strings("ErrNotFound"))
+		assert.True(t, src.Has("ErrStaleObject"))
+		assert.Equal(t, []string{"Table string", "Constraint string", "Columns []string", "Err *pgconn.PgError"}, src.Fields("ErrUniqueViolation"))
+		assert.Equal(t, []string{
+			"posts.posts_email_key", "email",
+			"posts.posts_pkey", "id",
+			"posts.posts_type_fkey", "type",
+			"posts.posts_user_id_fkey", "user_id",
+			"posts.posts_user_id_slug_key", "user_id", "slug",
+		}, src.Strings("constraintColumns"), "the columns of the unique and foreign key constraints by table and name")
+		assert.Equal(t, []string{`"%w: %w", ErrSerialization, pgErr`}, src.Calls("mapError", "Errorf"))
+		assert.Subset(t, src.Strings("mapError"), []string{"40001", "40P01"})
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		src := renderGoSource(t, gen, "hooks.tmpl", data)
+
+		assert.Equal(t, "func(ctx context.Context, op, table, sql string, args []any) context.Context", src.Signature("Hooks.BeforeQuery"))
+		assert.Equal(t, "func(ctx context.Context, op, table, sql string, args []any, duration time.Duration, err error)", src.Signature("Hooks.AfterQuery"))
+		assert.Equal(t, "func(db DBTX, hooks Hooks, table string) DBTX", src.Signature("withHooks"))
+		assert.Contains(t, src.Returns("hookedDB.Query"), "&hookedRows{Rows: rows, done: done}, nil")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		src := renderGoSource(t, gen, "options.tmpl", data)
+
+		assert.Equal(t, "func(hooks Hooks) Option", src.Signature("WithHooks"))
+		assert.Equal(t, "func(policy RetryPolicy) Option", src.Signature("WithRetryPolicy"))
+		assert.Equal(t, "func(replicas ...DBTX) Option", src.Signature("WithReplicas"))
+		assert.Equal(t, []string{"options{retry: DefaultRetryPolicy}"}, src.Assigns("newOptions", "o"))
+	})
+
+	t.Run("replicas", func(t *testing.T) {
+		src := renderGoSource(t, gen, "replicas.tmpl", data)
+
+		assert.Equal(t, "func(ctx context.Context, primary DBTX) DBTX", src.Signature("replicaSet.reader"))
+		assert.Equal(t, []string{"ctx"}, src.Calls("replicaSet.reader", "usePrimary"), "WithPrimary overrides the replicas")
+		assert.Contains(t, src.Returns("replicaSet.reader"), "primary")
+		assert.Equal(t, []string{"withHooks(replica, hooks, table)"}, src.Assigns("newReplicaSet", "s.replicas[i]"))
+	})
+
+	t.Run("hook adapters", func(t *testing.T) {
+		src := renderGoSource(t, gen, "hooks_otel.tmpl", data)
+		assert.Equal(t, "otel", src.BuildConstraint())
+		assert.Equal(t, "func(tracer trace.Tracer) *OTelHooks", src.Signature("NewOTelHooks"))
+		assert.Contains(t, src.Calls("OTelHooks.BeforeQuery", "WithSpanKind"), "trace.SpanKindClient")
+		assert.Equal(t, []string{"*OTelHooks"}, src.Implements("Hooks"))
+
+		src = renderGoSource(t, gen, "hooks_prometheus.tmpl", data)
+		assert.Equal(t, "prometheus", src.BuildConstraint())
+		assert.Equal(t, "func(registerer prometheus.Registerer) (*PrometheusHooks, error)", src.Signature("NewPrometheusHooks"))
+		assert.Subset(t, src.Strings("NewPrometheusHooks"), []string{"table", "op", "status"}, "the histogram labels")
+		assert.Equal(t, []string{"*PrometheusHooks"}, src.Implements("Hooks"))
+	})
+}
+
+func TestRepositoryTemplates_BulkInsert(t *testing.T) {
+	gen := &Generator{}
+	defaultValue := "nextval('posts_id_seq'::regclass)"
+
+	table := testTable("posts")
+	table.Columns[0].DefaultValue = &defaultValue
+	table.Columns = append(table.Columns, introspector.Column{Name: "search", GoType: "*string", IsNullable: true, IsGenerated: true})
+
+	render := func(t *testing.T, name, pkg string, table introspector.Table) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+
+	t.Run("interface", func(t *testing.T) {
+		src := render(t, "repository_interface.tmpl", "interfaces", table)
+
+		assert.Equal(t, "func(ctx context.Context, postss []*models.Posts) (int64, error)", src.Signature("PostsRepository.CreateMany"))
+		assert.Equal(t, "func(ctx context.Context, postss []*models.Posts) error", src.Signature("PostsRepository.InsertMany"))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", table)
+
+		assert.Contains(t, src.Imports(), "strings")
+		assert.Equal(t, []string{"CreateMany", "posts", "user_id", "slug", "type", "email", "payload", "published_at"}, src.Strings("PostsRepository.CreateMany"),
+			"the defaulted id and generated search columns are not copied")
+		assert.Equal(t, []string{"6"}, src.Values("PostsRepository.InsertMany", "columnsPerRow"))
+		assert.Equal(t, []string{
+			`"INSERT INTO posts (user_id, slug, type, email, payload, published_at) VALUES "`,
+			`", "`, `"("`, `", "`, `", "`, `", "`, `", "`, `", "`, `")"`,
+			`" RETURNING id, user_id, slug, type, email, payload, published_at, search"`,
+		}, src.Calls("PostsRepository.InsertMany", "WriteString"))
+		assert.Equal(t, []string{
+			"&query, &args, posts.UserId",
+			"&query, &args, posts.Slug",
+			"&query, &args, posts.Type",
+			"&query, &args, posts.Email",
+			"&query, &args, posts.Payload",
+			"&query, &args, posts.PublishedAt",
+		}, src.Calls("PostsRepository.InsertMany", "appendValue"))
+	})
+
+	t.Run("defaulted columns", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", testTable("accounts"))
+
+		assert.Equal(t, []string{"&query, &args, accounts.Plan"}, src.Calls("AccountsRepository.InsertMany", "appendValueUnlessZero"),
+			"an unset plan is left to its default like in Create")
+		assert.NotContains(t, src.Calls("AccountsRepository.InsertMany", "appendValue"), "&query, &args, accounts.Plan")
+		assert.Equal(t, "CreateMany inserts Accountss in bulk using the COPY protocol and returns the number of rows copied.\n"+
+			"Values generated by the database are not read back, use InsertMany when they are needed.\n"+
+			"Unlike Create and InsertMany, COPY writes every field as is: a zero value is stored rather\n"+
+			"than the default of plan.\n", src.Doc("AccountsRepository.CreateMany"))
+
+		query := renderGoSource(t, gen, "query.tmpl", struct{ Package, Tenancy string }{"postgres", ""})
+		assert.Equal(t, "func[T any](query *strings.Builder, args *[]any, value T)", query.Signature("appendValueUnlessZero"))
+		assert.Equal(t, []string{`"DEFAULT"`}, query.Calls("appendValueUnlessZero", "WriteString"))
+	})
+
+	t.Run("mocks", func(t *testing.T) {
+		assert.Equal(t, []string{"ctx, postss"}, render(t, "mock_testify.tmpl", "mocks", table).Calls("MockPostsRepository.CreateMany", "Called"))
+		assert.Equal(t, []string{`m, "InsertMany", ctx, postss`}, render(t, "mock_gomock.tmpl", "mocks", table).Calls("MockPostsRepository.InsertMany", "Call"))
+		assert.True(t, render(t, "test.tmpl", "tests", table).Has("TestPostsRepository_CreateMany"))
+	})
+
+	t.Run("no insertable columns", func(t *testing.T) {
+		table := introspector.Table{
+			Name: "sequences",
+			Columns: []introspector.Column{
+				{Name: "id", GoType: "int64", IsPrimaryKey: true, IsGenerated: true},
+			},
+		}
+
+		src := render(t, "repository_postgres.tmpl", "postgres", table)
+		assert.False(t, src.Has("SequencesRepository.CreateMany"))
+		assert.NotContains(t, src.Imports(), "strings")
+	})
+}
+
+func TestRepositoryTemplates_Upserts(t *testing.T) {
+	gen := &Generator{config: &config.Config{Upsert: config.UpsertConfig{ExcludeColumns: []string{"plan", "deleted_at"}}}}
+	table := testTable("accounts")
+	table.Indexes = table.Indexes[:1]
+
+	render := func(t *testing.T, name, pkg string) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	columns := "id, email, nickname, plan, balance, revision, version, deleted_at"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres")
+
+		assert.Equal(t, "func(ctx context.Context, accounts *models.Accounts) error", src.Signature("AccountsRepository.Upsert"))
+		assert.Contains(t, src.Strings("AccountsRepository.Upsert"), "INSERT INTO accounts ("+columns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8) "+
+			"ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, nickname = EXCLUDED.nickname, balance = EXCLUDED.balance, revision = EXCLUDED.revision, version = EXCLUDED.version "+
+			"RETURNING "+columns)
+		assert.Equal(t, "func(ctx context.Context, accounts *models.Accounts) error", src.Signature("AccountsRepository.UpsertByEmail"))
+		assert.Contains(t, src.Strings("AccountsRepository.UpsertByEmail"), "INSERT INTO accounts (email, nickname, plan, balance, revision, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+			"ON CONFLICT (email) DO UPDATE SET nickname = EXCLUDED.nickname, balance = EXCLUDED.balance, revision = EXCLUDED.revision, version = EXCLUDED.version "+
+			"RETURNING "+columns)
+		assert.False(t, src.Has("AccountsRepository.UpsertDoNothing"))
+	})
+
+	t.Run("do nothing", func(t *testing.T) {
+		gen.config.Upsert.DoNothing = true
+		defer func() { gen.config.Upsert.DoNothing = false }()
+
+		src := render(t, "repository_postgres.tmpl", "postgres")
+		assert.Equal(t, "func(ctx context.Context, accounts *models.Accounts) (bool, error)", src.Signature("AccountsRepository.UpsertByEmailDoNothing"))
+		assert.Contains(t, src.Strings("AccountsRepository.UpsertByEmailDoNothing"), "INSERT INTO accounts (email, nickname, plan, balance, revision, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7) "+
+			"ON CONFLICT (email) DO NOTHING RETURNING "+columns)
+
+		assert.Equal(t, "func(ctx context.Context, accounts *models.Accounts) (bool, error)", render(t, "repository_interface.tmpl", "interfaces").Signature("AccountsRepository.UpsertByEmailDoNothing"))
+		assert.Equal(t, "func(ctx context.Context, accounts *models.Accounts) (bool, error)", render(t, "mock_testify.tmpl", "mocks").Signature("MockAccountsRepository.UpsertDoNothing"))
+		assert.Contains(t, render(t, "mock_gomock.tmpl", "mocks").Strings("MockAccountsRepository.UpsertByEmailDoNothing"), "UpsertByEmailDoNothing")
+		assert.True(t, render(t, "test.tmpl", "tests").Has("TestAccountsRepository_UpsertDoNothing"))
+	})
+}
+
+func TestRepositoryTemplates_Patch(t *testing.T) {
+	gen := &Generator{}
+	table := testTable("accounts")
+
+	render := func(t *testing.T, name, pkg string, table introspector.Table) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	signature := "func(ctx context.Context, id uuid.UUID, patch models.AccountsPatch) (*models.Accounts, error)"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", table)
+
+		assert.Equal(t, signature, src.Signature("AccountsRepository.Patch"))
+		assert.Equal(t, []string{
+			`"email = $%d", len(args)`,
+			`"nickname = $%d", len(args)`,
+			`"plan = $%d", len(args)`,
+			`"balance = $%d", len(args)`,
+			`"revision = $%d", len(args)`,
+			`"version = $%d", len(args)`,
+			`"deleted_at = $%d", len(args)`,
+			`"UPDATE accounts SET %s WHERE id = $%d RETURNING id, email, nickname, plan, balance, revision, version, deleted_at", strings.Join(sets, ", "), len(args)`,
+		}, src.Calls("AccountsRepository.Patch", "Sprintf"), "the key is not patched")
+		assert.Contains(t, src.Calls("AccountsRepository.Patch", "append"), "args, patch.Nickname.Value")
+		assert.Equal(t, []string{"ctx, id"}, src.Calls("AccountsRepository.Patch", "GetByID"), "an empty patch reads the row")
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces", table).Signature("AccountsRepository.Patch"))
+		assert.Equal(t, []string{"ctx, id, patch"}, render(t, "mock_testify.tmpl", "mocks", table).Calls("MockAccountsRepository.Patch", "Called"))
+		assert.Equal(t, "func(ctx, id, patch interface{}) *gomock.Call", render(t, "mock_gomock.tmpl", "mocks", table).Signature("MockAccountsRepositoryMockRecorder.Patch"))
+		assert.True(t, render(t, "test.tmpl", "tests", table).Has("TestAccountsRepository_Patch"))
+	})
+
+	t.Run("key only table", func(t *testing.T) {
+		keyOnly := introspector.Table{
+			Name:    "tags",
+			Columns: []introspector.Column{{Name: "name", GoType: "string", IsPrimaryKey: true}},
+		}
+		assert.False(t, render(t, "repository_postgres.tmpl", "postgres", keyOnly).Has("TagsRepository.Patch"))
+	})
+}
+
+func TestRepositoryTemplates_Find(t *testing.T) {
+	gen := &Generator{}
+	table := testTable("posts")
+
+	render := func(t *testing.T, name, pkg string) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	signature := "func(ctx context.Context, filter models.PostsFilter, opts models.FindOptions[models.PostsSortField]) ([]*models.Posts, error)"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres")
+
+		assert.Equal(t, signature, src.Signature("PostsRepository.Find"))
+		assert.Equal(t, []string{"ctx, filter"}, src.Calls("PostsRepository.Find", "where"))
+		assert.Equal(t, []string{`opts.Sort, "id"`}, src.Calls("PostsRepository.Find", "orderByClause"))
+
+		assert.Equal(t, []string{`&w, "id", filter.Id`, `&w, "user_id", filter.UserId`}, src.Calls("PostsRepository.where", "appendFilter"))
+		assert.Equal(t, []string{`&w, "slug", filter.Slug`, `&w, "type", filter.Type`, `&w, "email", filter.Email`}, src.Calls("PostsRepository.where", "appendStringFilter"))
+		assert.Equal(t, []string{`&w, "published_at", filter.PublishedAt`}, src.Calls("PostsRepository.where", "appendTimeFilter"))
+		assert.False(t, src.Uses("PostsRepository.where", "Payload"), "json columns are not filtered")
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces").Signature("PostsRepository.Find"))
+		assert.Equal(t, []string{"ctx, filter, opts"}, render(t, "mock_testify.tmpl", "mocks").Calls("MockPostsRepository.Find", "Called"))
+		assert.Equal(t, []string{`m, "Find", ctx, filter, opts`}, render(t, "mock_gomock.tmpl", "mocks").Calls("MockPostsRepository.Find", "Call"))
+		assert.True(t, render(t, "test.tmpl", "tests").Has("TestPostsRepository_Find"))
+	})
+}
+
+func TestRepositoryTemplates_Batch(t *testing.T) {
+	gen := &Generator{}
+	table := testTable("posts")
+
+	render := func(t *testing.T, name, pkg string) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	signature := "func(ctx context.Context, ids []int64) (found []*models.Posts, missing []int64, err error)"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres")
+
+		assert.Equal(t, signature, src.Signature("PostsRepository.GetByIDs"))
+		assert.Equal(t, []string{"GetByIDs", "SELECT id, user_id, slug, type, email, payload, published_at FROM posts WHERE id = ANY($1)"}, src.Strings("PostsRepository.GetByIDs"))
+		assert.Equal(t, []string{"posts"}, src.Assigns("PostsRepository.GetByIDs", "byID[posts.Id]"))
+		assert.Equal(t, []string{"found, posts", "missing, id"}, src.Calls("PostsRepository.GetByIDs", "append"))
+		assert.Contains(t, src.Returns("PostsRepository.GetByIDs"), "found, missing, nil")
+
+		// Create, Update and Delete share their statements with the batch builder
+		assert.Equal(t, []string{"DELETE FROM posts WHERE id = $1"}, src.Strings("deletePostsQuery"))
+		assert.Equal(t, []string{"posts"}, src.Calls("PostsRepository.Create", "createPostsStatement"))
+		assert.Equal(t, []string{"updatePostsQuery"}, src.Assigns("PostsRepository.Update", "query"))
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces").Signature("PostsRepository.GetByIDs"))
+		assert.Contains(t, render(t, "mock_testify.tmpl", "mocks").Returns("MockPostsRepository.GetByIDs"), "args.Get(0).([]*models.Posts), args.Get(1).([]int64), args.Error(2)")
+		assert.Equal(t, []string{`m, "GetByIDs", ctx, ids`}, render(t, "mock_gomock.tmpl", "mocks").Calls("MockPostsRepository.GetByIDs", "Call"))
+		assert.True(t, render(t, "test.tmpl", "tests").Has("TestPostsRepository_GetByIDs"))
+	})
+
+	t.Run("test keys", func(t *testing.T) {
+		src := render(t, "test.tmpl", "tests")
+		assert.Equal(t, []string{"int64(1)"}, src.Assigns("TestPostsRepository_GetByID", "id"))
+		assert.Equal(t, []string{"[]int64{\n\tint64(1),\n\tint64(2),\n}"}, src.Assigns("TestPostsRepository_GetByIDs", "ids"))
+
+		// uuid keys are parsed from fixed literals, the keys without an obvious literal are zero values
+		src = renderGoSource(t, gen, "test.tmpl", gen.newRepositoryTemplateData(testTable("accounts"), "tests"))
+		assert.Equal(t, []string{`uuid.MustParse("00000000-0000-0000-0000-000000000001")`}, src.Assigns("TestAccountsRepository_Delete", "id"))
+		assert.Equal(t, "*new(time.Time)", (repositoryTemplateData{PrimaryKeyType: "time.Time"}).TestKey(1))
+	})
+
+	t.Run("builder", func(t *testing.T) {
+		src := renderGoSource(t, gen, "batch.tmpl", struct {
+			Package      string
+			Tables       []repositoryTemplateData
+			Tenancy      string
+			BatchImports []string
+		}{
+			Package: "postgres",
+			Tables:  []repositoryTemplateData{gen.newRepositoryTemplateData(table, "postgres")},
+		})
+
+		assert.Equal(t, "func(ctx context.Context, db DBTX) error", src.Signature("BatchBuilder.Send"))
+		assert.Equal(t, "func(posts *models.Posts) *BatchResult", src.Signature("BatchBuilder.CreatePosts"))
+		assert.Contains(t, src.Returns("BatchBuilder.CreatePosts"), "mapError(results.QueryRow().Scan(&posts.Id))")
+		assert.Equal(t, "func(posts *models.Posts) *BatchResult", src.Signature("BatchBuilder.UpdatePosts"))
+		assert.Contains(t, src.Returns("BatchBuilder.UpdatePosts"), "execAffecting(results, ErrNotFound)")
+		assert.Equal(t, "func(id int64) *BatchResult", src.Signature("BatchBuilder.DeletePosts"))
+		assert.True(t, src.Uses("BatchBuilder.DeletePosts", "deletePostsQuery"))
+		assert.NotContains(t, src.Imports(), "github.com/google/uuid")
+	})
+
+	t.Run("uuid primary key", func(t *testing.T) {
+		cfg := &config.Config{OutputDir: t.TempDir()}
+		cfg.ApplyDefaults()
+		g := New(cfg)
+		require.NoError(t, os.MkdirAll(cfg.GetReposDir(), 0755))
+
+		schema := &introspector.Schema{Tables: []introspector.Table{testTable("accounts")}}
+		require.NoError(t, g.generateRepositorySupport(schema))
+
+		content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "batch.go"))
+		require.NoError(t, err)
+		src := parseGoSource(t, string(content))
+		assert.Contains(t, src.Imports(), "github.com/google/uuid")
+		assert.Equal(t, "func(id uuid.UUID) *BatchResult", src.Signature("BatchBuilder.DeleteAccounts"))
+	})
+
+	t.Run("custom repository template", func(t *testing.T) {
+		templateDir := t.TempDir()
+		cfg := &config.Config{OutputDir: t.TempDir(), TemplateDir: templateDir}
+		cfg.ApplyDefaults()
+		g := New(cfg)
+		require.NoError(t, os.MkdirAll(cfg.GetReposDir(), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "repository_postgres.tmpl"), []byte("package {{.Package}}\n"), 0644))
+
+		require.NoError(t, g.generateRepositorySupport(testTables("posts")))
+		assert.NoFileExists(t, filepath.Join(cfg.GetReposDir(), "batch.go"), "the statements queued by the batch are declared by the embedded repository template")
+		assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "query.go"))
+
+		// A custom batch comes with the custom repository template
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "batch.tmpl"), []byte("package {{.Package}}\n"), 0644))
+		require.NoError(t, g.generateRepositorySupport(testTables("posts")))
+		assert.FileExists(t, filepath.Join(cfg.GetReposDir(), "batch.go"))
+	})
+}
+
+func TestRepositoryTemplates_Stream(t *testing.T) {
+	gen := &Generator{}
+	table := testTable("posts")
+
+	render := func(t *testing.T, name, pkg string) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	each := "func(ctx context.Context, filter models.PostsFilter, fn func(*models.Posts) error) error"
+	all := "func(ctx context.Context, filter models.PostsFilter) iter.Seq2[*models.Posts, error]"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres")
+
+		assert.Equal(t, each, src.Signature("PostsRepository.Each"))
+		assert.Equal(t, all, src.Signature("PostsRepository.All"))
+		assert.Equal(t, []string{"ctx, filter, fn"}, src.Calls("PostsRepository.All", "each"))
+		assert.Equal(t, []string{"ctx, filter"}, src.Calls("PostsRepository.each", "where"))
+		assert.Equal(t, []string{"SELECT id, user_id, slug, type, email, payload, published_at FROM posts", "ORDER BY id"}, src.Strings("PostsRepository.each"))
+		assert.Equal(t, []string{"ctx, r.reader(ctx), query, w.args, fetchSize, scan"}, src.Calls("PostsRepository.each", "streamWithCursor"))
+		assert.Equal(t, []string{"ctx, r.reader(ctx), query, w.args, scan"}, src.Calls("PostsRepository.each", "stream"))
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		assert.Equal(t, all, render(t, "repository_interface.tmpl", "interfaces").Signature("PostsRepository.All"))
+		assert.Equal(t, []string{"ctx, filter, fn"}, render(t, "mock_testify.tmpl", "mocks").Calls("MockPostsRepository.Each", "Called"))
+		assert.Equal(t, []string{`m, "All", ctx, filter`}, render(t, "mock_gomock.tmpl", "mocks").Calls("MockPostsRepository.All", "Call"))
+		assert.Contains(t, render(t, "test.tmpl", "tests").Calls("TestPostsRepository_Each", "On"), `"Each", ctx, filter, mocklib.Anything`)
+	})
+
+	t.Run("support", func(t *testing.T) {
+		src := renderGoSource(t, gen, "stream.tmpl", struct{ Package string }{"postgres"})
+
+		assert.Equal(t, []string{"pgx_goose_cursor_%d", "DECLARE", "NO SCROLL CURSOR FOR", "FETCH FORWARD %d FROM %s"}, src.Strings("streamWithCursor"))
+		assert.Contains(t, src.Calls("streamWithCursor", "Sprintf"), `"FETCH FORWARD %d FROM %s", fetchSize, cursor`)
+		assert.Equal(t, "func[T any](each func(fn func(T) error) error) iter.Seq2[T, error]", src.Signature("seq"))
+
+		src = renderGoSource(t, gen, "context.tmpl", struct{ Package, Tenancy string }{"postgres", ""})
+		assert.Equal(t, "func(ctx context.Context, fetchSize int) context.Context", src.Signature("WithCursor"))
+	})
+}
+
+func TestModelFilterTemplate(t *testing.T) {
+	table := testTable("posts")
+	data := struct {
+		StructName string
+		Package    string
+		Imports    []string
+		Filters    []FilterField
+		SortFields []SortField
+	}{
+		StructName: "Posts",
+		Package:    "models",
+		Imports:    []string{"time"},
+		Filters:    buildFilterFields(table),
+		SortFields: buildSortFields(table),
+	}
+	src := renderGoSource(t, &Generator{}, "model_filter.tmpl", data)
+
+	assert.Contains(t, src.Fields("PostsFilter"), "PublishedAt *TimeFilter")
+	assert.NotContains(t, src.Fields("PostsFilter"), "Payload *Filter[[]byte]")
+	assert.Equal(t, []string{"published_at"}, src.Strings("PostsSortByPublishedAt"))
+	for _, field := range data.SortFields {
+		assert.True(t, src.Uses("PostsSortField.Valid", field.Const), field.Const)
+	}
+}
+
+func TestRepositoryTemplates_Keyset(t *testing.T) {
+	gen := &Generator{config: &config.Config{Keyset: config.KeysetConfig{
+		SortKeys: map[string][]string{"posts": {"type"}},
+	}}}
+	table := testTable("posts")
+
+	render := func(t *testing.T, name, pkg string) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+	signature := "func(ctx context.Context, cursor string, limit int) (*models.Page[*models.Posts], error)"
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres")
+
+		assert.Equal(t, signature, src.Signature("PostsRepository.ListAfter"))
+		assert.Equal(t, []string{"cursor, &key.Id"}, src.Calls("PostsRepository.ListAfter", "decodeCursor"))
+		assert.Equal(t, []string{`" ORDER BY id ASC"`, `" ORDER BY id DESC"`}, src.Assigns("PostsRepository.ListAfter", "orderBy"))
+
+		assert.Equal(t, signature, src.Signature("PostsRepository.ListAfterByType"))
+		assert.Equal(t, []string{"cursor, &key.Type, &key.Id"}, src.Calls("PostsRepository.ListAfterByType", "decodeCursor"))
+		assert.Equal(t, []string{`" ORDER BY type ASC, id ASC"`, `" ORDER BY type DESC, id DESC"`}, src.Assigns("PostsRepository.ListAfterByType", "orderBy"))
+		assert.Equal(t, []string{"key.Type", "key.Id", "limit + 1"}, src.Calls("PostsRepository.ListAfterByType", "arg"))
+		assert.Contains(t, src.Strings("PostsRepository.ListAfterByType"), "(type, id)")
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces").Signature("PostsRepository.ListAfterByType"))
+		assert.Equal(t, []string{"ctx, cursor, limit"}, render(t, "mock_testify.tmpl", "mocks").Calls("MockPostsRepository.ListAfterByType", "Called"))
+		assert.Equal(t, []string{`m, "ListAfterByType", ctx, cursor, limit`}, render(t, "mock_gomock.tmpl", "mocks").Calls("MockPostsRepository.ListAfterByType", "Call"))
+		assert.True(t, render(t, "test.tmpl", "tests").Has("TestPostsRepository_ListAfter"))
+	})
+}
+
+func TestRepositoryTemplates_SoftDelete(t *testing.T) {
+	gen := &Generator{config: &config.Config{SoftDelete: config.SoftDeleteConfig{
+		Enabled: true,
+		Columns: []string{"deleted_at"},
+	}}}
+	table := testTable("accounts")
+
+	render := func(t *testing.T, name, pkg string, table introspector.Table) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", table)
+
+		assert.Equal(t, []string{"UPDATE accounts SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL"}, src.Strings("deleteAccountsQuery"))
+		assert.Equal(t, "func(ctx context.Context, id uuid.UUID) error", src.Signature("AccountsRepository.HardDelete"))
+		assert.Equal(t, []string{"HardDelete", "DELETE FROM accounts WHERE id = $1"}, src.Strings("AccountsRepository.HardDelete"))
+		assert.Equal(t, "func(ctx context.Context, id uuid.UUID) error", src.Signature("AccountsRepository.Restore"))
+		assert.Equal(t, []string{"Restore", "UPDATE accounts SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"}, src.Strings("AccountsRepository.Restore"))
+		assert.Equal(t, []string{`ctx, "WHERE deleted_at IS NULL"`}, src.Calls("AccountsRepository.Count", "notDeleted"))
+		assert.Equal(t, []string{`ctx, "AND deleted_at IS NULL"`}, src.Calls("AccountsRepository.ListByPlan", "notDeleted"))
+		assert.Contains(t, src.Calls("AccountsRepository.where", "append"), `w.conditions, "deleted_at IS NULL"`)
+		assert.Equal(t, []string{"UPDATE accounts SET email = $1, nickname = $2, plan = $3, balance = $4, revision = $5, version = $6 WHERE id = $7 AND deleted_at IS NULL"},
+			src.Strings("updateAccountsQuery"), "only Delete and Restore write the soft delete column")
+		assert.Contains(t, src.Strings("AccountsRepository.Patch"), "UPDATE accounts SET %s WHERE id = $%d AND deleted_at IS NULL RETURNING id, email, nickname, plan, balance, revision, version, deleted_at")
+		assert.NotContains(t, src.Strings("AccountsRepository.Patch"), "deleted_at = $%d")
+	})
+
+	t.Run("without soft delete column", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", testTable("posts"))
+
+		assert.Equal(t, []string{"DELETE FROM posts WHERE id = $1"}, src.Strings("deletePostsQuery"))
+		assert.Empty(t, src.Calls("PostsRepository.Count", "notDeleted"))
+		assert.Empty(t, src.Calls("PostsRepository.GetByID", "notDeleted"))
+		assert.False(t, src.Has("PostsRepository.HardDelete"))
+		assert.False(t, src.Has("PostsRepository.Restore"))
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		signature := "func(ctx context.Context, id uuid.UUID) error"
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces", table).Signature("AccountsRepository.Restore"))
+		assert.Equal(t, signature, render(t, "mock_testify.tmpl", "mocks", table).Signature("MockAccountsRepository.HardDelete"))
+		assert.Equal(t, []string{`m, "Restore", ctx, id`}, render(t, "mock_gomock.tmpl", "mocks", table).Calls("MockAccountsRepository.Restore", "Call"))
+		assert.True(t, render(t, "test.tmpl", "tests", table).Has("TestAccountsRepository_Restore"))
+	})
+
+	t.Run("context", func(t *testing.T) {
+		src := renderGoSource(t, gen, "context.tmpl", struct{ Package, Tenancy string }{"postgres", ""})
+
+		assert.Equal(t, "func(ctx context.Context) context.Context", src.Signature("WithDeleted"))
+		assert.Equal(t, "func(ctx context.Context, condition string) string", src.Signature("notDeleted"))
+	})
+}
+
+func TestRepositoryTemplates_OptimisticLocking(t *testing.T) {
+	gen := &Generator{config: &config.Config{OptimisticLocking: config.OptimisticLockingConfig{
+		Enabled: true,
+		Column:  "version",
+		Tables:  map[string]string{"posts": "xmin"},
+	}}}
+
+	render := func(t *testing.T, name, pkg string, table introspector.Table) goSource {
+		return renderGoSource(t, gen, name, gen.newRepositoryTemplateData(table, pkg))
+	}
+
+	t.Run("version column", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", testTable("accounts"))
+
+		assert.Equal(t, []string{"UPDATE accounts SET email = $1, nickname = $2, plan = $3, balance = $4, revision = $5, deleted_at = $6, " +
+			"version = accounts.version + 1 WHERE id = $7 AND version = $8 RETURNING version"}, src.Strings("updateAccountsQuery"), "the version is bumped rather than overwritten")
+		assert.Contains(t, src.Calls("AccountsRepository.Update", "Scan"), "&accounts.Version")
+		assert.True(t, src.Uses("AccountsRepository.Update", "ErrStaleObject"))
+		assert.True(t, src.Uses("AccountsRepository.Delete", "ErrStaleObject"))
+		assert.True(t, src.Uses("AccountsRepository.Patch", "ErrStaleObject"))
+		assert.Equal(t, "func(ctx context.Context, id uuid.UUID, version int32) error", src.Signature("AccountsRepository.Delete"))
+		assert.Equal(t, []string{"DELETE FROM accounts WHERE id = $1 AND version = $2"}, src.Strings("deleteAccountsQuery"))
+		assert.Equal(t, "func(ctx context.Context, id uuid.UUID, version int32, patch models.AccountsPatch) (*models.Accounts, error)", src.Signature("AccountsRepository.Patch"))
+		assert.Contains(t, src.Strings("AccountsRepository.Patch"), "UPDATE accounts SET %s, version = accounts.version + 1 WHERE id = $%d AND version = $%d RETURNING "+
+			"id, email, nickname, plan, balance, revision, version, deleted_at")
+		assert.Contains(t, src.Strings("AccountsRepository.Upsert"), "INSERT INTO accounts (id, email, nickname, plan, balance, revision, version, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) "+
+			"ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, nickname = EXCLUDED.nickname, plan = EXCLUDED.plan, balance = EXCLUDED.balance, revision = EXCLUDED.revision, deleted_at = EXCLUDED.deleted_at, "+
+			"version = accounts.version + 1 RETURNING id, email, nickname, plan, balance, revision, version, deleted_at")
+	})
+
+	t.Run("xmin", func(t *testing.T) {
+		src := render(t, "repository_postgres.tmpl", "postgres", testTable("posts"))
+
+		assert.Contains(t, src.Strings("PostsRepository.GetByID"), "SELECT id, user_id, slug, type, email, payload, published_at, xmin FROM posts WHERE id = $1")
+		assert.Equal(t, []string{"UPDATE posts SET user_id = $1, slug = $2, type = $3, email = $4, payload = $5, published_at = $6 WHERE id = $7 AND xmin = $8 RETURNING xmin"},
+			src.Strings("updatePostsQuery"), "PostgreSQL bumps xmin itself")
+		assert.Equal(t, "func(ctx context.Context, id int64, version uint32) error", src.Signature("PostsRepository.Delete"))
+	})
+
+	t.Run("xmin read back by Create", func(t *testing.T) {
+		data := gen.newRepositoryTemplateData(testTable("posts"), "postgres")
+		var returning []string
+		for _, col := range data.CreateReturning() {
+			returning = append(returning, col.Name)
+		}
+		assert.Equal(t, []string{"id", "xmin"}, returning, "a created row is only versioned once xmin is read back")
+
+		src := render(t, "repository_postgres.tmpl", "postgres", testTable("posts"))
+		assert.Equal(t, []string{"INSERT INTO posts (user_id, slug, type, email, payload, published_at ) VALUES ($1, $2, $3, $4, $5, $6 ) RETURNING id, xmin"}, src.Strings("createPostsQuery"))
+		assert.Contains(t, src.Calls("PostsRepository.Create", "Scan"), "&posts.Id, &posts.Xmin")
+
+		batch := renderGoSource(t, gen, "batch.tmpl", struct {
+			Package      string
+			Tables       []repositoryTemplateData
+			Tenancy      string
+			BatchImports []string
+		}{Package: "postgres", Tables: []repositoryTemplateData{data}})
+		assert.Contains(t, batch.Calls("BatchBuilder.CreatePosts", "Scan"), "&posts.Id, &posts.Xmin")
+	})
+
+	t.Run("mocks and tests", func(t *testing.T) {
+		table := testTable("accounts")
+		signature := "func(ctx context.Context, id uuid.UUID, version int32) error"
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces", table).Signature("AccountsRepository.Delete"))
+		assert.Equal(t, signature, render(t, "mock_testify.tmpl", "mocks", table).Signature("MockAccountsRepository.Delete"))
+		assert.Equal(t, "func(ctx, id, version interface{}) *gomock.Call", render(t, "mock_gomock.tmpl", "mocks", table).Signature("MockAccountsRepositoryMockRecorder.Delete"))
+		assert.Contains(t, render(t, "test.tmpl", "tests", table).Calls("TestAccountsRepository_Delete", "On"), `"Delete", ctx, id, version`)
+
+		signature = "func(ctx context.Context, id uuid.UUID, version int32, patch models.AccountsPatch) (*models.Accounts, error)"
+		assert.Equal(t, signature, render(t, "repository_interface.tmpl", "interfaces", table).Signature("AccountsRepository.Patch"))
+		assert.Equal(t, signature, render(t, "mock_testify.tmpl", "mocks", table).Signature("MockAccountsRepository.Patch"))
+		assert.Equal(t, "func(ctx, id, version, patch interface{}) *gomock.Call", render(t, "mock_gomock.tmpl", "mocks", table).Signature("MockAccountsRepositoryMockRecorder.Patch"))
+		assert.Contains(t, render(t, "test.tmpl", "tests", table).Calls("TestAccountsRepository_Patch", "Patch"), "ctx, id, version, patch")
+	})
+}
+
+func TestRepositoryPostgresTemplate_Errors(t *testing.T) {
+	gen := &Generator{config: &config.Config{SoftDelete: config.SoftDeleteConfig{
+		Enabled: true,
+		Columns: []string{"deleted_at"},
+	}}}
+	src := renderGoSource(t, gen, "repository_postgres.tmpl", gen.newRepositoryTemplateData(testTable("accounts"), "postgres"))
+
+	assert.Equal(t, []string{`"accounts with id %v: %w", id, ErrNotFound`}, src.Calls("AccountsRepository.GetByID", "Errorf"))
+	for _, method := range []string{"Delete", "HardDelete", "Restore"} {
+		assert.Contains(t, src.Returns("AccountsRepository."+method), "ErrNotFound", "%s of a missing row", method)
+	}
+	assert.Contains(t, src.Calls("AccountsRepository.List", "mapError"), "rows.Err()")
+	assert.Contains(t, src.Returns("AccountsRepository.Count"), "count, mapError(err)")
+	for _, method := range []string{"Create", "GetByID", "Update", "Delete", "HardDelete", "Restore", "List", "Count", "Patch", "Upsert"} {
+		assert.NotContains(t, src.Returns("AccountsRepository."+method), "err", "%s returns mapped errors", method)
+	}
+}
@@ -10,6 +10,7 @@ func (g *Generator) getEmbeddedTemplate(name string) (*template.Template, error)
 	funcMap := template.FuncMap{
 		"toPascalCase": toPascalCase,
 		"lower":        strings.ToLower,
+		"contains":     strings.Contains,
 		"add": func(a, b int) int {
 			return a + b
 		},
@@ -37,6 +38,84 @@ func (g *Generator) getEmbeddedTemplate(name string) (*template.Template, error)
 		return template.New("mock_gomock").Funcs(funcMap).Parse(mockGomockTemplate)
 	case "test.tmpl":
 		return template.New("test").Funcs(funcMap).Parse(testTemplate)
+	case "stream.tmpl":
+		return template.New("stream").Funcs(funcMap).Parse(streamTemplate)
+	case "db.tmpl":
+		return template.New("db").Funcs(funcMap).Parse(dbTemplate)
+	case "tx_manager.tmpl":
+		return template.New("tx_manager").Funcs(funcMap).Parse(txManagerTemplate)
+	case "model_patch.tmpl":
+		return template.New("model_patch").Funcs(funcMap).Parse(modelPatchTemplate)
+	case "optional.tmpl":
+		return template.New("optional").Funcs(funcMap).Parse(optionalTemplate)
+	case "model_filter.tmpl":
+		return template.New("model_filter").Funcs(funcMap).Parse(modelFilterTemplate)
+	case "filter.tmpl":
+		return template.New("filter").Funcs(funcMap).Parse(filterTemplate)
+	case "query.tmpl":
+		return template.New("query").Funcs(funcMap).Parse(queryTemplate)
+	case "page.tmpl":
+		return template.New("page").Funcs(funcMap).Parse(pageTemplate)
+	case "cursor.tmpl":
+		return template.New("cursor").Funcs(funcMap).Parse(cursorTemplate)
+	case "context.tmpl":
+		return template.New("context").Funcs(funcMap).Parse(contextTemplate)
+	case "notify.tmpl":
+		return template.New("notify").Funcs(funcMap).Parse(notifyTemplate)
+	case "outbox.tmpl":
+		return template.New("outbox").Funcs(funcMap).Parse(outboxTemplate)
+	case "audit.tmpl":
+		return template.New("audit").Funcs(funcMap).Parse(auditTemplate)
+	case "audit_changed_by.tmpl":
+		return template.New("audit_changed_by").Funcs(funcMap).Parse(auditChangedByTemplate)
+	case "tenancy.tmpl":
+		return template.New("tenancy").Funcs(funcMap).Parse(tenancyTemplate)
+	case "cache.tmpl":
+		return template.New("cache").Funcs(funcMap).Parse(cacheTemplate)
+	case "cached_repository.tmpl":
+		return template.New("cached_repository").Funcs(funcMap).Parse(cachedRepositoryTemplate)
+	case "handlers.tmpl":
+		return template.New("handlers").Funcs(funcMap).Parse(handlersSupportTemplate)
+	case "handler.tmpl":
+		return template.New("handler").Funcs(funcMap).Parse(handlerTemplate)
+	case "handler_test.tmpl":
+		return template.New("handler_test").Funcs(funcMap).Parse(handlerTestTemplate)
+	case "proto.tmpl":
+		return template.New("proto").Funcs(funcMap).Parse(protoTemplate)
+	case "proto_convert.tmpl":
+		return template.New("proto_convert").Funcs(funcMap).Parse(protoConvertTemplate)
+	case "proto_support.tmpl":
+		return template.New("proto_support").Funcs(funcMap).Parse(protoSupportTemplate)
+	case "openapi.tmpl":
+		return template.New("openapi").Funcs(funcMap).Parse(openAPITemplate)
+	case "graphql_schema.tmpl":
+		return template.New("graphql_schema").Funcs(funcMap).Parse(graphQLSchemaTemplate)
+	case "graphql_resolver.tmpl":
+		return template.New("graphql_resolver").Funcs(funcMap).Parse(graphQLResolverTemplate)
+	case "graphql_loader.tmpl":
+		return template.New("graphql_loader").Funcs(funcMap).Parse(graphQLLoaderTemplate)
+	case "graphql_table_resolver.tmpl":
+		return template.New("graphql_table_resolver").Funcs(funcMap).Parse(graphQLTableResolverTemplate)
+	case "json_schema.tmpl":
+		return template.New("json_schema").Funcs(funcMap).Parse(jsonSchemaTemplate)
+	case "typescript.tmpl":
+		return template.New("typescript").Funcs(funcMap).Parse(typeScriptTemplate)
+	case "typescript_index.tmpl":
+		return template.New("typescript_index").Funcs(funcMap).Parse(typeScriptIndexTemplate)
+	case "batch.tmpl":
+		return template.New("batch").Funcs(funcMap).Parse(batchTemplate)
+	case "replicas.tmpl":
+		return template.New("replicas").Funcs(funcMap).Parse(replicasTemplate)
+	case "options.tmpl":
+		return template.New("options").Funcs(funcMap).Parse(optionsTemplate)
+	case "hooks.tmpl":
+		return template.New("hooks").Funcs(funcMap).Parse(hooksTemplate)
+	case "hooks_otel.tmpl":
+		return template.New("hooks_otel").Funcs(funcMap).Parse(hooksOTelTemplate)
+	case "hooks_prometheus.tmpl":
+		return template.New("hooks_prometheus").Funcs(funcMap).Parse(hooksPrometheusTemplate)
+	case "errors.tmpl":
+		return template.New("errors").Funcs(funcMap).Parse(errorsTemplate)
 	default:
 		return nil, nil
 	}
@@ -66,12 +145,184 @@ func ({{lower .StructName}}) TableName() string {
 }
 `
 
+const modelPatchTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+{{- if .Imports}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+
+// {{.StructName}}Patch holds the {{.StructName}} columns changed by a partial update.
+// Fields left unset keep their stored value.
+type {{.StructName}}Patch struct {
+{{- range .Columns}}
+	{{toPascalCase .Name}} Optional[{{.GoType}}] ` + "`json:\"{{.Name}}\"`" + `
+{{- end}}
+}
+`
+
+const optionalTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import "encoding/json"
+
+// Optional holds a value that may be left unset, so that partial updates can tell
+// a field that was not provided apart from a zero value or NULL
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns an Optional holding v
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Get returns the value and whether it was set
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// UnmarshalJSON marks the value as set whenever the field is present in the JSON document,
+// including an explicit null
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// MarshalJSON encodes the value, or null when it is unset
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+`
+
+const modelFilterTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+{{- if .Imports}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{- end}}
+
+// {{.StructName}}Filter selects the {{.StructName}} rows returned by Find.
+// Nil fields are ignored and the others are combined with AND.
+type {{.StructName}}Filter struct {
+{{- range .Filters}}
+	{{.Field}} *{{.Type}}
+{{- end}}
+}
+
+// {{.StructName}}SortField lists the {{.StructName}} columns Find can sort by, limited to indexed columns
+type {{.StructName}}SortField string
+{{- if .SortFields}}
+
+const (
+{{- range .SortFields}}
+	{{.Const}} {{$.StructName}}SortField = "{{.Column}}"
+{{- end}}
+)
+{{- end}}
+
+// Valid reports whether f is one of the declared sort fields
+func (f {{.StructName}}SortField) Valid() bool {
+{{- if .SortFields}}
+	switch f {
+	case {{range $i, $f := .SortFields}}{{if $i}}, {{end}}{{.Const}}{{end}}:
+		return true
+	}
+{{- end}}
+	return false
+}
+`
+
+const filterTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import "time"
+
+// Filter holds the predicates applied to a column.
+// Unset predicates are ignored and the others are combined with AND.
+type Filter[T any] struct {
+	Eq     *T    // column = value
+	In     []T   // column = ANY(values)
+	Lt     *T    // column < value
+	Gt     *T    // column > value
+	IsNull *bool // column IS NULL when true, IS NOT NULL when false
+}
+
+// StringFilter adds pattern matching to the predicates of text columns
+type StringFilter struct {
+	Filter[string]
+	Like *string // column LIKE pattern
+}
+
+// TimeFilter adds a half-open range to the predicates of time columns
+type TimeFilter struct {
+	Filter[time.Time]
+	From *time.Time // column >= from
+	To   *time.Time // column < to
+}
+
+// Sort orders query results by a field
+type Sort[F ~string] struct {
+	Field F
+	Desc  bool
+}
+
+// FindOptions controls the ordering and pagination of Find.
+// A zero Limit returns every matching row.
+type FindOptions[F ~string] struct {
+	Sort   []Sort[F]
+	Limit  int
+	Offset int
+}
+
+// Ptr returns a pointer to v, handy to fill filter predicates
+func Ptr[T any](v T) *T {
+	return &v
+}
+`
+
+const pageTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+// Page holds a page of keyset paginated results with the opaque cursors
+// of the neighbouring pages
+type Page[T any] struct {
+	Items []T
+	Next  string // Cursor of the following page, empty on the last page
+	Prev  string // Cursor of the preceding page, empty on the first page
+}
+`
+
 const repositoryInterfaceTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
 
 package {{.Package}}
 
 import (
 	"context"
+	"iter"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
 	
 	"github.com/fsvxavier/pgx-goose/models"
 )
@@ -84,17 +335,82 @@ type {{.InterfaceName}} interface {
 	// GetByID retrieves a {{.StructName}} by ID
 	GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error)
 	
+	// GetByIDs retrieves the {{.StructName}}s with the given IDs in a single query, in the order of ids.
+	// The IDs matching no row are returned in missing.
+	GetByIDs(ctx context.Context, ids []{{.PrimaryKeyType}}) (found []*models.{{.StructName}}, missing []{{.PrimaryKeyType}}, err error)
+	
 	// Update updates an existing {{.StructName}}
 	Update(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error
 	
-	// Delete deletes a {{.StructName}} by ID
-	Delete(ctx context.Context, id {{.PrimaryKeyType}}) error
+	// Delete deletes a {{.StructName}} by ID{{with .Version}}, failing with ErrStaleObject unless its {{.Column}} matches version{{end}}
+	Delete(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) error
+{{- if .SoftDelete}}
+	
+	// HardDelete permanently deletes a soft deletable {{.StructName}} by ID
+	HardDelete(ctx context.Context, id {{.PrimaryKeyType}}) error
+	
+	// Restore restores a soft deleted {{.StructName}} by ID
+	Restore(ctx context.Context, id {{.PrimaryKeyType}}) error
+{{- end}}
 	
 	// List retrieves all {{.StructName}}s with pagination
 	List(ctx context.Context, limit, offset int) ([]*models.{{.StructName}}, error)
 	
 	// Count returns the total number of {{.StructName}}s
 	Count(ctx context.Context) (int64, error)
+	
+	// Find retrieves the {{.StructName}}s matching filter, sorted and paginated by opts
+	Find(ctx context.Context, filter models.{{.StructName}}Filter, opts models.FindOptions[models.{{.StructName}}SortField]) ([]*models.{{.StructName}}, error)
+	
+	// Each calls fn for every {{.StructName}} matching filter, ordered by primary key, reading the rows
+	// as they arrive instead of loading them all. It stops at the first error returned by fn.
+	Each(ctx context.Context, filter models.{{.StructName}}Filter, fn func(*models.{{.StructName}}) error) error
+	
+	// All returns an iterator streaming the {{.StructName}}s matching filter like Each
+	All(ctx context.Context, filter models.{{.StructName}}Filter) iter.Seq2[*models.{{.StructName}}, error]
+{{- range .Keysets}}
+	
+	// {{.Method}} retrieves a page of {{$.StructName}}s ordered by {{.Description}}, starting at cursor
+	{{.Method}}(ctx context.Context, cursor string, limit int) (*models.Page[*models.{{$.StructName}}], error)
+{{- end}}
+{{- if .UpdateColumns}}
+	
+	// Patch updates the {{.StructName}} columns set in patch and returns the updated row
+{{- with .Version}}, while its {{.Column}} matches version{{end}}
+	Patch(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}, patch models.{{.StructName}}Patch) (*models.{{.StructName}}, error)
+{{- end}}
+{{- if .InsertColumns}}
+	
+	// CreateMany inserts {{.StructName}}s in bulk using the COPY protocol
+	CreateMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) (int64, error)
+	
+	// InsertMany inserts a small batch of {{.StructName}}s and reads back the stored rows
+	InsertMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) error
+{{- end}}
+{{- range .Upserts}}
+	
+	// {{.Name}} inserts a {{$.StructName}} or updates the row conflicting on {{.Description}}
+	{{.Name}}(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) error
+{{- if $.UpsertDoNothing}}
+	
+	// {{.Name}}DoNothing inserts a {{$.StructName}} unless a row conflicting on {{.Description}} exists
+	{{.Name}}DoNothing(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) (bool, error)
+{{- end}}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+	
+	// GetBy{{.Name}} retrieves a {{$.StructName}} by {{.Description}}
+	GetBy{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error)
+	
+	// ExistsBy{{.Name}} reports whether a {{$.StructName}} with the given {{.Description}} exists
+	ExistsBy{{.Name}}(ctx context.Context, {{.Signature}}) (bool, error)
+{{- else}}
+	
+	// ListBy{{.Name}} retrieves {{$.StructName}}s by {{.Description}} with pagination
+	ListBy{{.Name}}(ctx context.Context, {{.Signature}}, limit, offset int) ([]*models.{{$.StructName}}, error)
+{{- end}}
+{{- end}}
 }
 `
 
@@ -105,9 +421,15 @@ package {{.Package}}
 import (
 	"context"
 	"fmt"
+	"iter"
+{{- if or .InsertColumns .UpdateColumns}}
+	"strings"
+{{- end}}
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
 	
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	
 	"github.com/fsvxavier/pgx-goose/models"
 	"github.com/fsvxavier/pgx-goose/repository/interfaces"
@@ -115,100 +437,393 @@ import (
 
 // {{.ImplName}} implements the {{.InterfaceName}} interface
 type {{.ImplName}} struct {
-	db *pgxpool.Pool
+	db       DBTX
+	replicas *replicaSet
+	hooks    Hooks
 }
 
-// New{{.StructName}}Repository creates a new {{.StructName}} repository
-func New{{.StructName}}Repository(db *pgxpool.Pool) interfaces.{{.InterfaceName}} {
-	return &{{.ImplName}}{db: db}
+// New{{.StructName}}Repository creates a new {{.StructName}} repository.
+// The querier can be a *pgxpool.Pool, a *pgx.Conn or a pgx.Tx.
+{{- if .Tenant}}
+// Its queries only read and write the rows of the tenant of their context, see WithTenant.
+{{- else if .TenantSchema}}
+// Its queries run on the table of the schema of their context, see WithTenantSchema.
+{{- end}}
+func New{{.StructName}}Repository(db DBTX, opts ...Option) interfaces.{{.InterfaceName}} {
+	o := newOptions(opts)
+	return &{{.ImplName}}{
+		db:       {{if .Tenancy}}withTenancy({{end}}withHooks(db, o.hooks, "{{.Table.Name}}"){{if .Tenancy}}){{end}},
+		replicas: newReplicaSet(o.replicas, o.hooks, "{{.Table.Name}}"),
+		hooks:    o.hooks,
+	}
 }
 
-// Create creates a new {{.StructName}}
-func (r *{{.ImplName}}) Create(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
-	query := ` + "`" + `
-		INSERT INTO {{.Table.Name}} (
-			{{- $first := true}}{{- range .Table.Columns}}{{if not .IsPrimaryKey}}{{if not $first}}, {{end}}{{.Name}}{{$first = false}}{{end}}{{- end}}
+// New{{.StructName}}RepositoryWithTx creates a new {{.StructName}} repository bound to a transaction.
+// Replicas are ignored, all its queries run inside the transaction.
+func New{{.StructName}}RepositoryWithTx(tx pgx.Tx, opts ...Option) interfaces.{{.InterfaceName}} {
+	o := newOptions(opts)
+	return &{{.ImplName}}{db: {{if .Tenancy}}withTenancy({{end}}withHooks(tx, o.hooks, "{{.Table.Name}}"){{if .Tenancy}}){{end}}, hooks: o.hooks}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given transaction
+func (r *{{.ImplName}}) WithTx(tx pgx.Tx) interfaces.{{.InterfaceName}} {
+	clone := *r
+	clone.db = {{if .Tenancy}}withTenancy({{end}}withHooks(tx, r.hooks, "{{.Table.Name}}"){{if .Tenancy}}){{end}}
+	clone.replicas = nil
+	return &clone
+}
+
+// reader returns the querier for reads, a replica unless ctx requires the primary
+func (r *{{.ImplName}}) reader(ctx context.Context) DBTX {
+	return r.replicas.reader(ctx, r.db)
+}
+
+// Statements writing a single {{.StructName}} row, shared with the BatchBuilder
+const (
+	create{{.StructName}}Query = ` + "`" + `
+		{{- if .Outbox}}
+		WITH changed AS ({{end}}
+		{{- if .CreateDefaults}}
+		INSERT INTO {{.TableRef}} %s
+		{{- else}}
+		INSERT INTO {{.TableRef}} (
+			{{- $first := true}}{{- range .CreateColumns}}{{if not $first}}, {{end}}{{.Name}}{{$first = false}}{{- end}}
+			{{- with .Tenant}}{{if not $first}}, {{end}}{{.Column}}{{end}}
 		) VALUES (
-			{{- $paramIndex := 1}}{{- $first := true}}{{- range .Table.Columns}}{{if not .IsPrimaryKey}}{{if not $first}}, {{end}}${{$paramIndex}}{{$first = false}}{{$paramIndex = add $paramIndex 1}}{{end}}{{- end}}
-		){{if .PrimaryKeyCol}} RETURNING {{.PrimaryKeyCol}}{{end}}
+			{{- $paramIndex := 1}}{{- $first := true}}{{- range .CreateColumns}}{{if not $first}}, {{end}}${{$paramIndex}}{{$first = false}}{{$paramIndex = add $paramIndex 1}}{{- end}}
+			{{- with .Tenant}}{{if not $first}}, {{end}}{{.Param}}{{end}}
+		)
+		{{- end}}{{if .Outbox}} {{.Outbox.ReturningRow .CreateReturning}}
+		), event AS (
+			{{.Outbox.Event "created"}}
+		)
+		SELECT {{range $i, $col := .CreateReturning}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM changed{{else if .PrimaryKeyCol}} RETURNING {{range $i, $col := .CreateReturning}}{{if $i}}, {{end}}{{.Name}}{{end}}{{end}}
+	` + "`" + `
+	update{{.StructName}}Query = ` + "`" + `
+		{{- if .Outbox}}
+		WITH changed AS ({{end}}
+		UPDATE {{.TableRef}} SET
+{{- $paramIndex := 1}}{{- range $i, $col := .UpdateColumns}}{{if $i}}, {{end}}
+			{{.Name}} = ${{$paramIndex}}{{$paramIndex = add $paramIndex 1}}{{- end}}
+{{- with .Version}}{{with .Increment}}{{if $.UpdateColumns}}, {{end}}
+			{{.}}{{end}}{{end}}
+		WHERE {{.PrimaryKeyCol}} = ${{$paramIndex}}{{with .Version}} AND {{.Column}} = ${{add $paramIndex 1}}{{end}}{{with .SoftDelete}} AND {{.Condition}}{{end}}{{with .Tenant}} AND {{.Condition}}{{end}}
+{{- if and .Outbox .Version}}
+		{{.Outbox.Returning .Version.Column}}
+		), event AS (
+			{{.Outbox.Event "updated"}}
+		)
+		SELECT {{.Version.Column}} FROM changed
+{{- else if .Outbox}}
+		{{.Outbox.Returning}}
+		)
+		{{.Outbox.Event "updated"}}
+{{- else if .Version}}
+		RETURNING {{.Version.Column}}
+{{- end}}
 	` + "`" + `
+	delete{{.StructName}}Query = ` + "`{{if .Outbox}}WITH changed AS ({{end}}" + `
+{{- if and .SoftDelete .Version}}UPDATE {{.TableRef}} SET {{.SoftDelete.MarkDeleted}}{{with .Version.Increment}}, {{.}}{{end}} WHERE {{.PrimaryKeyCol}} = $1 AND {{.SoftDelete.Condition}} AND {{.Version.Column}} = $2
+{{- else if .SoftDelete}}UPDATE {{.TableRef}} SET {{.SoftDelete.MarkDeleted}} WHERE {{.PrimaryKeyCol}} = $1 AND {{.SoftDelete.Condition}}
+{{- else if .Version}}DELETE FROM {{.TableRef}} WHERE {{.PrimaryKeyCol}} = $1 AND {{.Version.Column}} = $2
+{{- else}}DELETE FROM {{.TableRef}} WHERE {{.PrimaryKeyCol}} = $1
+{{- end}}
+{{- with .Tenant}} AND {{.Condition}}{{end}}
+{{- with .Outbox}} {{.Returning}}) {{.Event "deleted"}}{{end}}` + "`" + `
+)
+
+// create{{.StructName}}Statement returns the statement inserting {{lower .StructName}} and its arguments
+{{- if .CreateDefaults}}.
+// The columns with a default are only inserted when their field is set, the database
+// writes the default of the others.
+{{- end}}
+func create{{.StructName}}Statement({{lower .StructName}} *models.{{.StructName}}) (string, []any) {
+{{- if .CreateDefaults}}
+	var b insertBuilder
+{{- range .CreateColumns}}
+	b.set("{{.Name}}", {{lower $.StructName}}.{{toPascalCase .Name}})
+{{- end}}
+{{- range .CreateDefaults}}
+	setUnlessZero(&b, "{{.Name}}", {{lower $.StructName}}.{{toPascalCase .Name}})
+{{- end}}
+{{- with .Tenant}}
+	b.setSQL("{{.Column}}", "{{.Param}}")
+{{- end}}
+	return fmt.Sprintf(create{{.StructName}}Query, b.SQL()), b.args
+{{- else}}
+	return create{{.StructName}}Query, []any{
+		{{- range .CreateColumns}}
+		{{lower $.StructName}}.{{toPascalCase .Name}},{{- end}}
+	}
+{{- end}}
+}
+
+// Create creates a new {{.StructName}}
+{{- if .Outbox}}, appending a {{.StructName}}CreatedEvent to the outbox{{end}}
+{{- if .CreateDefaults}}.
+// The columns with a default are left to the database while their field holds its zero value,
+// their stored values are read back into {{lower .StructName}}.
+{{- end}}
+{{- with .Version}}
+{{- if not $.CreateDefaults}}.{{end}}
+// The stored {{.Column}} is read back into {{lower $.StructName}}.{{.Field}}, which guards the following Update and Delete.
+{{- end}}
+func (r *{{.ImplName}}) Create(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
+	ctx = withOperation(ctx, r.hooks, "Create")
+	query, args := create{{.StructName}}Statement({{lower .StructName}})
 	
 	{{if .PrimaryKeyCol}}
-	return r.db.QueryRow(ctx, query,
-		{{- range .Table.Columns}}{{if not .IsPrimaryKey}}
-		{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}{{- end}}
-	).Scan(&{{lower .StructName}}.{{toPascalCase .PrimaryKeyCol}})
+	err := r.db.QueryRow(ctx, query, args...).Scan({{range $i, $col := .CreateReturning}}{{if $i}}, {{end}}&{{lower $.StructName}}.{{toPascalCase .Name}}{{end}})
 	{{else}}
-	_, err := r.db.Exec(ctx, query,
-		{{- range .Table.Columns}}{{if not .IsPrimaryKey}}
-		{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}{{- end}}
-	)
-	return err
+	_, err := r.db.Exec(ctx, query, args...)
 	{{end}}
+	return mapError(err)
 }
 
 // GetByID retrieves a {{.StructName}} by ID
 func (r *{{.ImplName}}) GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "GetByID")
 	query := ` + "`" + `
 		SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
-		FROM {{.Table.Name}}
-		WHERE {{.PrimaryKeyCol}} = $1
-	` + "`" + `
+		FROM {{.TableRef}}
+		WHERE {{.PrimaryKeyCol}} = $1{{with .Tenant}} AND {{.Condition}}{{end}}
+	` + "`" + `{{if .SoftDelete}} + notDeleted(ctx, "AND {{.SoftDelete.Condition}}"){{end}}
 	
 	{{lower .StructName}} := &models.{{.StructName}}{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := r.reader(ctx).QueryRow(ctx, query, id).Scan(
 		{{- range .Table.Columns}}
 		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
 	)
 	
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("{{lower .StructName}} with id %v not found", id)
+			return nil, fmt.Errorf("{{lower .StructName}} with id %v: %w", id, ErrNotFound)
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 	
 	return {{lower .StructName}}, nil
 }
 
+// GetByIDs retrieves the {{.StructName}}s with the given IDs in a single query, in the order of ids.
+// The IDs matching no row are returned in missing.
+func (r *{{.ImplName}}) GetByIDs(ctx context.Context, ids []{{.PrimaryKeyType}}) (found []*models.{{.StructName}}, missing []{{.PrimaryKeyType}}, err error) {
+	ctx = withOperation(ctx, r.hooks, "GetByIDs")
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	
+	query := ` + "`" + `
+		SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		FROM {{.TableRef}}
+		WHERE {{.PrimaryKeyCol}} = ANY($1){{with .Tenant}} AND {{.Condition}}{{end}}
+	` + "`" + `{{if .SoftDelete}} + notDeleted(ctx, "AND {{.SoftDelete.Condition}}"){{end}}
+	
+	rows, err := r.reader(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return nil, nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	byID := make(map[{{.PrimaryKeyType}}]*models.{{.StructName}}, len(ids))
+	for rows.Next() {
+		{{lower .StructName}} := &models.{{.StructName}}{}
+		err := rows.Scan(
+			{{- range .Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return nil, nil, mapError(err)
+		}
+		byID[{{lower .StructName}}.{{toPascalCase .PrimaryKeyCol}}] = {{lower .StructName}}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, mapError(err)
+	}
+	
+	found = make([]*models.{{.StructName}}, 0, len(byID))
+	for _, id := range ids {
+		if {{lower .StructName}}, ok := byID[id]; ok {
+			found = append(found, {{lower .StructName}})
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing, nil
+}
+
 // Update updates an existing {{.StructName}}
+{{- if .Version}}.
+// The row is only updated while its {{.Version.Column}} matches {{lower .StructName}}.{{.Version.Field}}, otherwise
+// ErrStaleObject is returned. The new {{.Version.Column}} is read back into {{lower .StructName}}.
+{{- else}}, ErrNotFound is returned when it does not exist
+{{- end}}
+{{- if .SoftDelete}}
+// Soft deleted rows are not updated{{if .Version}}, ErrStaleObject is returned for them{{end}}, and {{lower .StructName}}.{{.SoftDelete.Field}} is not written: use Delete and Restore.
+{{- end}}
+{{- if .Outbox}}
+// A {{.StructName}}UpdatedEvent is appended to the outbox in the same statement.
+{{- end}}
 func (r *{{.ImplName}}) Update(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
-	query := ` + "`" + `
-		UPDATE {{.Table.Name}} SET
-{{- $paramIndex := 1}}{{- $first := true}}{{- range .Table.Columns}}{{if not .IsPrimaryKey}}{{if not $first}}, {{end}}
-			{{.Name}} = ${{$paramIndex}}{{$first = false}}{{$paramIndex = add $paramIndex 1}}{{end}}{{- end}}
-		WHERE {{.PrimaryKeyCol}} = ${{$paramIndex}}
-	` + "`" + `
+	ctx = withOperation(ctx, r.hooks, "Update")
+	query := update{{.StructName}}Query
+	{{- if .Version}}
+	
+	err := r.db.QueryRow(ctx, query,
+		{{- range .UpdateColumns}}
+		{{lower $.StructName}}.{{toPascalCase .Name}},{{- end}}
+		{{lower .StructName}}.{{toPascalCase .PrimaryKeyCol}},
+		{{lower .StructName}}.{{.Version.Field}},
+	).Scan(&{{lower .StructName}}.{{.Version.Field}})
+	if err == pgx.ErrNoRows {
+		return ErrStaleObject
+	}
+	
+	return mapError(err)
+	{{- else}}
 	
-	_, err := r.db.Exec(ctx, query,
-		{{- range .Table.Columns}}{{if not .IsPrimaryKey}}
-		{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}{{- end}}
+	tag, err := r.db.Exec(ctx, query,
+		{{- range .UpdateColumns}}
+		{{lower $.StructName}}.{{toPascalCase .Name}},{{- end}}
 		{{lower .StructName}}.{{toPascalCase .PrimaryKeyCol}},
 	)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+	{{- end}}
+}
+
+{{- if and .SoftDelete .Version}}
+// Delete soft deletes a {{.StructName}} by ID, the row is kept and hidden from reads.
+// The row is only deleted while its {{.Version.Column}} matches version, otherwise ErrStaleObject is returned.
+{{- if .Outbox}}
+// A {{.StructName}}DeletedEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}, version {{.Version.GoType}}) error {
+	ctx = withOperation(ctx, r.hooks, "Delete")
+	query := delete{{.StructName}}Query
 	
-	return err
+	tag, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStaleObject
+	}
+	return nil
+}
+{{- else if .SoftDelete}}
+// Delete soft deletes a {{.StructName}} by ID, the row is kept and hidden from reads.
+// ErrNotFound is returned when no such row exists or it is already deleted.
+{{- if .Outbox}}
+// A {{.StructName}}DeletedEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	ctx = withOperation(ctx, r.hooks, "Delete")
+	query := delete{{.StructName}}Query
+	
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+{{- end}}
+{{- if .SoftDelete}}
+
+// HardDelete permanently deletes a {{.StructName}} by ID, ErrNotFound is returned when it does not exist
+{{- if .Outbox}}
+// A {{.StructName}}DeletedEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) HardDelete(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	ctx = withOperation(ctx, r.hooks, "HardDelete")
+	query := ` + "`{{if .Outbox}}WITH changed AS ({{end}}DELETE FROM {{.TableRef}} WHERE {{.PrimaryKeyCol}} = $1{{with .Tenant}} AND {{.Condition}}{{end}}{{with .Outbox}} {{.Returning}}) {{.Event \"deleted\"}}{{end}}`" + `
+	
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-// Delete deletes a {{.StructName}} by ID
+// Restore restores a soft deleted {{.StructName}} by ID, ErrNotFound is returned when no deleted row matches
+{{- if .Outbox}}
+// A {{.StructName}}RestoredEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) Restore(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	ctx = withOperation(ctx, r.hooks, "Restore")
+	query := ` + "`{{if .Outbox}}WITH changed AS ({{end}}UPDATE {{.TableRef}} SET {{.SoftDelete.MarkRestored}}{{with .Version}}{{with .Increment}}, {{.}}{{end}}{{end}} WHERE {{.PrimaryKeyCol}} = $1 AND {{.SoftDelete.DeletedCondition}}{{with .Tenant}} AND {{.Condition}}{{end}}{{with .Outbox}} {{.Returning}}) {{.Event \"restored\"}}{{end}}`" + `
+	
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+{{- else if .Version}}
+// Delete deletes a {{.StructName}} by ID while its {{.Version.Column}} matches version, otherwise ErrStaleObject is returned
+{{- if .Outbox}}
+// A {{.StructName}}DeletedEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}, version {{.Version.GoType}}) error {
+	ctx = withOperation(ctx, r.hooks, "Delete")
+	query := delete{{.StructName}}Query
+	
+	tag, err := r.db.Exec(ctx, query, id, version)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStaleObject
+	}
+	return nil
+}
+{{- else}}
+// Delete deletes a {{.StructName}} by ID, ErrNotFound is returned when it does not exist
+{{- if .Outbox}}
+// A {{.StructName}}DeletedEvent is appended to the outbox in the same statement.
+{{- end}}
 func (r *{{.ImplName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}) error {
-	query := ` + "`DELETE FROM {{.Table.Name}} WHERE {{.PrimaryKeyCol}} = $1`" + `
+	ctx = withOperation(ctx, r.hooks, "Delete")
+	query := delete{{.StructName}}Query
 	
-	_, err := r.db.Exec(ctx, query, id)
-	return err
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
+{{- end}}
 
 // List retrieves all {{.StructName}}s with pagination
 func (r *{{.ImplName}}) List(ctx context.Context, limit, offset int) ([]*models.{{.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "List")
 	query := ` + "`" + `
 		SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
-		FROM {{.Table.Name}}
+		FROM {{.TableRef}}{{with .Tenant}} WHERE {{.Condition}}{{end}}{{if .SoftDelete}}` + "`" + ` + notDeleted(ctx, "{{if .Tenant}}AND{{else}}WHERE{{end}} {{.SoftDelete.Condition}}") + ` + "`" + `{{end}}
 		ORDER BY {{.PrimaryKeyCol}}
 		LIMIT $1 OFFSET $2
 	` + "`" + `
 	
-	rows, err := r.db.Query(ctx, query, limit, offset)
+	rows, err := r.reader(ctx).Query(ctx, query, limit, offset)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer rows.Close()
 	
@@ -220,44 +835,592 @@ func (r *{{.ImplName}}) List(ctx context.Context, limit, offset int) ([]*models.
 			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
 		)
 		if err != nil {
-			return nil, err
+			return nil, mapError(err)
 		}
 		{{lower .StructName}}s = append({{lower .StructName}}s, {{lower .StructName}})
 	}
 	
-	return {{lower .StructName}}s, rows.Err()
+	return {{lower .StructName}}s, mapError(rows.Err())
 }
 
 // Count returns the total number of {{.StructName}}s
 func (r *{{.ImplName}}) Count(ctx context.Context) (int64, error) {
-	query := ` + "`SELECT COUNT(*) FROM {{.Table.Name}}`" + `
+	ctx = withOperation(ctx, r.hooks, "Count")
+	query := ` + "`SELECT COUNT(*) FROM {{.TableRef}}{{with .Tenant}} WHERE {{.Condition}}{{end}}`" + `{{if .SoftDelete}} + notDeleted(ctx, "{{if .Tenant}}AND{{else}}WHERE{{end}} {{.SoftDelete.Condition}}"){{end}}
 	
 	var count int64
-	err := r.db.QueryRow(ctx, query).Scan(&count)
-	return count, err
+	err := r.reader(ctx).QueryRow(ctx, query).Scan(&count)
+	return count, mapError(err)
 }
-`
 
-const mockTestifyTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
-
-package {{.Package}}
-
-import (
-	"context"
+// Find retrieves the {{.StructName}}s matching filter, sorted and paginated by opts
+func (r *{{.ImplName}}) Find(ctx context.Context, filter models.{{.StructName}}Filter, opts models.FindOptions[models.{{.StructName}}SortField]) ([]*models.{{.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "Find")
+	w := r.where(ctx, filter)
 	
-	"github.com/stretchr/testify/mock"
+	orderBy, err := orderByClause(opts.Sort, "{{.PrimaryKeyCol}}")
+	if err != nil {
+		return nil, err
+	}
 	
-	"github.com/fsvxavier/pgx-goose/models"
-	"github.com/fsvxavier/pgx-goose/repository/interfaces"
-)
+	query := "SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM {{.TableRef}}" + w.SQL() + orderBy
+	if opts.Limit > 0 {
+		query += " LIMIT " + w.arg(opts.Limit)
+	}
+	if opts.Offset > 0 {
+		query += " OFFSET " + w.arg(opts.Offset)
+	}
+	
+	rows, err := r.reader(ctx).Query(ctx, query, w.args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	var {{lower .StructName}}s []*models.{{.StructName}}
+	for rows.Next() {
+		{{lower .StructName}} := &models.{{.StructName}}{}
+		err := rows.Scan(
+			{{- range .Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		{{lower .StructName}}s = append({{lower .StructName}}s, {{lower .StructName}})
+	}
+	
+	return {{lower .StructName}}s, mapError(rows.Err())
+}
 
-// {{.MockName}} is a mock implementation of {{.InterfaceName}}
-type {{.MockName}} struct {
-	mock.Mock
+// Each calls fn for every {{.StructName}} matching filter, ordered by primary key, reading the rows
+// as they arrive instead of loading them all. It stops at the first error returned by fn.
+func (r *{{.ImplName}}) Each(ctx context.Context, filter models.{{.StructName}}Filter, fn func(*models.{{.StructName}}) error) error {
+	ctx = withOperation(ctx, r.hooks, "Each")
+	return r.each(ctx, filter, fn)
 }
 
-// Ensure {{.MockName}} implements {{.InterfaceName}}
-var _ interfaces.{{.InterfaceName}} = (*{{.MockName}})(nil)
+// All returns an iterator streaming the {{.StructName}}s matching filter like Each
+func (r *{{.ImplName}}) All(ctx context.Context, filter models.{{.StructName}}Filter) iter.Seq2[*models.{{.StructName}}, error] {
+	ctx = withOperation(ctx, r.hooks, "All")
+	return seq(func(fn func(*models.{{.StructName}}) error) error {
+		return r.each(ctx, filter, fn)
+	})
+}
+
+func (r *{{.ImplName}}) each(ctx context.Context, filter models.{{.StructName}}Filter, fn func(*models.{{.StructName}}) error) error {
+	w := r.where(ctx, filter)
+	query := "SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM {{.TableRef}}" + w.SQL() + " ORDER BY {{.PrimaryKeyCol}}"
+	
+	scan := func(rows pgx.Rows) error {
+		{{lower .StructName}} := &models.{{.StructName}}{}
+		err := rows.Scan(
+			{{- range .Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return mapError(err)
+		}
+		return fn({{lower .StructName}})
+	}
+	
+	if fetchSize := cursorFetchSize(ctx); fetchSize > 0 {
+		return streamWithCursor(ctx, r.reader(ctx), query, w.args, fetchSize, scan)
+	}
+	return stream(ctx, r.reader(ctx), query, w.args, scan)
+}
+
+// where builds the conditions selecting the {{.StructName}}s matching filter
+func (r *{{.ImplName}}) where(ctx context.Context, filter models.{{.StructName}}Filter) *whereBuilder {
+	var w whereBuilder
+	{{- with .Tenant}}
+	w.conditions = append(w.conditions, "{{.Condition}}")
+	{{- end}}
+	{{- if .SoftDelete}}
+	if !includeDeleted(ctx) {
+		w.conditions = append(w.conditions, "{{.SoftDelete.Condition}}")
+	}
+	{{- end}}
+	{{- range .Filters}}
+	{{- if eq .Kind "string"}}
+	appendStringFilter(&w, "{{.Column}}", filter.{{.Field}})
+	{{- else if eq .Kind "time"}}
+	appendTimeFilter(&w, "{{.Column}}", filter.{{.Field}})
+	{{- else}}
+	appendFilter(&w, "{{.Column}}", filter.{{.Field}})
+	{{- end}}
+	{{- end}}
+	return &w
+}
+{{- range .Keysets}}
+
+// {{.Method}} retrieves a page of {{$.StructName}}s ordered by {{.Description}}.
+// An empty cursor returns the first page, the Next and Prev cursors of a page move forward and backward.
+func (r *{{$.ImplName}}) {{.Method}}(ctx context.Context, cursor string, limit int) (*models.Page[*models.{{$.StructName}}], error) {
+	ctx = withOperation(ctx, r.hooks, "{{.Method}}")
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	
+	var w whereBuilder
+	orderBy := " ORDER BY {{.OrderBy "ASC"}}"
+	backward := false
+	{{- with $.Tenant}}
+	w.conditions = append(w.conditions, "{{.Condition}}")
+	{{- end}}
+	{{- if $.SoftDelete}}
+	
+	if !includeDeleted(ctx) {
+		w.conditions = append(w.conditions, "{{$.SoftDelete.Condition}}")
+	}
+	{{- end}}
+	
+	if cursor != "" {
+		var key models.{{$.StructName}}
+		var err error
+		backward, err = decodeCursor(cursor{{range .Columns}}, &key.{{.Field}}{{end}})
+		if err != nil {
+			return nil, err
+		}
+		
+		comparison := ">"
+		if backward {
+			comparison = "<"
+			orderBy = " ORDER BY {{.OrderBy "DESC"}}"
+		}
+		w.conditions = append(w.conditions, "({{.ColumnList}}) "+comparison+" ("+{{range $i, $col := .Columns}}{{if $i}}+", "+{{end}}w.arg(key.{{.Field}}){{end}}+")")
+	}
+	
+	// One extra row tells whether another page follows
+	query := "SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM {{$.TableRef}}" + w.SQL() + orderBy
+	query += " LIMIT " + w.arg(limit+1)
+	
+	rows, err := r.reader(ctx).Query(ctx, query, w.args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	var {{lower $.StructName}}s []*models.{{$.StructName}}
+	for rows.Next() {
+		{{lower $.StructName}} := &models.{{$.StructName}}{}
+		err := rows.Scan(
+			{{- range $.Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		{{lower $.StructName}}s = append({{lower $.StructName}}s, {{lower $.StructName}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+	
+	return newPage({{lower $.StructName}}s, limit, cursor != "", backward, func({{lower $.StructName}} *models.{{$.StructName}}) []any {
+		return []any{ {{- range $i, $col := .Columns}}{{if $i}}, {{end}}{{lower $.StructName}}.{{.Field}}{{end -}} }
+	})
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// Patch updates only the {{.StructName}} columns set in patch and returns the updated row.
+// When no field is set the stored row is returned unchanged.
+{{- if .Version}}
+// The row is only updated while its {{.Version.Column}} matches version, otherwise ErrStaleObject is returned.
+{{- end}}
+{{- if .SoftDelete}}
+// Soft deleted rows are not updated, {{if .Version}}ErrStaleObject{{else}}ErrNotFound{{end}} is returned for them.
+{{- end}}
+{{- if .Outbox}}
+// A {{.StructName}}UpdatedEvent is appended to the outbox in the same statement, unless no field is set.
+{{- end}}
+func (r *{{.ImplName}}) Patch(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}, patch models.{{.StructName}}Patch) (*models.{{.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "Patch")
+	var sets []string
+	var args []any
+	{{- range .UpdateColumns}}
+	if patch.{{toPascalCase .Name}}.Set {
+		args = append(args, patch.{{toPascalCase .Name}}.Value)
+		sets = append(sets, fmt.Sprintf("{{.Name}} = $%d", len(args)))
+	}
+	{{- end}}
+	
+	if len(sets) == 0 {
+		{{- if .Version}}
+		{{lower .StructName}}, err := r.GetByID(ctx, id)
+		if err == nil && {{lower .StructName}}.{{.Version.Field}} != version {
+			return nil, ErrStaleObject
+		}
+		return {{lower .StructName}}, err
+		{{- else}}
+		return r.GetByID(ctx, id)
+		{{- end}}
+	}
+	
+	args = append(args, id{{if .Version}}, version{{end}})
+	query := fmt.Sprintf({{if .Outbox}}` + "`" + `WITH changed AS ({{else}}"{{end}}UPDATE {{.TableRef}} SET %s{{with .Version}}{{with .Increment}}, {{.}}{{end}}{{end}} WHERE {{.PrimaryKeyCol}} = $%d{{with .Version}} AND {{.Column}} = $%d{{end}}{{with .SoftDelete}} AND {{.Condition}}{{end}}{{with .Tenant}} AND {{.Condition}}{{end}}
+		{{- with .Outbox}} {{.ReturningRow $.Table.Columns}}), event AS ({{.Event "updated"}}) SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM changed` + "`" + `
+		{{- else}} RETURNING {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}"{{end}},
+		strings.Join(sets, ", "), {{if .Version}}len(args)-1, {{end}}len(args))
+	
+	{{lower .StructName}} := &models.{{.StructName}}{}
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		{{- range .Table.Columns}}
+		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	)
+	
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			{{- if .Version}}
+			return nil, ErrStaleObject
+			{{- else}}
+			return nil, fmt.Errorf("{{lower .StructName}} with id %v: %w", id, ErrNotFound)
+			{{- end}}
+		}
+		return nil, mapError(err)
+	}
+	
+	return {{lower .StructName}}, nil
+}
+{{- end}}
+{{- if .InsertColumns}}
+
+// CreateMany inserts {{.StructName}}s in bulk using the COPY protocol and returns the number of rows copied.
+// Values generated by the database are not read back, use InsertMany when they are needed.
+{{- with .InsertDefaults}}
+// Unlike Create and InsertMany, COPY writes every field as is: a zero value is stored rather
+// than the default of {{range $i, $col := .}}{{if $i}}, {{end}}{{.Name}}{{end}}.
+{{- end}}
+{{- if .Outbox}}
+// No event is appended to the outbox since COPY cannot return the copied rows, use InsertMany
+// for their {{.StructName}}CreatedEvents to be appended.
+{{- end}}
+func (r *{{.ImplName}}) CreateMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) (int64, error) {
+	ctx = withOperation(ctx, r.hooks, "CreateMany")
+	if len({{lower .StructName}}s) == 0 {
+		return 0, nil
+	}
+	
+	{{- if .Tenant}}
+	tenantID, ok := TenantID(ctx)
+	if !ok {
+		return 0, ErrNoTenant
+	}
+	{{- end}}
+	
+	n, err := r.db.CopyFrom(ctx,
+		pgx.Identifier{"{{.Table.Name}}"},
+		[]string{ {{- range $i, $col := .InsertColumns}}{{if $i}}, {{end}}"{{.Name}}"{{end}}{{with .Tenant}}, "{{.Column}}"{{end -}} },
+		pgx.CopyFromSlice(len({{lower .StructName}}s), func(i int) ([]any, error) {
+			return []any{
+				{{- range .InsertColumns}}
+				{{lower $.StructName}}s[i].{{toPascalCase .Name}},{{end}}
+				{{- if .Tenant}}
+				tenantID,
+				{{- end}}
+			}, nil
+		}),
+	)
+	return n, mapError(err)
+}
+
+// InsertMany inserts {{.StructName}}s with a single multi-row INSERT and scans the stored rows,
+// including generated values, back into the given models. It is meant for small batches:
+// a statement accepts at most 65535 parameters, use CreateMany for larger loads.
+{{- if .InsertDefaults}}
+// Like Create, the columns with a default are left to the database while their field holds
+// its zero value.
+{{- end}}
+{{- if .Outbox}}
+// A {{.StructName}}CreatedEvent is appended to the outbox for each row in the same statement.
+{{- end}}
+func (r *{{.ImplName}}) InsertMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) error {
+	ctx = withOperation(ctx, r.hooks, "InsertMany")
+	if len({{lower .StructName}}s) == 0 {
+		return nil
+	}
+	
+	const columnsPerRow = {{len .InsertColumns}}
+	if len({{lower .StructName}}s)*columnsPerRow > 65535 {
+		return fmt.Errorf("too many {{lower .StructName}}s for a single insert (%d), use CreateMany instead", len({{lower .StructName}}s))
+	}
+	
+	var query strings.Builder
+	query.WriteString("{{if .Outbox}}WITH changed AS ({{end}}INSERT INTO {{.TableRef}} ({{range $i, $col := .InsertColumns}}{{if $i}}, {{end}}{{.Name}}{{end}}{{with .Tenant}}, {{.Column}}{{end}}) VALUES ")
+	
+	args := make([]any, 0, len({{lower .StructName}}s)*columnsPerRow)
+	for i, {{lower .StructName}} := range {{lower .StructName}}s {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		{{- range $j, $col := .InsertColumns}}
+		{{- if $j}}
+		query.WriteString(", ")
+		{{- end}}
+		{{- if .DefaultValue}}
+		appendValueUnlessZero(&query, &args, {{lower $.StructName}}.{{toPascalCase .Name}})
+		{{- else}}
+		appendValue(&query, &args, {{lower $.StructName}}.{{toPascalCase .Name}})
+		{{- end}}
+		{{- end}}
+		{{- if .Tenant}}
+		query.WriteString(", " + tenantParam)
+		{{- end}}
+		query.WriteString(")")
+	}
+	
+	{{- if .Outbox}}
+	query.WriteString(` + "`" + ` {{.Outbox.ReturningRow .Table.Columns}}), event AS ({{.Outbox.Event "created"}}) SELECT {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM changed` + "`" + `)
+	{{- else}}
+	query.WriteString(" RETURNING {{range $i, $col := .Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}")
+	{{- end}}
+	
+	rows, err := r.db.Query(ctx, query.String(), args...)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+	
+	// Rows are returned in the order of the VALUES list
+	for i := 0; rows.Next(); i++ {
+		if i >= len({{lower .StructName}}s) {
+			return fmt.Errorf("unexpected number of rows returned by insert")
+		}
+		{{lower .StructName}} := {{lower .StructName}}s[i]
+		err := rows.Scan(
+			{{- range .Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return mapError(err)
+		}
+	}
+	
+	return mapError(rows.Err())
+}
+{{- end}}
+{{- range .Upserts}}
+
+// {{.Name}} inserts a {{$.StructName}} or, when a row with the same {{.Description}} exists,
+// updates it with the given values. The stored row is read back into {{lower $.StructName}}.
+{{- with $.Version}}
+// The {{.Column}} of the existing row is not checked: use Update to guard against concurrent changes.
+{{- end}}
+{{- with $.SoftDelete}}
+// A soft deleted row is updated but stays deleted, {{.Column}} is only changed by Delete and Restore.
+{{- end}}
+{{- if .Tenant}}
+// ErrNotFound is returned when the existing row belongs to another tenant, it is left untouched.
+{{- end}}
+{{- if $.Outbox}}
+// A {{$.StructName}}CreatedEvent or {{$.StructName}}UpdatedEvent is appended to the outbox in the same statement.
+{{- end}}
+func (r *{{$.ImplName}}) {{.Name}}(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) error {
+	ctx = withOperation(ctx, r.hooks, "{{.Name}}")
+	query := ` + "`" + `
+		{{- if $.Outbox}}
+		WITH changed AS ({{end}}
+		INSERT INTO {{$.TableRef}} ({{.ColumnList}})
+		VALUES ({{.Placeholders}})
+		ON CONFLICT ({{.ConflictTarget}}) DO UPDATE SET {{.SetClause}}
+		{{- with .Tenant}} WHERE {{$.Table.Name}}.{{.}} = EXCLUDED.{{.}}{{end}}
+		{{- with $.Outbox}}
+		{{.ReturningUpsert $.Table.Columns}}
+		), event AS (
+			{{.UpsertEvent}}
+		)
+		SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM changed
+		{{- else}}
+		RETURNING {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		{{- end}}
+	` + "`" + `
+	
+	err := r.db.QueryRow(ctx, query,
+		{{- range .InsertColumns}}
+		{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	).Scan(
+		{{- range $.Table.Columns}}
+		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	)
+	return mapError(err)
+}
+{{- if $.UpsertDoNothing}}
+
+// {{.Name}}DoNothing inserts a {{$.StructName}} unless a row with the same {{.Description}} exists.
+// It reports whether the row was inserted; on conflict {{lower $.StructName}} is left untouched.
+{{- if $.Outbox}}
+// A {{$.StructName}}CreatedEvent is appended to the outbox in the same statement when the row is inserted.
+{{- end}}
+func (r *{{$.ImplName}}) {{.Name}}DoNothing(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) (bool, error) {
+	ctx = withOperation(ctx, r.hooks, "{{.Name}}DoNothing")
+	query := ` + "`" + `
+		{{- if $.Outbox}}
+		WITH changed AS ({{end}}
+		INSERT INTO {{$.TableRef}} ({{.ColumnList}})
+		VALUES ({{.Placeholders}})
+		ON CONFLICT ({{.ConflictTarget}}) DO NOTHING
+		{{- with $.Outbox}}
+		{{.ReturningRow $.Table.Columns}}
+		), event AS (
+			{{.Event "created"}}
+		)
+		SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM changed
+		{{- else}}
+		RETURNING {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		{{- end}}
+	` + "`" + `
+	
+	err := r.db.QueryRow(ctx, query,
+		{{- range .InsertColumns}}
+		{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	).Scan(
+		{{- range $.Table.Columns}}
+		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	)
+	
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, mapError(err)
+	}
+	
+	return true, nil
+}
+{{- end}}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+// GetBy{{.Name}} retrieves a {{$.StructName}} by {{.Description}}
+func (r *{{$.ImplName}}) GetBy{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "GetBy{{.Name}}")
+	query := ` + "`" + `
+		SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		FROM {{$.TableRef}}
+		WHERE {{.Where}}{{with $.Tenant}} AND {{.Condition}}{{end}}
+	` + "`" + `{{if $.SoftDelete}} + notDeleted(ctx, "AND {{$.SoftDelete.Condition}}"){{end}}
+	
+	{{lower $.StructName}} := &models.{{$.StructName}}{}
+	err := r.reader(ctx).QueryRow(ctx, query, {{.Args}}).Scan(
+		{{- range $.Table.Columns}}
+		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	)
+	
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("{{lower $.StructName}} with {{.NotFoundFormat}}: %w", {{.Args}}, ErrNotFound)
+		}
+		return nil, mapError(err)
+	}
+	
+	return {{lower $.StructName}}, nil
+}
+
+// ExistsBy{{.Name}} reports whether a {{$.StructName}} with the given {{.Description}} exists
+func (r *{{$.ImplName}}) ExistsBy{{.Name}}(ctx context.Context, {{.Signature}}) (bool, error) {
+	ctx = withOperation(ctx, r.hooks, "ExistsBy{{.Name}}")
+	query := ` + "`SELECT EXISTS(SELECT 1 FROM {{$.TableRef}} WHERE {{.Where}}{{with $.Tenant}} AND {{.Condition}}{{end}}{{if $.SoftDelete}}` + notDeleted(ctx, \"AND {{$.SoftDelete.Condition}}\") + `{{end}})`" + `
+	
+	var exists bool
+	err := r.reader(ctx).QueryRow(ctx, query, {{.Args}}).Scan(&exists)
+	return exists, mapError(err)
+}
+{{- else}}
+
+// ListBy{{.Name}} retrieves {{$.StructName}}s by {{.Description}} with pagination
+{{- range .Columns}}{{if .Nullable}}
+// A nil {{.Param}} matches the {{$.StructName}}s whose {{.Name}} is NULL.
+{{- end}}{{end}}
+func (r *{{$.ImplName}}) ListBy{{.Name}}(ctx context.Context, {{.Signature}}, limit, offset int) ([]*models.{{$.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "ListBy{{.Name}}")
+	{{- if .Nullable}}
+	var w whereBuilder
+	{{- range .Columns}}
+	{{- if .Nullable}}
+	if {{.Param}} == nil {
+		w.conditions = append(w.conditions, "{{.Name}} IS NULL")
+	} else {
+		w.add("{{.Name}} = %s", *{{.Param}})
+	}
+	{{- else}}
+	w.add("{{.Name}} = %s", {{.Param}})
+	{{- end}}
+	{{- end}}
+	{{- with $.Tenant}}
+	w.conditions = append(w.conditions, "{{.Condition}}")
+	{{- end}}
+	{{- if $.SoftDelete}}
+	if !includeDeleted(ctx) {
+		w.conditions = append(w.conditions, "{{$.SoftDelete.Condition}}")
+	}
+	{{- end}}
+	
+	query := "SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}} FROM {{$.TableRef}}" + w.SQL() + " ORDER BY {{$.PrimaryKeyCol}}"
+	query += " LIMIT " + w.arg(limit) + " OFFSET " + w.arg(offset)
+	
+	rows, err := r.reader(ctx).Query(ctx, query, w.args...)
+	{{- else}}
+	query := ` + "`" + `
+		SELECT {{range $i, $col := $.Table.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		FROM {{$.TableRef}}
+		WHERE {{.Where}}{{with $.Tenant}} AND {{.Condition}}{{end}}{{if $.SoftDelete}}` + "`" + ` + notDeleted(ctx, "AND {{$.SoftDelete.Condition}}") + ` + "`" + `{{end}}
+		ORDER BY {{$.PrimaryKeyCol}}
+		LIMIT ${{.LimitParam}} OFFSET ${{.OffsetParam}}
+	` + "`" + `
+	
+	rows, err := r.reader(ctx).Query(ctx, query, {{.Args}}, limit, offset)
+	{{- end}}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	var {{lower $.StructName}}s []*models.{{$.StructName}}
+	for rows.Next() {
+		{{lower $.StructName}} := &models.{{$.StructName}}{}
+		err := rows.Scan(
+			{{- range $.Table.Columns}}
+			&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		{{lower $.StructName}}s = append({{lower $.StructName}}s, {{lower $.StructName}})
+	}
+	
+	return {{lower $.StructName}}s, mapError(rows.Err())
+}
+{{- end}}
+{{- end}}
+`
+
+const mockTestifyTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"iter"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/stretchr/testify/mock"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+	"github.com/fsvxavier/pgx-goose/repository/interfaces"
+)
+
+// {{.MockName}} is a mock implementation of {{.InterfaceName}}
+type {{.MockName}} struct {
+	mock.Mock
+}
+
+// Ensure {{.MockName}} implements {{.InterfaceName}}
+var _ interfaces.{{.InterfaceName}} = (*{{.MockName}})(nil)
 
 // Create mocks the Create method
 func (m *{{.MockName}}) Create(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
@@ -271,6 +1434,12 @@ func (m *{{.MockName}}) GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*m
 	return args.Get(0).(*models.{{.StructName}}), args.Error(1)
 }
 
+// GetByIDs mocks the GetByIDs method
+func (m *{{.MockName}}) GetByIDs(ctx context.Context, ids []{{.PrimaryKeyType}}) ([]*models.{{.StructName}}, []{{.PrimaryKeyType}}, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*models.{{.StructName}}), args.Get(1).([]{{.PrimaryKeyType}}), args.Error(2)
+}
+
 // Update mocks the Update method
 func (m *{{.MockName}}) Update(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
 	args := m.Called(ctx, {{lower .StructName}})
@@ -278,11 +1447,25 @@ func (m *{{.MockName}}) Update(ctx context.Context, {{lower .StructName}} *model
 }
 
 // Delete mocks the Delete method
-func (m *{{.MockName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}) error {
+func (m *{{.MockName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) error {
+	args := m.Called(ctx, id{{if .Version}}, version{{end}})
+	return args.Error(0)
+}
+{{- if .SoftDelete}}
+
+// HardDelete mocks the HardDelete method
+func (m *{{.MockName}}) HardDelete(ctx context.Context, id {{.PrimaryKeyType}}) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+// Restore mocks the Restore method
+func (m *{{.MockName}}) Restore(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+{{- end}}
+
 // List mocks the List method
 func (m *{{.MockName}}) List(ctx context.Context, limit, offset int) ([]*models.{{.StructName}}, error) {
 	args := m.Called(ctx, limit, offset)
@@ -294,6 +1477,93 @@ func (m *{{.MockName}}) Count(ctx context.Context) (int64, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(int64), args.Error(1)
 }
+
+// Find mocks the Find method
+func (m *{{.MockName}}) Find(ctx context.Context, filter models.{{.StructName}}Filter, opts models.FindOptions[models.{{.StructName}}SortField]) ([]*models.{{.StructName}}, error) {
+	args := m.Called(ctx, filter, opts)
+	return args.Get(0).([]*models.{{.StructName}}), args.Error(1)
+}
+
+// Each mocks the Each method
+func (m *{{.MockName}}) Each(ctx context.Context, filter models.{{.StructName}}Filter, fn func(*models.{{.StructName}}) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+// All mocks the All method
+func (m *{{.MockName}}) All(ctx context.Context, filter models.{{.StructName}}Filter) iter.Seq2[*models.{{.StructName}}, error] {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(iter.Seq2[*models.{{.StructName}}, error])
+}
+{{- range .Keysets}}
+
+// {{.Method}} mocks the {{.Method}} method
+func (m *{{$.MockName}}) {{.Method}}(ctx context.Context, cursor string, limit int) (*models.Page[*models.{{$.StructName}}], error) {
+	args := m.Called(ctx, cursor, limit)
+	return args.Get(0).(*models.Page[*models.{{$.StructName}}]), args.Error(1)
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// Patch mocks the Patch method
+func (m *{{.MockName}}) Patch(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}, patch models.{{.StructName}}Patch) (*models.{{.StructName}}, error) {
+	args := m.Called(ctx, id{{if .Version}}, version{{end}}, patch)
+	return args.Get(0).(*models.{{.StructName}}), args.Error(1)
+}
+{{- end}}
+{{- if .InsertColumns}}
+
+// CreateMany mocks the CreateMany method
+func (m *{{.MockName}}) CreateMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) (int64, error) {
+	args := m.Called(ctx, {{lower .StructName}}s)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// InsertMany mocks the InsertMany method
+func (m *{{.MockName}}) InsertMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) error {
+	args := m.Called(ctx, {{lower .StructName}}s)
+	return args.Error(0)
+}
+{{- end}}
+{{- range .Upserts}}
+
+// {{.Name}} mocks the {{.Name}} method
+func (m *{{$.MockName}}) {{.Name}}(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) error {
+	args := m.Called(ctx, {{lower $.StructName}})
+	return args.Error(0)
+}
+{{- if $.UpsertDoNothing}}
+
+// {{.Name}}DoNothing mocks the {{.Name}}DoNothing method
+func (m *{{$.MockName}}) {{.Name}}DoNothing(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) (bool, error) {
+	args := m.Called(ctx, {{lower $.StructName}})
+	return args.Bool(0), args.Error(1)
+}
+{{- end}}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+// GetBy{{.Name}} mocks the GetBy{{.Name}} method
+func (m *{{$.MockName}}) GetBy{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error) {
+	args := m.Called(ctx, {{.Args}})
+	return args.Get(0).(*models.{{$.StructName}}), args.Error(1)
+}
+
+// ExistsBy{{.Name}} mocks the ExistsBy{{.Name}} method
+func (m *{{$.MockName}}) ExistsBy{{.Name}}(ctx context.Context, {{.Signature}}) (bool, error) {
+	args := m.Called(ctx, {{.Args}})
+	return args.Bool(0), args.Error(1)
+}
+{{- else}}
+
+// ListBy{{.Name}} mocks the ListBy{{.Name}} method
+func (m *{{$.MockName}}) ListBy{{.Name}}(ctx context.Context, {{.Signature}}, limit, offset int) ([]*models.{{$.StructName}}, error) {
+	args := m.Called(ctx, {{.Args}}, limit, offset)
+	return args.Get(0).([]*models.{{$.StructName}}), args.Error(1)
+}
+{{- end}}
+{{- end}}
 `
 
 const mockGomockTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
@@ -302,7 +1572,11 @@ package {{.Package}}
 
 import (
 	"context"
+	"iter"
 	"reflect"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
 
 	"go.uber.org/mock/gomock"
 
@@ -362,36 +1636,82 @@ func (mr *{{.MockName}}MockRecorder) Create(ctx, {{lower .StructName}} interface
 }
 
 // Delete mocks base method.
-func (m *{{.MockName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}) error {
+func (m *{{.MockName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id{{if .Version}}, version{{end}})
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *{{.MockName}}MockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+func (mr *{{.MockName}}MockRecorder) Delete(ctx, id{{if .Version}}, version{{end}} interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*{{.MockName}})(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*{{.MockName}})(nil).Delete), ctx, id{{if .Version}}, version{{end}})
 }
+{{- if .SoftDelete}}
 
-// GetByID mocks base method.
-func (m *{{.MockName}}) GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error) {
+// HardDelete mocks base method.
+func (m *{{.MockName}}) HardDelete(ctx context.Context, id {{.PrimaryKeyType}}) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
-	ret0, _ := ret[0].(*models.{{.StructName}})
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "HardDelete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetByID indicates an expected call of GetByID.
-func (mr *{{.MockName}}MockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+// HardDelete indicates an expected call of HardDelete.
+func (mr *{{.MockName}}MockRecorder) HardDelete(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*{{.MockName}})(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HardDelete", reflect.TypeOf((*{{.MockName}})(nil).HardDelete), ctx, id)
 }
 
-// List mocks base method.
-func (m *{{.MockName}}) List(ctx context.Context, limit, offset int) ([]*models.{{.StructName}}, error) {
+// Restore mocks base method.
+func (m *{{.MockName}}) Restore(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *{{.MockName}}MockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*{{.MockName}})(nil).Restore), ctx, id)
+}
+{{- end}}
+
+// GetByID mocks base method.
+func (m *{{.MockName}}) GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.{{.StructName}})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *{{.MockName}}MockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*{{.MockName}})(nil).GetByID), ctx, id)
+}
+
+// GetByIDs mocks base method.
+func (m *{{.MockName}}) GetByIDs(ctx context.Context, ids []{{.PrimaryKeyType}}) ([]*models.{{.StructName}}, []{{.PrimaryKeyType}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*models.{{.StructName}})
+	ret1, _ := ret[1].([]{{.PrimaryKeyType}})
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *{{.MockName}}MockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*{{.MockName}})(nil).GetByIDs), ctx, ids)
+}
+
+// List mocks base method.
+func (m *{{.MockName}}) List(ctx context.Context, limit, offset int) ([]*models.{{.StructName}}, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "List", ctx, limit, offset)
 	ret0, _ := ret[0].([]*models.{{.StructName}})
@@ -418,6 +1738,197 @@ func (mr *{{.MockName}}MockRecorder) Update(ctx, {{lower .StructName}} interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*{{.MockName}})(nil).Update), ctx, {{lower .StructName}})
 }
+
+// Find mocks base method.
+func (m *{{.MockName}}) Find(ctx context.Context, filter models.{{.StructName}}Filter, opts models.FindOptions[models.{{.StructName}}SortField]) ([]*models.{{.StructName}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, filter, opts)
+	ret0, _ := ret[0].([]*models.{{.StructName}})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *{{.MockName}}MockRecorder) Find(ctx, filter, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*{{.MockName}})(nil).Find), ctx, filter, opts)
+}
+
+// Each mocks base method.
+func (m *{{.MockName}}) Each(ctx context.Context, filter models.{{.StructName}}Filter, fn func(*models.{{.StructName}}) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Each", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Each indicates an expected call of Each.
+func (mr *{{.MockName}}MockRecorder) Each(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Each", reflect.TypeOf((*{{.MockName}})(nil).Each), ctx, filter, fn)
+}
+
+// All mocks base method.
+func (m *{{.MockName}}) All(ctx context.Context, filter models.{{.StructName}}Filter) iter.Seq2[*models.{{.StructName}}, error] {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", ctx, filter)
+	ret0, _ := ret[0].(iter.Seq2[*models.{{.StructName}}, error])
+	return ret0
+}
+
+// All indicates an expected call of All.
+func (mr *{{.MockName}}MockRecorder) All(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*{{.MockName}})(nil).All), ctx, filter)
+}
+{{- range .Keysets}}
+
+// {{.Method}} mocks base method.
+func (m *{{$.MockName}}) {{.Method}}(ctx context.Context, cursor string, limit int) (*models.Page[*models.{{$.StructName}}], error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "{{.Method}}", ctx, cursor, limit)
+	ret0, _ := ret[0].(*models.Page[*models.{{$.StructName}}])
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// {{.Method}} indicates an expected call of {{.Method}}.
+func (mr *{{$.MockName}}MockRecorder) {{.Method}}(ctx, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "{{.Method}}", reflect.TypeOf((*{{$.MockName}})(nil).{{.Method}}), ctx, cursor, limit)
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// Patch mocks base method.
+func (m *{{.MockName}}) Patch(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}, patch models.{{.StructName}}Patch) (*models.{{.StructName}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id{{if .Version}}, version{{end}}, patch)
+	ret0, _ := ret[0].(*models.{{.StructName}})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *{{.MockName}}MockRecorder) Patch(ctx, id{{if .Version}}, version{{end}}, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*{{.MockName}})(nil).Patch), ctx, id{{if .Version}}, version{{end}}, patch)
+}
+{{- end}}
+{{- if .InsertColumns}}
+
+// CreateMany mocks base method.
+func (m *{{.MockName}}) CreateMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, {{lower .StructName}}s)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *{{.MockName}}MockRecorder) CreateMany(ctx, {{lower .StructName}}s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*{{.MockName}})(nil).CreateMany), ctx, {{lower .StructName}}s)
+}
+
+// InsertMany mocks base method.
+func (m *{{.MockName}}) InsertMany(ctx context.Context, {{lower .StructName}}s []*models.{{.StructName}}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMany", ctx, {{lower .StructName}}s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertMany indicates an expected call of InsertMany.
+func (mr *{{.MockName}}MockRecorder) InsertMany(ctx, {{lower .StructName}}s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMany", reflect.TypeOf((*{{.MockName}})(nil).InsertMany), ctx, {{lower .StructName}}s)
+}
+{{- end}}
+{{- range .Upserts}}
+
+// {{.Name}} mocks base method.
+func (m *{{$.MockName}}) {{.Name}}(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "{{.Name}}", ctx, {{lower $.StructName}})
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// {{.Name}} indicates an expected call of {{.Name}}.
+func (mr *{{$.MockName}}MockRecorder) {{.Name}}(ctx, {{lower $.StructName}} interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "{{.Name}}", reflect.TypeOf((*{{$.MockName}})(nil).{{.Name}}), ctx, {{lower $.StructName}})
+}
+{{- if $.UpsertDoNothing}}
+
+// {{.Name}}DoNothing mocks base method.
+func (m *{{$.MockName}}) {{.Name}}DoNothing(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "{{.Name}}DoNothing", ctx, {{lower $.StructName}})
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// {{.Name}}DoNothing indicates an expected call of {{.Name}}DoNothing.
+func (mr *{{$.MockName}}MockRecorder) {{.Name}}DoNothing(ctx, {{lower $.StructName}} interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "{{.Name}}DoNothing", reflect.TypeOf((*{{$.MockName}})(nil).{{.Name}}DoNothing), ctx, {{lower $.StructName}})
+}
+{{- end}}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+// GetBy{{.Name}} mocks base method.
+func (m *{{$.MockName}}) GetBy{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBy{{.Name}}", ctx, {{.Args}})
+	ret0, _ := ret[0].(*models.{{$.StructName}})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBy{{.Name}} indicates an expected call of GetBy{{.Name}}.
+func (mr *{{$.MockName}}MockRecorder) GetBy{{.Name}}(ctx, {{.RecorderSignature}}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBy{{.Name}}", reflect.TypeOf((*{{$.MockName}})(nil).GetBy{{.Name}}), ctx, {{.Args}})
+}
+
+// ExistsBy{{.Name}} mocks base method.
+func (m *{{$.MockName}}) ExistsBy{{.Name}}(ctx context.Context, {{.Signature}}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsBy{{.Name}}", ctx, {{.Args}})
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsBy{{.Name}} indicates an expected call of ExistsBy{{.Name}}.
+func (mr *{{$.MockName}}MockRecorder) ExistsBy{{.Name}}(ctx, {{.RecorderSignature}}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsBy{{.Name}}", reflect.TypeOf((*{{$.MockName}})(nil).ExistsBy{{.Name}}), ctx, {{.Args}})
+}
+{{- else}}
+
+// ListBy{{.Name}} mocks base method.
+func (m *{{$.MockName}}) ListBy{{.Name}}(ctx context.Context, {{.Signature}}, limit, offset int) ([]*models.{{$.StructName}}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBy{{.Name}}", ctx, {{.Args}}, limit, offset)
+	ret0, _ := ret[0].([]*models.{{$.StructName}})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBy{{.Name}} indicates an expected call of ListBy{{.Name}}.
+func (mr *{{$.MockName}}MockRecorder) ListBy{{.Name}}(ctx, {{.Args}}, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBy{{.Name}}", reflect.TypeOf((*{{$.MockName}})(nil).ListBy{{.Name}}), ctx, {{.Args}}, limit, offset)
+}
+{{- end}}
+{{- end}}
 `
 
 const testTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
@@ -427,8 +1938,12 @@ package {{.Package}}
 import (
 	"context"
 	"testing"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
 	
 	"github.com/stretchr/testify/assert"
+	mocklib "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	
 	"github.com/fsvxavier/pgx-goose/models"
@@ -465,7 +1980,7 @@ func Test{{.StructName}}Repository_Create(t *testing.T) {
 func Test{{.StructName}}Repository_GetByID(t *testing.T) {
 	mock := &mocks.{{.MockName}}{}
 	ctx := context.Background()
-	id := {{.PrimaryKeyType}}(1) // TODO: Set appropriate test ID
+	id := {{.TestKey 1}}
 	
 	{{lower .StructName}} := &models.{{.StructName}}{
 		// TODO: Set test data
@@ -492,6 +2007,40 @@ func Test{{.StructName}}Repository_GetByID(t *testing.T) {
 	})
 }
 
+func Test{{.StructName}}Repository_GetByIDs(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	ids := []{{.PrimaryKeyType}}{
+		{{.TestKey 1}},
+		{{.TestKey 2}},
+	}
+	
+	{{lower .StructName}}s := []*models.{{.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("GetByIDs", ctx, ids).Return({{lower .StructName}}s, ids[1:], nil).Once()
+		
+		found, missing, err := mock.GetByIDs(ctx, ids)
+		
+		require.NoError(t, err)
+		assert.Equal(t, {{lower .StructName}}s, found)
+		assert.Equal(t, ids[1:], missing)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("GetByIDs", ctx, ids).Return([]*models.{{.StructName}}(nil), []{{.PrimaryKeyType}}(nil), assert.AnError).Once()
+		
+		found, _, err := mock.GetByIDs(ctx, ids)
+		
+		assert.Error(t, err)
+		assert.Nil(t, found)
+		mock.AssertExpectations(t)
+	})
+}
+
 func Test{{.StructName}}Repository_Update(t *testing.T) {
 	mock := &mocks.{{.MockName}}{}
 	ctx := context.Background()
@@ -522,26 +2071,70 @@ func Test{{.StructName}}Repository_Update(t *testing.T) {
 func Test{{.StructName}}Repository_Delete(t *testing.T) {
 	mock := &mocks.{{.MockName}}{}
 	ctx := context.Background()
-	id := {{.PrimaryKeyType}}(1) // TODO: Set appropriate test ID
+	id := {{.TestKey 1}}
+	{{- with .Version}}
+	version := {{.GoType}}(1)
+	{{- end}}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("Delete", ctx, id{{if .Version}}, version{{end}}).Return(nil)
+		
+		err := mock.Delete(ctx, id{{if .Version}}, version{{end}})
+		
+		assert.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("Delete", ctx, id{{if .Version}}, version{{end}}).Return(assert.AnError)
+		
+		err := mock.Delete(ctx, id{{if .Version}}, version{{end}})
+		
+		assert.Error(t, err)
+		mock.AssertExpectations(t)
+	})
+}
+{{- if .SoftDelete}}
+
+func Test{{.StructName}}Repository_HardDelete(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	var id {{.PrimaryKeyType}} // TODO: Set appropriate test ID
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("HardDelete", ctx, id).Return(nil).Once()
+		
+		err := mock.HardDelete(ctx, id)
+		
+		assert.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+}
+
+func Test{{.StructName}}Repository_Restore(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	var id {{.PrimaryKeyType}} // TODO: Set appropriate test ID
 	
 	t.Run("success", func(t *testing.T) {
-		mock.On("Delete", ctx, id).Return(nil)
+		mock.On("Restore", ctx, id).Return(nil).Once()
 		
-		err := mock.Delete(ctx, id)
+		err := mock.Restore(ctx, id)
 		
 		assert.NoError(t, err)
 		mock.AssertExpectations(t)
 	})
 	
 	t.Run("error", func(t *testing.T) {
-		mock.On("Delete", ctx, id).Return(assert.AnError)
+		mock.On("Restore", ctx, id).Return(assert.AnError).Once()
 		
-		err := mock.Delete(ctx, id)
+		err := mock.Restore(ctx, id)
 		
 		assert.Error(t, err)
 		mock.AssertExpectations(t)
 	})
 }
+{{- end}}
 
 func Test{{.StructName}}Repository_List(t *testing.T) {
 	mock := &mocks.{{.MockName}}{}
@@ -599,4 +2192,4974 @@ func Test{{.StructName}}Repository_Count(t *testing.T) {
 		mock.AssertExpectations(t)
 	})
 }
+
+func Test{{.StructName}}Repository_Find(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	
+	filter := models.{{.StructName}}Filter{
+		// TODO: Set the predicates to apply
+	}
+	opts := models.FindOptions[models.{{.StructName}}SortField]{Limit: 10}
+	
+	{{lower .StructName}}s := []*models.{{.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("Find", ctx, filter, opts).Return({{lower .StructName}}s, nil).Once()
+		
+		result, err := mock.Find(ctx, filter, opts)
+		
+		require.NoError(t, err)
+		assert.Equal(t, {{lower .StructName}}s, result)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("Find", ctx, filter, opts).Return([]*models.{{.StructName}}(nil), assert.AnError).Once()
+		
+		result, err := mock.Find(ctx, filter, opts)
+		
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mock.AssertExpectations(t)
+	})
+}
+
+func Test{{.StructName}}Repository_Each(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	
+	filter := models.{{.StructName}}Filter{
+		// TODO: Set the predicates to apply
+	}
+	fn := func(*models.{{.StructName}}) error { return nil }
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("Each", ctx, filter, mocklib.Anything).Return(nil).Once()
+		
+		err := mock.Each(ctx, filter, fn)
+		
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("Each", ctx, filter, mocklib.Anything).Return(assert.AnError).Once()
+		
+		err := mock.Each(ctx, filter, fn)
+		
+		assert.Error(t, err)
+		mock.AssertExpectations(t)
+	})
+}
+{{- range .Keysets}}
+
+func Test{{$.StructName}}Repository_{{.Method}}(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	
+	page := &models.Page[*models.{{$.StructName}}]{
+		Items: []*models.{{$.StructName}}{
+			// TODO: Set test data
+		},
+		Next: "next-cursor",
+	}
+	
+	t.Run("first page", func(t *testing.T) {
+		mock.On("{{.Method}}", ctx, "", 10).Return(page, nil).Once()
+		
+		result, err := mock.{{.Method}}(ctx, "", 10)
+		
+		require.NoError(t, err)
+		assert.Equal(t, page, result)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("{{.Method}}", ctx, page.Next, 10).Return((*models.Page[*models.{{$.StructName}}])(nil), assert.AnError).Once()
+		
+		result, err := mock.{{.Method}}(ctx, page.Next, 10)
+		
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mock.AssertExpectations(t)
+	})
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+func Test{{.StructName}}Repository_Patch(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	
+	var id {{.PrimaryKeyType}} // TODO: Set appropriate test value
+	{{- with .Version}}
+	version := {{.GoType}}(1)
+	{{- end}}
+	patch := models.{{.StructName}}Patch{
+		// TODO: Set the fields to change, e.g. Field: models.Some(value)
+	}
+	{{lower .StructName}} := &models.{{.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("Patch", ctx, id{{if .Version}}, version{{end}}, patch).Return({{lower .StructName}}, nil).Once()
+		
+		result, err := mock.Patch(ctx, id{{if .Version}}, version{{end}}, patch)
+		
+		require.NoError(t, err)
+		assert.Equal(t, {{lower .StructName}}, result)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("not found", func(t *testing.T) {
+		mock.On("Patch", ctx, id{{if .Version}}, version{{end}}, patch).Return((*models.{{.StructName}})(nil), assert.AnError).Once()
+		
+		result, err := mock.Patch(ctx, id{{if .Version}}, version{{end}}, patch)
+		
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mock.AssertExpectations(t)
+	})
+}
+{{- end}}
+{{- if .InsertColumns}}
+
+func Test{{.StructName}}Repository_CreateMany(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	
+	{{lower .StructName}}s := []*models.{{.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("CreateMany", ctx, {{lower .StructName}}s).Return(int64(len({{lower .StructName}}s)), nil).Once()
+		
+		copied, err := mock.CreateMany(ctx, {{lower .StructName}}s)
+		
+		require.NoError(t, err)
+		assert.Equal(t, int64(len({{lower .StructName}}s)), copied)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("CreateMany", ctx, {{lower .StructName}}s).Return(int64(0), assert.AnError).Once()
+		
+		copied, err := mock.CreateMany(ctx, {{lower .StructName}}s)
+		
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), copied)
+		mock.AssertExpectations(t)
+	})
+}
+
+func Test{{.StructName}}Repository_InsertMany(t *testing.T) {
+	mock := &mocks.{{.MockName}}{}
+	ctx := context.Background()
+	
+	{{lower .StructName}}s := []*models.{{.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("InsertMany", ctx, {{lower .StructName}}s).Return(nil).Once()
+		
+		err := mock.InsertMany(ctx, {{lower .StructName}}s)
+		
+		assert.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("InsertMany", ctx, {{lower .StructName}}s).Return(assert.AnError).Once()
+		
+		err := mock.InsertMany(ctx, {{lower .StructName}}s)
+		
+		assert.Error(t, err)
+		mock.AssertExpectations(t)
+	})
+}
+{{- end}}
+{{- range .Upserts}}
+
+func Test{{$.StructName}}Repository_{{.Name}}(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	
+	{{lower $.StructName}} := &models.{{$.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("{{.Name}}", ctx, {{lower $.StructName}}).Return(nil).Once()
+		
+		err := mock.{{.Name}}(ctx, {{lower $.StructName}})
+		
+		assert.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("{{.Name}}", ctx, {{lower $.StructName}}).Return(assert.AnError).Once()
+		
+		err := mock.{{.Name}}(ctx, {{lower $.StructName}})
+		
+		assert.Error(t, err)
+		mock.AssertExpectations(t)
+	})
+}
+{{- if $.UpsertDoNothing}}
+
+func Test{{$.StructName}}Repository_{{.Name}}DoNothing(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	
+	{{lower $.StructName}} := &models.{{$.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("inserted", func(t *testing.T) {
+		mock.On("{{.Name}}DoNothing", ctx, {{lower $.StructName}}).Return(true, nil).Once()
+		
+		inserted, err := mock.{{.Name}}DoNothing(ctx, {{lower $.StructName}})
+		
+		require.NoError(t, err)
+		assert.True(t, inserted)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("conflict", func(t *testing.T) {
+		mock.On("{{.Name}}DoNothing", ctx, {{lower $.StructName}}).Return(false, nil).Once()
+		
+		inserted, err := mock.{{.Name}}DoNothing(ctx, {{lower $.StructName}})
+		
+		require.NoError(t, err)
+		assert.False(t, inserted)
+		mock.AssertExpectations(t)
+	})
+}
+{{- end}}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+func Test{{$.StructName}}Repository_GetBy{{.Name}}(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	{{- range .Columns}}
+	var {{.Param}} {{.GoType}} // TODO: Set appropriate test value
+	{{- end}}
+	
+	{{lower $.StructName}} := &models.{{$.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("GetBy{{.Name}}", ctx, {{.Args}}).Return({{lower $.StructName}}, nil).Once()
+		
+		result, err := mock.GetBy{{.Name}}(ctx, {{.Args}})
+		
+		require.NoError(t, err)
+		assert.Equal(t, {{lower $.StructName}}, result)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("not found", func(t *testing.T) {
+		mock.On("GetBy{{.Name}}", ctx, {{.Args}}).Return((*models.{{$.StructName}})(nil), assert.AnError).Once()
+		
+		result, err := mock.GetBy{{.Name}}(ctx, {{.Args}})
+		
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mock.AssertExpectations(t)
+	})
+}
+
+func Test{{$.StructName}}Repository_ExistsBy{{.Name}}(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	{{- range .Columns}}
+	var {{.Param}} {{.GoType}} // TODO: Set appropriate test value
+	{{- end}}
+	
+	t.Run("exists", func(t *testing.T) {
+		mock.On("ExistsBy{{.Name}}", ctx, {{.Args}}).Return(true, nil).Once()
+		
+		exists, err := mock.ExistsBy{{.Name}}(ctx, {{.Args}})
+		
+		require.NoError(t, err)
+		assert.True(t, exists)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("ExistsBy{{.Name}}", ctx, {{.Args}}).Return(false, assert.AnError).Once()
+		
+		exists, err := mock.ExistsBy{{.Name}}(ctx, {{.Args}})
+		
+		assert.Error(t, err)
+		assert.False(t, exists)
+		mock.AssertExpectations(t)
+	})
+}
+{{- else}}
+
+func Test{{$.StructName}}Repository_ListBy{{.Name}}(t *testing.T) {
+	mock := &mocks.{{$.MockName}}{}
+	ctx := context.Background()
+	{{- range .Columns}}
+	var {{.Param}} {{.GoType}} // TODO: Set appropriate test value
+	{{- end}}
+	limit := 10
+	offset := 0
+	
+	{{lower $.StructName}}s := []*models.{{$.StructName}}{
+		// TODO: Set test data
+	}
+	
+	t.Run("success", func(t *testing.T) {
+		mock.On("ListBy{{.Name}}", ctx, {{.Args}}, limit, offset).Return({{lower $.StructName}}s, nil).Once()
+		
+		result, err := mock.ListBy{{.Name}}(ctx, {{.Args}}, limit, offset)
+		
+		require.NoError(t, err)
+		assert.Equal(t, {{lower $.StructName}}s, result)
+		mock.AssertExpectations(t)
+	})
+	
+	t.Run("error", func(t *testing.T) {
+		mock.On("ListBy{{.Name}}", ctx, {{.Args}}, limit, offset).Return([]*models.{{$.StructName}}(nil), assert.AnError).Once()
+		
+		result, err := mock.ListBy{{.Name}}(ctx, {{.Args}}, limit, offset)
+		
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		mock.AssertExpectations(t)
+	})
+}
+{{- end}}
+{{- end}}
+`
+
+const streamTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync/atomic"
+	
+	"github.com/jackc/pgx/v5"
+)
+
+// errStopIteration ends a stream when the consumer of an iterator stops early
+var errStopIteration = errors.New("stop iteration")
+
+// cursorSeq numbers the cursors so that nested streams do not clash
+var cursorSeq atomic.Uint64
+
+// stream runs query and calls each for every row as it is read
+func stream(ctx context.Context, db DBTX, query string, args []any, each func(pgx.Rows) error) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		if err := each(rows); err != nil {
+			return err
+		}
+	}
+	return mapError(rows.Err())
+}
+
+// streamWithCursor declares a cursor for query in a new transaction and calls each for
+// every row, fetching fetchSize rows at a time. The transaction is rolled back at the end.
+func streamWithCursor(ctx context.Context, db DBTX, query string, args []any, fetchSize int, each func(pgx.Rows) error) error {
+	tx, q, err := beginStream(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	
+	cursor := fmt.Sprintf("pgx_goose_cursor_%d", cursorSeq.Add(1))
+	if _, err := q.Exec(ctx, "DECLARE "+cursor+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return mapError(err)
+	}
+	
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", fetchSize, cursor)
+	for {
+		n := 0
+		err := stream(ctx, q, fetch, nil, func(rows pgx.Rows) error {
+			n++
+			return each(rows)
+		})
+		if err != nil || n < fetchSize {
+			return err
+		}
+	}
+}
+
+// wrappedDB is implemented by the queriers wrapping another one
+type wrappedDB interface {
+	unwrap() DBTX
+	rewrap(db DBTX) DBTX
+}
+
+// beginStream starts the transaction holding a cursor and returns it along with the
+// querier to use in it, which is wrapped like db
+func beginStream(ctx context.Context, db DBTX) (pgx.Tx, DBTX, error) {
+	var wrappers []wrappedDB
+	for {
+		w, ok := db.(wrappedDB)
+		if !ok {
+			break
+		}
+		wrappers = append(wrappers, w)
+		db = w.unwrap()
+	}
+	
+	beginner, ok := db.(interface {
+		Begin(ctx context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return nil, nil, errors.New("cursor requires a querier able to begin a transaction")
+	}
+	
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin cursor transaction: %w", err)
+	}
+	
+	var q DBTX = tx
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		q = wrappers[i].rewrap(q)
+	}
+	return tx, q, nil
+}
+
+// seq turns a callback based stream into an iterator. An error ends the iteration
+// and is yielded with a nil value.
+func seq[T any](each func(fn func(T) error) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := each(func(v T) error {
+			if !yield(v, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+`
+
+const dbTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX is the querier used by the repositories.
+// It is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx, so the same
+// repository code runs both on a connection pool and inside a transaction.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+var (
+	_ DBTX = (*pgxpool.Pool)(nil)
+	_ DBTX = (*pgx.Conn)(nil)
+	_ DBTX = (pgx.Tx)(nil)
+)
+`
+
+const queryTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+)
+
+// whereBuilder accumulates the conditions and arguments of a dynamic query.
+// Column names always come from generated code, values are always bound as arguments.
+type whereBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg binds value and returns its placeholder
+func (w *whereBuilder) arg(value any) string {
+	w.args = append(w.args, value)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// add appends a condition whose %s verb is replaced by the placeholder of value
+func (w *whereBuilder) add(condition string, value any) {
+	w.conditions = append(w.conditions, fmt.Sprintf(condition, w.arg(value)))
+}
+
+// SQL returns the WHERE clause, or an empty string when there are no conditions
+func (w *whereBuilder) SQL() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// insertBuilder accumulates the columns and arguments of an INSERT statement.
+// Column names always come from generated code, values are always bound as arguments.
+type insertBuilder struct {
+	columns []string
+	values  []string
+	args    []any
+}
+
+// set inserts value in column
+func (b *insertBuilder) set(column string, value any) {
+	b.args = append(b.args, value)
+	b.setSQL(column, fmt.Sprintf("$%d", len(b.args)))
+}
+
+// setSQL inserts the result of a generated SQL expression in column
+func (b *insertBuilder) setSQL(column, expr string) {
+	b.columns = append(b.columns, column)
+	b.values = append(b.values, expr)
+}
+
+// setUnlessZero inserts value in column unless it is the zero value of its type,
+// leaving the column to its default
+func setUnlessZero[T any](b *insertBuilder, column string, value T) {
+	if !reflect.ValueOf(&value).Elem().IsZero() {
+		b.set(column, value)
+	}
+}
+
+// SQL returns the columns and values of the INSERT, DEFAULT VALUES when there are none
+func (b *insertBuilder) SQL() string {
+	if len(b.columns) == 0 {
+		return "DEFAULT VALUES"
+	}
+	return "(" + strings.Join(b.columns, ", ") + ") VALUES (" + strings.Join(b.values, ", ") + ")"
+}
+
+// appendValue writes the placeholder of value in a row of a multi-row INSERT
+func appendValue(query *strings.Builder, args *[]any, value any) {
+	*args = append(*args, value)
+	fmt.Fprintf(query, "$%d", len(*args))
+}
+
+// appendValueUnlessZero writes the placeholder of value unless it is the zero value of its
+// type, writing DEFAULT instead so that the database writes the default of the column
+func appendValueUnlessZero[T any](query *strings.Builder, args *[]any, value T) {
+	if reflect.ValueOf(&value).Elem().IsZero() {
+		query.WriteString("DEFAULT")
+		return
+	}
+	appendValue(query, args, value)
+}
+
+// appendFilter adds the predicates set in f for column
+func appendFilter[T any](w *whereBuilder, column string, f *models.Filter[T]) {
+	if f == nil {
+		return
+	}
+	if f.Eq != nil {
+		w.add(column+" = %s", *f.Eq)
+	}
+	if f.In != nil {
+		w.add(column+" = ANY(%s)", f.In)
+	}
+	if f.Lt != nil {
+		w.add(column+" < %s", *f.Lt)
+	}
+	if f.Gt != nil {
+		w.add(column+" > %s", *f.Gt)
+	}
+	if f.IsNull != nil {
+		if *f.IsNull {
+			w.conditions = append(w.conditions, column+" IS NULL")
+		} else {
+			w.conditions = append(w.conditions, column+" IS NOT NULL")
+		}
+	}
+}
+
+// appendStringFilter adds the predicates set in f for a text column
+func appendStringFilter(w *whereBuilder, column string, f *models.StringFilter) {
+	if f == nil {
+		return
+	}
+	appendFilter(w, column, &f.Filter)
+	if f.Like != nil {
+		w.add(column+" LIKE %s", *f.Like)
+	}
+}
+
+// appendTimeFilter adds the predicates set in f for a time column
+func appendTimeFilter(w *whereBuilder, column string, f *models.TimeFilter) {
+	if f == nil {
+		return
+	}
+	appendFilter(w, column, &f.Filter)
+	if f.From != nil {
+		w.add(column+" >= %s", *f.From)
+	}
+	if f.To != nil {
+		w.add(column+" < %s", *f.To)
+	}
+}
+
+// orderByClause validates the requested sort fields and returns the ORDER BY clause.
+// The tie-break column, the primary key, is appended so that pagination is deterministic.
+func orderByClause[F interface {
+	~string
+	Valid() bool
+}](sorts []models.Sort[F], tieBreak string) (string, error) {
+	parts := make([]string, 0, len(sorts)+1)
+	hasTieBreak := false
+	for _, sort := range sorts {
+		if !sort.Field.Valid() {
+			return "", fmt.Errorf("invalid sort field %q", string(sort.Field))
+		}
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		parts = append(parts, string(sort.Field)+" "+direction)
+		hasTieBreak = hasTieBreak || string(sort.Field) == tieBreak
+	}
+	if !hasTieBreak {
+		parts = append(parts, tieBreak+" ASC")
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+`
+
+const cursorTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+)
+
+// cursorPayload is the content of an opaque keyset cursor
+type cursorPayload struct {
+	Values   []json.RawMessage ` + "`json:\"v\"`" + ` // Sort key of the row the page starts after
+	Backward bool              ` + "`json:\"b,omitempty\"`" + ` // Whether the page precedes that row
+}
+
+// encodeCursor builds an opaque cursor from the sort key of a row
+func encodeCursor(backward bool, values ...any) (string, error) {
+	payload := cursorPayload{Backward: backward}
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor: %w", err)
+		}
+		payload.Values = append(payload.Values, raw)
+	}
+	
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor reads the sort key of a cursor into dest and reports its direction
+func decodeCursor(cursor string, dest ...any) (bool, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false, fmt.Errorf("invalid cursor: %w", err)
+	}
+	
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return false, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(payload.Values) != len(dest) {
+		return false, fmt.Errorf("invalid cursor: expected %d values, got %d", len(dest), len(payload.Values))
+	}
+	
+	for i, raw := range payload.Values {
+		if err := json.Unmarshal(raw, dest[i]); err != nil {
+			return false, fmt.Errorf("invalid cursor: %w", err)
+		}
+	}
+	return payload.Backward, nil
+}
+
+// newPage builds a page from rows fetched with one extra row to detect further results.
+// Backward pages are fetched in reverse order and restored to ascending order here.
+func newPage[T any](items []T, limit int, hasCursor, backward bool, key func(T) []any) (*models.Page[T], error) {
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	
+	page := &models.Page[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	
+	var err error
+	if backward || hasMore {
+		if page.Next, err = encodeCursor(false, key(items[len(items)-1])...); err != nil {
+			return nil, err
+		}
+	}
+	if (backward && hasMore) || (!backward && hasCursor) {
+		if page.Prev, err = encodeCursor(true, key(items[0])...); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+`
+
+const contextTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import "context"
+
+// contextKey identifies the repository options carried by a context
+type contextKey int
+
+const (
+	withDeletedKey contextKey = iota
+	operationKey
+	txAttemptKey
+	usePrimaryKey
+	cursorFetchSizeKey
+{{- if .Tenancy}}
+	tenantKey
+{{- end}}
+)
+
+// WithDeleted returns a context whose reads also return soft deleted rows
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withDeletedKey, true)
+}
+
+// includeDeleted reports whether reads made with ctx return soft deleted rows
+func includeDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(withDeletedKey).(bool)
+	return include
+}
+
+// notDeleted returns the condition excluding soft deleted rows, or an empty string
+// when ctx includes them
+func notDeleted(ctx context.Context, condition string) string {
+	if includeDeleted(ctx) {
+		return ""
+	}
+	return " " + condition
+}
+
+// WithPrimary returns a context whose reads go to the primary rather than a replica,
+// e.g. to read back a write before it reached the replicas
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey, true)
+}
+
+// usePrimary reports whether reads made with ctx go to the primary
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(usePrimaryKey).(bool)
+	return primary
+}
+
+// WithCursor returns a context whose streaming reads go through a server-side cursor,
+// fetching fetchSize rows at a time, instead of a single query. The cursor lives in a
+// transaction, a savepoint when the repository already runs in one.
+func WithCursor(ctx context.Context, fetchSize int) context.Context {
+	return context.WithValue(ctx, cursorFetchSizeKey, fetchSize)
+}
+
+// cursorFetchSize returns the fetch size of the cursor streaming reads made with ctx
+// go through, or 0 when they do not use a cursor
+func cursorFetchSize(ctx context.Context) int {
+	fetchSize, _ := ctx.Value(cursorFetchSizeKey).(int)
+	return fetchSize
+}
+`
+
+const errorsTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNotFound is returned when the row read, updated or deleted does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrStaleObject is returned by updates and deletes guarded by a version when the row
+// was changed or deleted since it was read
+var ErrStaleObject = errors.New("stale object: the row was modified or deleted concurrently")
+
+// ErrSerialization is returned when PostgreSQL aborts a transaction because of a
+// serialization failure or a deadlock. The transaction can be retried as a whole.
+var ErrSerialization = errors.New("serialization failure")
+
+// ErrUniqueViolation is returned when a write conflicts with a unique constraint
+type ErrUniqueViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string // Columns covered by the constraint, when known
+	Err        *pgconn.PgError
+}
+
+func (e *ErrUniqueViolation) Error() string {
+	return violationMessage("unique", e.Table, e.Constraint, e.Columns)
+}
+
+func (e *ErrUniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrForeignKeyViolation is returned when a write references a missing row,
+// or deletes a row that is still referenced
+type ErrForeignKeyViolation struct {
+	Table      string
+	Constraint string
+	Columns    []string // Referencing columns, when known
+	Err        *pgconn.PgError
+}
+
+func (e *ErrForeignKeyViolation) Error() string {
+	return violationMessage("foreign key", e.Table, e.Constraint, e.Columns)
+}
+
+func (e *ErrForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrCheckViolation is returned when a written row fails a check constraint
+type ErrCheckViolation struct {
+	Table      string
+	Constraint string
+	Err        *pgconn.PgError
+}
+
+func (e *ErrCheckViolation) Error() string {
+	return violationMessage("check", e.Table, e.Constraint, nil)
+}
+
+func (e *ErrCheckViolation) Unwrap() error {
+	return e.Err
+}
+
+// ErrNotNullViolation is returned when a NULL is written to a NOT NULL column
+type ErrNotNullViolation struct {
+	Table  string
+	Column string
+	Err    *pgconn.PgError
+}
+
+func (e *ErrNotNullViolation) Error() string {
+	return fmt.Sprintf("not null violation on %s.%s", e.Table, e.Column)
+}
+
+func (e *ErrNotNullViolation) Unwrap() error {
+	return e.Err
+}
+
+// constraintColumns lists the columns of the unique and foreign key constraints,
+// keyed by table and constraint name
+var constraintColumns = map[string][]string{
+{{- range .Tables}}{{$table := .Table.Name}}
+{{- range .Constraints}}
+	"{{$table}}.{{.Name}}": { {{- range $i, $col := .Columns}}{{if $i}}, {{end}}"{{$col}}"{{end -}} },
+{{- end}}
+{{- end}}
+}
+
+// mapError translates pgx errors into the errors above using their SQLSTATE code.
+// Other errors, including nil, are returned unchanged.
+func mapError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return &ErrUniqueViolation{
+			Table:      pgErr.TableName,
+			Constraint: pgErr.ConstraintName,
+			Columns:    constraintColumns[pgErr.TableName+"."+pgErr.ConstraintName],
+			Err:        pgErr,
+		}
+	case "23503": // foreign_key_violation
+		return &ErrForeignKeyViolation{
+			Table:      pgErr.TableName,
+			Constraint: pgErr.ConstraintName,
+			Columns:    constraintColumns[pgErr.TableName+"."+pgErr.ConstraintName],
+			Err:        pgErr,
+		}
+	case "23514": // check_violation
+		return &ErrCheckViolation{Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Err: pgErr}
+	case "23502": // not_null_violation
+		return &ErrNotNullViolation{Table: pgErr.TableName, Column: pgErr.ColumnName, Err: pgErr}
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return fmt.Errorf("%w: %w", ErrSerialization, pgErr)
+	}
+	return err
+}
+
+func violationMessage(kind, table, constraint string, columns []string) string {
+	msg := fmt.Sprintf("%s violation on %s constraint %s", kind, table, constraint)
+	if len(columns) > 0 {
+		msg += " (" + strings.Join(columns, ", ") + ")"
+	}
+	return msg
+}
+`
+
+const optionsTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+// Option configures the repositories and the transaction manager
+type Option func(*options)
+
+type options struct {
+	hooks    Hooks
+	retry    RetryPolicy
+	replicas []DBTX
+}
+
+func newOptions(opts []Option) options {
+	o := options{retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHooks observes every query run by the repositories with hooks
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+// WithReplicas sends the reads of the repositories to the given replicas in turn.
+// Writes, and reads made with a context from WithPrimary, go to the primary querier
+// the repositories are created with. Transactions never use the replicas.
+func WithReplicas(replicas ...DBTX) Option {
+	return func(o *options) {
+		o.replicas = replicas
+	}
+}
+
+// WithRetryPolicy sets how TxManager.RunInTxWithRetry retries failed transactions
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+`
+
+const hooksTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Hooks observes the queries run by the repositories, e.g. for tracing, metrics or logging.
+// op is the repository method running the query and table the table it belongs to.
+type Hooks interface {
+	// BeforeQuery is called before a query is sent. The returned context is used
+	// to run the query and is passed to AfterQuery.
+	BeforeQuery(ctx context.Context, op, table, sql string, args []any) context.Context
+	
+	// AfterQuery is called once the query completed. For queries returning rows
+	// the duration includes reading them and err is the error ending the iteration.
+	AfterQuery(ctx context.Context, op, table, sql string, args []any, duration time.Duration, err error)
+}
+
+// withOperation records the repository method running the following queries
+func withOperation(ctx context.Context, hooks Hooks, op string) context.Context {
+	if hooks == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, operationKey, op)
+}
+
+// withHooks wraps the querier of a repository so that hooks observe its queries
+func withHooks(db DBTX, hooks Hooks, table string) DBTX {
+	if hooks == nil {
+		return db
+	}
+	return &hookedDB{db: db, hooks: hooks, table: table}
+}
+
+// hookedDB is a DBTX calling hooks around every query.
+// Batches are sent as is, their queries are not observed.
+type hookedDB struct {
+	db    DBTX
+	hooks Hooks
+	table string
+}
+
+func (h *hookedDB) before(ctx context.Context, sql string, args []any) (context.Context, func(error)) {
+	op, _ := ctx.Value(operationKey).(string)
+	start := time.Now()
+	ctx = h.hooks.BeforeQuery(ctx, op, h.table, sql, args)
+	
+	return ctx, func(err error) {
+		h.hooks.AfterQuery(ctx, op, h.table, sql, args, time.Since(start), err)
+	}
+}
+
+func (h *hookedDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, done := h.before(ctx, sql, args)
+	tag, err := h.db.Exec(ctx, sql, args...)
+	done(err)
+	return tag, err
+}
+
+func (h *hookedDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, done := h.before(ctx, sql, args)
+	rows, err := h.db.Query(ctx, sql, args...)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+	return &hookedRows{Rows: rows, done: done}, nil
+}
+
+func (h *hookedDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, done := h.before(ctx, sql, args)
+	return &hookedRow{row: h.db.QueryRow(ctx, sql, args...), done: done}
+}
+
+func (h *hookedDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return h.db.SendBatch(ctx, b)
+}
+
+func (h *hookedDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	ctx, done := h.before(ctx, "COPY "+tableName.Sanitize()+" FROM STDIN", nil)
+	n, err := h.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	done(err)
+	return n, err
+}
+
+func (h *hookedDB) unwrap() DBTX {
+	return h.db
+}
+
+func (h *hookedDB) rewrap(db DBTX) DBTX {
+	return withHooks(db, h.hooks, h.table)
+}
+
+// hookedRows reports the end of a query once its rows are exhausted or closed
+type hookedRows struct {
+	pgx.Rows
+	done     func(error)
+	finished bool
+}
+
+func (r *hookedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *hookedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *hookedRows) finish() {
+	if !r.finished {
+		r.finished = true
+		r.done(r.Rows.Err())
+	}
+}
+
+// hookedRow reports the end of a query once its row is scanned
+type hookedRow struct {
+	row  pgx.Row
+	done func(error)
+}
+
+func (r *hookedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.done(err)
+	return err
+}
+`
+
+const hooksOTelTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+//go:build otel
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelHooks records an OpenTelemetry client span per query
+type OTelHooks struct {
+	tracer trace.Tracer
+}
+
+// NewOTelHooks creates hooks starting spans with the given tracer
+func NewOTelHooks(tracer trace.Tracer) *OTelHooks {
+	return &OTelHooks{tracer: tracer}
+}
+
+// BeforeQuery starts the span of a query
+func (h *OTelHooks) BeforeQuery(ctx context.Context, op, table, sql string, args []any) context.Context {
+	ctx, _ = h.tracer.Start(ctx, table+"."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", op),
+			attribute.String("db.sql.table", table),
+			attribute.String("db.statement", sql),
+		),
+	)
+	return ctx
+}
+
+// AfterQuery ends the span of a query, recording its error. A missing row is not an error.
+func (h *OTelHooks) AfterQuery(ctx context.Context, op, table, sql string, args []any, duration time.Duration, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+var _ Hooks = (*OTelHooks)(nil)
+`
+
+const hooksPrometheusTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+//go:build prometheus
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusHooks observes the duration of every query in a histogram
+// labelled by table, operation and status ("ok" or "error")
+type PrometheusHooks struct {
+	duration *prometheus.HistogramVec
+}
+
+// NewPrometheusHooks creates hooks registering their histogram with registerer
+func NewPrometheusHooks(registerer prometheus.Registerer) (*PrometheusHooks, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "pgx_goose",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of the queries run by the generated repositories.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"table", "op", "status"})
+	
+	if err := registerer.Register(duration); err != nil {
+		return nil, err
+	}
+	return &PrometheusHooks{duration: duration}, nil
+}
+
+// BeforeQuery does nothing, durations are measured by the repositories
+func (h *PrometheusHooks) BeforeQuery(ctx context.Context, op, table, sql string, args []any) context.Context {
+	return ctx
+}
+
+// AfterQuery observes the duration of a query. A missing row is not an error.
+func (h *PrometheusHooks) AfterQuery(ctx context.Context, op, table, sql string, args []any, duration time.Duration, err error) {
+	status := "ok"
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		status = "error"
+	}
+	h.duration.WithLabelValues(table, op, status).Observe(duration.Seconds())
+}
+
+var _ Hooks = (*PrometheusHooks)(nil)
+`
+
+const replicasTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// replicaSet spreads the reads of a repository over its replicas in a round-robin
+type replicaSet struct {
+	replicas []DBTX
+	next     atomic.Uint64
+}
+
+// newReplicaSet returns the replicas of a repository, or nil when it has none
+func newReplicaSet(replicas []DBTX, hooks Hooks, table string) *replicaSet {
+	if len(replicas) == 0 {
+		return nil
+	}
+	
+	s := &replicaSet{replicas: make([]DBTX, len(replicas))}
+	for i, replica := range replicas {
+		s.replicas[i] = {{if .Tenancy}}withTenancy({{end}}withHooks(replica, hooks, table){{if .Tenancy}}){{end}}
+	}
+	return s
+}
+
+// reader returns the querier for a read: the next replica, or the primary when
+// there are no replicas or ctx requires it
+func (s *replicaSet) reader(ctx context.Context, primary DBTX) DBTX {
+	if s == nil || usePrimary(ctx) {
+		return primary
+	}
+	n := s.next.Add(1) - 1
+	return s.replicas[n%uint64(len(s.replicas))]
+}
+`
+
+const batchTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	
+	"github.com/jackc/pgx/v5"
+{{- range .BatchImports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+)
+
+// ErrBatchAborted is reported by the operations of a batch that were rolled back
+// because another operation of the batch failed
+var ErrBatchAborted = errors.New("batch aborted")
+
+// BatchBuilder queues writes to several tables and sends them in a single round trip.
+// The batch runs in an implicit transaction, or in the transaction of the querier it
+// is sent with, so that a failing statement rolls back every other one.
+type BatchBuilder struct {
+	ops []batchOp
+}
+
+// BatchResult is the outcome of a queued operation, known once the batch was sent
+type BatchResult struct {
+	err error
+}
+
+// Err returns the error of the operation: ErrNotFound or ErrStaleObject when it matched
+// no row, ErrBatchAborted when it was rolled back because of another operation, or the
+// error of its statement
+func (r *BatchResult) Err() error {
+	return r.err
+}
+
+type batchOp struct {
+	query  string
+	args   []any
+	read   func(results pgx.BatchResults) error
+	result *BatchResult
+}
+
+// NewBatchBuilder creates an empty batch
+func NewBatchBuilder() *BatchBuilder {
+	return &BatchBuilder{}
+}
+
+// Len returns the number of queued operations
+func (b *BatchBuilder) Len() int {
+	return len(b.ops)
+}
+
+func (b *BatchBuilder) queue(query string, args []any, read func(results pgx.BatchResults) error) *BatchResult {
+	result := &BatchResult{}
+	b.ops = append(b.ops, batchOp{query: query, args: args, read: read, result: result})
+	return result
+}
+
+// Send sends the queued operations with db and records their results. It returns the
+// first error of the operations, their results tell which ones failed. Operations that
+// matched no row do not abort the batch. The builder is emptied and can be reused.
+func (b *BatchBuilder) Send(ctx context.Context, db DBTX) error {
+	ops := b.ops
+	b.ops = nil
+	if len(ops) == 0 {
+		return nil
+	}
+	
+	batch := &pgx.Batch{}
+	for _, op := range ops {
+		batch.Queue(op.query, op.args...)
+	}
+	results := {{if .Tenancy}}withTenancy(db){{else}}db{{end}}.SendBatch(ctx, batch)
+	
+	var first error
+	aborted := false
+	for _, op := range ops {
+		if aborted {
+			op.result.err = ErrBatchAborted
+			continue
+		}
+		
+		err := op.read(results)
+		op.result.err = err
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		aborted = !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrStaleObject)
+	}
+	
+	if err := results.Close(); err != nil && first == nil {
+		first = mapError(err)
+		aborted = true
+	}
+	if aborted {
+		for _, op := range ops {
+			if op.result.err == nil {
+				op.result.err = ErrBatchAborted
+			}
+		}
+	}
+	
+	return first
+}
+
+// execAffecting reads the result of a statement expected to affect a row,
+// returning notAffected when it did not
+func execAffecting(results pgx.BatchResults, notAffected error) error {
+	tag, err := results.Exec()
+	if err != nil {
+		return mapError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return notAffected
+	}
+	return nil
+}
+{{- range .Tables}}
+{{- $var := lower .StructName}}{{$table := .}}
+
+// Create{{.StructName}} queues the creation of a {{.StructName}} like {{.StructName}}Repository.Create
+func (b *BatchBuilder) Create{{.StructName}}({{$var}} *models.{{.StructName}}) *BatchResult {
+	query, args := create{{.StructName}}Statement({{$var}})
+	return b.queue(query, args, func(results pgx.BatchResults) error {
+		{{- if .PrimaryKeyCol}}
+		return mapError(results.QueryRow().Scan({{range $i, $col := .CreateReturning}}{{if $i}}, {{end}}&{{$var}}.{{toPascalCase .Name}}{{end}}))
+		{{- else}}
+		_, err := results.Exec()
+		return mapError(err)
+		{{- end}}
+	})
+}
+
+// Update{{.StructName}} queues the update of a {{.StructName}} like {{.StructName}}Repository.Update
+func (b *BatchBuilder) Update{{.StructName}}({{$var}} *models.{{.StructName}}) *BatchResult {
+	args := []any{
+		{{- range .UpdateColumns}}
+		{{$var}}.{{toPascalCase .Name}},{{end}}
+		{{$var}}.{{toPascalCase .PrimaryKeyCol}},
+		{{- with .Version}}
+		{{$var}}.{{.Field}},
+		{{- end}}
+	}
+	return b.queue(update{{.StructName}}Query, args, func(results pgx.BatchResults) error {
+		{{- if .Version}}
+		err := results.QueryRow().Scan(&{{$var}}.{{.Version.Field}})
+		if err == pgx.ErrNoRows {
+			return ErrStaleObject
+		}
+		return mapError(err)
+		{{- else}}
+		return execAffecting(results, ErrNotFound)
+		{{- end}}
+	})
+}
+
+// Delete{{.StructName}} queues the deletion of a {{.StructName}} like {{.StructName}}Repository.Delete
+func (b *BatchBuilder) Delete{{.StructName}}(id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) *BatchResult {
+	return b.queue(delete{{.StructName}}Query, []any{id{{if .Version}}, version{{end}}}, func(results pgx.BatchResults) error {
+		return execAffecting(results, {{if .Version}}ErrStaleObject{{else}}ErrNotFound{{end}})
+	})
+}
+{{- end}}
+`
+
+const notifyTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+)
+
+// ChangeOp is the kind of change notified for a row
+type ChangeOp string
+
+const (
+	ChangeInsert ChangeOp = "INSERT"
+	ChangeUpdate ChangeOp = "UPDATE"
+	ChangeDelete ChangeOp = "DELETE"
+)
+
+// subscribe listens to channel on conn and passes the payload of every notification to
+// handle until ctx is done. A lost connection is reopened with the configuration of conn,
+// the changes made while disconnected are not notified. The subscription owns conn and
+// closes it at the end, then calls done.
+func subscribe(ctx context.Context, conn *pgx.Conn, channel string, handle func(payload string), done func()) error {
+	listenSQL := "LISTEN " + pgx.Identifier{channel}.Sanitize()
+	if _, err := conn.Exec(ctx, listenSQL); err != nil {
+		return fmt.Errorf("failed to listen to %s: %w", channel, err)
+	}
+	config := conn.Config()
+	
+	go func() {
+		defer done()
+		for {
+			notification, err := conn.WaitForNotification(ctx)
+			if err == nil {
+				handle(notification.Payload)
+				continue
+			}
+			
+			_ = conn.Close(context.Background())
+			if conn = reconnect(ctx, config, listenSQL); conn == nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// reconnect opens a new listening connection, retrying with an increasing delay
+// until it succeeds. It returns nil once ctx is done.
+func reconnect(ctx context.Context, config *pgx.ConnConfig, listenSQL string) *pgx.Conn {
+	delay := 100 * time.Millisecond
+	for ctx.Err() == nil {
+		conn, err := pgx.ConnectConfig(ctx, config)
+		if err == nil {
+			if _, err = conn.Exec(ctx, listenSQL); err == nil {
+				return conn
+			}
+			_ = conn.Close(context.Background())
+		}
+		
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		delay = min(2*delay, 30*time.Second)
+	}
+	return nil
+}
+{{- range .Tables}}
+
+// {{.StructName}}ChangeEvent is a change of a {{.Table.Name}} row
+type {{.StructName}}ChangeEvent struct {
+	Op  ChangeOp ` + "`" + `json:"op"` + "`" + ` // Kind of change
+	ID  {{.PrimaryKeyType}} ` + "`" + `json:"id"` + "`" + ` // Primary key of the changed row
+	Row *models.{{.StructName}} ` + "`" + `json:"row"` + "`" + ` // New row or deleted row, nil when it did not fit in the notification
+	Err error ` + "`" + `json:"-"` + "`" + ` // Error decoding the notification, the other fields are then unset
+}
+
+// Subscribe{{.StructName}}Changes listens to the changes of the {{.Table.Name}} table notified by the trigger
+// of the notify_changes migration. The subscription owns conn, which must not be used for anything
+// else, and closes it when ctx is done before closing the returned channel.
+func Subscribe{{.StructName}}Changes(ctx context.Context, conn *pgx.Conn) (<-chan {{.StructName}}ChangeEvent, error) {
+	events := make(chan {{.StructName}}ChangeEvent)
+	handle := func(payload string) {
+		var event {{.StructName}}ChangeEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			event = {{.StructName}}ChangeEvent{Err: fmt.Errorf("failed to decode {{.Table.Name}} change: %w", err)}
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	
+	if err := subscribe(ctx, conn, "{{.Table.Name}}_changes", handle, func() { close(events) }); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+{{- end}}
+`
+
+const outboxTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+)
+{{- $aggregates := false}}{{range .Tables}}{{if .Outbox}}{{$aggregates = true}}{{end}}{{end}}
+{{- if $aggregates}}
+
+// Types of the events appended to the outbox by the writes of the aggregates
+const (
+{{- range .Tables}}{{if .Outbox}}
+	{{.StructName}}CreatedEvent = "{{.Outbox.EventType "created"}}"
+	{{.StructName}}UpdatedEvent = "{{.Outbox.EventType "updated"}}"
+	{{.StructName}}DeletedEvent = "{{.Outbox.EventType "deleted"}}"
+{{- if .SoftDelete}}
+	{{.StructName}}RestoredEvent = "{{.Outbox.EventType "restored"}}"
+{{- end}}
+{{- end}}{{end}}
+)
+{{- end}}
+
+const (
+	appendOutboxEventQuery = ` + "`INSERT INTO {{.OutboxRef}} (aggregate_type, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4)`" + `
+	lockOutboxEventsQuery = ` + "`" + `
+		SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at
+		FROM {{.OutboxRef}}
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	` + "`" + `
+	deleteOutboxEventsQuery = ` + "`DELETE FROM {{.OutboxRef}} WHERE id = ANY($1)`" + `
+)
+
+// OutboxEvent is an event appended to the outbox
+type OutboxEvent struct {
+	ID            int64
+	AggregateType string // Type of the aggregate, the name of its table for the generated events
+	AggregateID   string
+	EventType     string
+	Payload       json.RawMessage // The row written for the generated events
+	CreatedAt     time.Time
+}
+
+// Publisher publishes the events relayed from the outbox, to a message broker for instance.
+// An event is removed from the outbox once published, a relay failing before that publishes
+// it again so consumers must tolerate duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, event OutboxEvent) error
+}
+
+// Outbox appends events to the outbox. The write methods of the aggregate repositories
+// append theirs in the statement writing the rows, except CreateMany, the Outbox records
+// the other events of an application.
+type Outbox struct {
+	db    DBTX
+	hooks Hooks
+}
+
+// NewOutbox creates an outbox appending events with the given querier. Give it the
+// transaction of the writes an event describes so that both are committed together.
+func NewOutbox(db DBTX, opts ...Option) *Outbox {
+	o := newOptions(opts)
+	return &Outbox{db: {{if .Tenancy}}withTenancy({{end}}withHooks(db, o.hooks, "{{.Outbox}}"){{if .Tenancy}}){{end}}, hooks: o.hooks}
+}
+
+// Append appends an event to the outbox, payload is encoded as JSON
+func (o *Outbox) Append(ctx context.Context, aggregateType, aggregateID, eventType string, payload any) error {
+	ctx = withOperation(ctx, o.hooks, "Append")
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event payload: %w", eventType, err)
+	}
+	
+	_, err = o.db.Exec(ctx, appendOutboxEventQuery, aggregateType, aggregateID, eventType, string(data))
+	return mapError(err)
+}
+
+// RelayOption configures a Relay
+type RelayOption func(*relayOptions)
+
+type relayOptions struct {
+	batchSize    int
+	pollInterval time.Duration
+	onError      func(error)
+}
+
+// WithBatchSize sets the maximum number of events published in a transaction, 100 by default
+func WithBatchSize(size int) RelayOption {
+	return func(o *relayOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithPollInterval sets how long the relay waits before polling an empty outbox again,
+// or retrying a failed batch, one second by default
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithErrorHandler passes the errors of the relay to handler, they are dropped by default
+func WithErrorHandler(handler func(error)) RelayOption {
+	return func(o *relayOptions) {
+		o.onError = handler
+	}
+}
+
+// Relay hands the events of the outbox to a Publisher in the order they were appended.
+// Several relays can share an outbox, each locking the events it publishes, but the
+// events of an aggregate may then be published out of order.
+{{- if eq .Tenancy "schema"}}
+// A relay publishes the outbox of the tenant schema of the context it runs with.
+{{- end}}
+type Relay struct {
+	db        TxBeginner
+	publisher Publisher
+	options   relayOptions
+}
+
+// NewRelay creates a relay publishing the events of the outbox with publisher
+func NewRelay(db TxBeginner, publisher Publisher, opts ...RelayOption) *Relay {
+	o := relayOptions{batchSize: 100, pollInterval: time.Second, onError: func(error) {}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Relay{db: db, publisher: publisher, options: o}
+}
+
+// Run relays the events until ctx is done and returns its error. Batches follow each
+// other while the outbox is full, the failed ones are retried after the poll interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		published, err := r.RelayBatch(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			r.options.onError(err)
+		} else if published == r.options.batchSize {
+			continue
+		}
+		
+		timer := time.NewTimer(r.options.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RelayBatch publishes the oldest events of the outbox not locked by another relay and
+// removes them in a single transaction. It returns the number of events published, the
+// first failure to publish ends the batch.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	
+	// Rollback is a no-op once the transaction has been committed
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	
+	events, err := lockOutboxEvents(ctx, {{if .Tenancy}}withTenancy(tx){{else}}tx{{end}}, r.options.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	
+	var published []int64
+	var publishErr error
+	for _, event := range events {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			publishErr = fmt.Errorf("failed to publish outbox event %d: %w", event.ID, err)
+			break
+		}
+		published = append(published, event.ID)
+	}
+	if len(published) == 0 {
+		return 0, publishErr
+	}
+	
+	if _, err := {{if .Tenancy}}withTenancy(tx){{else}}tx{{end}}.Exec(ctx, deleteOutboxEventsQuery, published); err != nil {
+		return 0, mapError(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(published), publishErr
+}
+
+// lockOutboxEvents locks and returns the oldest events of the outbox not locked yet
+func lockOutboxEvents(ctx context.Context, db DBTX, limit int) ([]OutboxEvent, error) {
+	rows, err := db.Query(ctx, lockOutboxEventsQuery, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	var events []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateType,
+			&event.AggregateID,
+			&event.EventType,
+			&event.Payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+	return events, nil
+}
+`
+
+const auditTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+	"time"
+{{- range .Audit.Imports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/jackc/pgx/v5"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+)
+
+// {{.StructName}}Version is a version of a {{.StructName}} recorded in {{.Audit.HistoryTable}}
+type {{.StructName}}Version struct {
+	Row       *models.{{.StructName}} // Row as written, or as it was when deleted
+	Operation string    // INSERT, UPDATE or DELETE
+	ChangedAt time.Time // Time of the change
+	ChangedBy *string   // User making the change, nil when not captured
+}
+
+// {{.StructName}}HistoryRepository reads the versions of the {{.StructName}} rows recorded by
+// the audit trigger of {{.Table.Name}}
+type {{.StructName}}HistoryRepository struct {
+	db       DBTX
+	replicas *replicaSet
+	hooks    Hooks
+}
+
+// New{{.StructName}}HistoryRepository creates a new {{.StructName}} history repository
+func New{{.StructName}}HistoryRepository(db DBTX, opts ...Option) *{{.StructName}}HistoryRepository {
+	o := newOptions(opts)
+	return &{{.StructName}}HistoryRepository{
+		db:       {{if .Tenancy}}withTenancy({{end}}withHooks(db, o.hooks, "{{.Audit.HistoryTable}}"){{if .Tenancy}}){{end}},
+		replicas: newReplicaSet(o.replicas, o.hooks, "{{.Audit.HistoryTable}}"),
+		hooks:    o.hooks,
+	}
+}
+
+// reader returns the querier for reads, a replica unless ctx requires the primary
+func (r *{{.StructName}}HistoryRepository) reader(ctx context.Context) DBTX {
+	return r.replicas.reader(ctx, r.db)
+}
+
+// History returns the versions of the {{.StructName}} with the given ID, oldest first.
+// Rows existing before the audit was enabled start with the version recorded then.
+func (r *{{.StructName}}HistoryRepository) History(ctx context.Context, id {{.PrimaryKeyType}}) ([]*{{.StructName}}Version, error) {
+	ctx = withOperation(ctx, r.hooks, "History")
+	query := ` + "`" + `
+		SELECT history_operation, history_changed_at, history_changed_by, {{range $i, $col := .Audit.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		FROM {{.Audit.HistoryRef}}
+		WHERE {{.PrimaryKeyCol}} = $1{{with .Tenant}} AND {{.Condition}}{{end}}
+		ORDER BY history_id
+	` + "`" + `
+	
+	rows, err := r.reader(ctx).Query(ctx, query, id)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	
+	var versions []*{{.StructName}}Version
+	for rows.Next() {
+		version := &{{.StructName}}Version{Row: &models.{{.StructName}}{}}
+		err := rows.Scan(
+			&version.Operation,
+			&version.ChangedAt,
+			&version.ChangedBy,
+			{{- range .Audit.Columns}}
+			&version.Row.{{toPascalCase .Name}},{{end}}
+		)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err)
+	}
+	
+	return versions, nil
+}
+
+// AsOf returns the {{.StructName}} with the given ID as it was at the given time,
+// ErrNotFound is returned when it did not exist then
+func (r *{{.StructName}}HistoryRepository) AsOf(ctx context.Context, id {{.PrimaryKeyType}}, at time.Time) (*models.{{.StructName}}, error) {
+	ctx = withOperation(ctx, r.hooks, "AsOf")
+	query := ` + "`" + `
+		SELECT history_operation, {{range $i, $col := .Audit.Columns}}{{if $i}}, {{end}}{{.Name}}{{end}}
+		FROM {{.Audit.HistoryRef}}
+		WHERE {{.PrimaryKeyCol}} = $1 AND history_changed_at <= $2{{with .Tenant}} AND {{.Condition}}{{end}}
+		ORDER BY history_id DESC
+		LIMIT 1
+	` + "`" + `
+	
+	var operation string
+	{{lower .StructName}} := &models.{{.StructName}}{}
+	err := r.reader(ctx).QueryRow(ctx, query, id, at).Scan(
+		&operation,
+		{{- range .Audit.Columns}}
+		&{{lower $.StructName}}.{{toPascalCase .Name}},{{end}}
+	)
+	if err == pgx.ErrNoRows || (err == nil && operation == "DELETE") {
+		return nil, fmt.Errorf("{{lower .StructName}} with id %v as of %v: %w", id, at, ErrNotFound)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	
+	return {{lower .StructName}}, nil
+}
+`
+
+const auditChangedByTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangedBySetting is the session setting the audit triggers read the author of a change from
+const ChangedBySetting = "{{.Setting}}"
+
+// SetChangedBy records user as the author of the changes made by tx, the audit
+// triggers save it with the versions they record until tx ends
+func SetChangedBy(ctx context.Context, tx pgx.Tx, user string) error {
+	_, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", ChangedBySetting, user)
+	return mapError(err)
+}
+`
+
+const tenancyTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+{{- if eq .Tenancy "column"}}
+	"strconv"
+{{- end}}
+	"strings"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNoTenant is returned by the queries scoped to a tenant when their context has none
+var ErrNoTenant = errors.New("no tenant in context")
+{{- if eq .Tenancy "column"}}
+
+// TenantColumn is the column scoping the rows of a table to a tenant
+const TenantColumn = "{{.TenantColumn}}"
+
+// tenantParam marks the parameter holding the tenant ID in the generated SQL
+const tenantParam = "$tenant"
+
+// WithTenant returns a context scoping the repositories to the given tenant: the queries
+// of the tables having a {{.TenantColumn}} column only read and write the rows of the tenant,
+// and the rows they insert belong to it. Without a tenant those queries fail with ErrNoTenant.
+func WithTenant(ctx context.Context, tenantID any) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// TenantID returns the tenant of ctx
+func TenantID(ctx context.Context) (any, bool) {
+	tenantID := ctx.Value(tenantKey)
+	return tenantID, tenantID != nil
+}
+
+// bindTenant replaces the tenant markers of a query with a parameter holding the tenant of ctx
+func bindTenant(ctx context.Context, sql string, args []any) (string, []any, error) {
+	if !strings.Contains(sql, tenantParam) {
+		return sql, args, nil
+	}
+	tenantID, ok := TenantID(ctx)
+	if !ok {
+		return "", nil, ErrNoTenant
+	}
+	
+	// The arguments of the caller are left untouched
+	args = append(args[:len(args):len(args)], tenantID)
+	return strings.ReplaceAll(sql, tenantParam, "$"+strconv.Itoa(len(args))), args, nil
+}
+{{- else}}
+
+// tenantSchema marks the tables qualified with the schema of the tenant in the generated SQL
+const tenantSchema = "$schema."
+
+// WithTenantSchema returns a context running the queries of the repositories on the tables
+// of the given schema. Without a schema the queries fail with ErrNoTenant.
+func WithTenantSchema(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, tenantKey, schema)
+}
+
+// TenantSchema returns the tenant schema of ctx
+func TenantSchema(ctx context.Context) (string, bool) {
+	schema, _ := ctx.Value(tenantKey).(string)
+	return schema, schema != ""
+}
+
+// bindTenant qualifies the tables of a query with the schema of the tenant of ctx
+func bindTenant(ctx context.Context, sql string, args []any) (string, []any, error) {
+	if !strings.Contains(sql, tenantSchema) {
+		return sql, args, nil
+	}
+	schema, ok := TenantSchema(ctx)
+	if !ok {
+		return "", nil, ErrNoTenant
+	}
+	return strings.ReplaceAll(sql, tenantSchema, pgx.Identifier{schema}.Sanitize()+"."), args, nil
+}
+{{- end}}
+
+// withTenancy wraps a querier so that the queries of the repositories are bound to the
+// tenant of their context
+func withTenancy(db DBTX) DBTX {
+	return &tenantDB{db: db}
+}
+
+// tenantDB is a DBTX binding the tenant markers of every query before sending it,
+// a query having markers fails with ErrNoTenant when its context has no tenant
+type tenantDB struct {
+	db DBTX
+}
+
+func (t *tenantDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	sql, args, err := bindTenant(ctx, sql, args)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t *tenantDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	sql, args, err := bindTenant(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t *tenantDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	sql, args, err := bindTenant(ctx, sql, args)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+// SendBatch binds the queued queries in place, none is sent when one of them fails
+func (t *tenantDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	for _, query := range b.QueuedQueries {
+		sql, args, err := bindTenant(ctx, query.SQL, query.Arguments)
+		if err != nil {
+			return errBatchResults{err: err}
+		}
+		query.SQL, query.Arguments = sql, args
+	}
+	return t.db.SendBatch(ctx, b)
+}
+
+func (t *tenantDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	{{- if eq .Tenancy "schema"}}
+	if len(tableName) == 1 {
+		schema, ok := TenantSchema(ctx)
+		if !ok {
+			return 0, ErrNoTenant
+		}
+		tableName = pgx.Identifier{schema, tableName[0]}
+	}
+	{{- end}}
+	return t.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (t *tenantDB) unwrap() DBTX {
+	return t.db
+}
+
+func (t *tenantDB) rewrap(db DBTX) DBTX {
+	return withTenancy(db)
+}
+
+// errRow is the row of a query that could not be sent
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+// errBatchResults are the results of a batch that could not be sent
+type errBatchResults struct {
+	err error
+}
+
+func (r errBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, r.err
+}
+
+func (r errBatchResults) Query() (pgx.Rows, error) {
+	return nil, r.err
+}
+
+func (r errBatchResults) QueryRow() pgx.Row {
+	return errRow{err: r.err}
+}
+
+func (r errBatchResults) Close() error {
+	return r.err
+}
+`
+
+const txManagerTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+	
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	
+	"github.com/fsvxavier/pgx-goose/repository/interfaces"
+)
+
+// Repositories groups the repositories of all tables sharing the same querier
+type Repositories struct {
+{{- range .Tables}}
+	{{.StructName}} interfaces.{{.InterfaceName}}
+{{- end}}
+{{- if .Outbox}}
+	Outbox *Outbox
+{{- end}}
+}
+
+// NewRepositories creates every repository on top of the given querier
+func NewRepositories(db DBTX, opts ...Option) *Repositories {
+	return &Repositories{
+{{- range .Tables}}
+		{{.StructName}}: New{{.StructName}}Repository(db, opts...),
+{{- end}}
+{{- if .Outbox}}
+		Outbox: NewOutbox(db, opts...),
+{{- end}}
+	}
+}
+
+// TxBeginner starts transactions. It is satisfied by *pgxpool.Pool and *pgx.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxManager runs units of work spanning several repositories in a single transaction
+type TxManager struct {
+	db      TxBeginner
+	options []Option
+}
+
+// NewTxManager creates a new transaction manager, the options apply to the repositories it creates
+func NewTxManager(db TxBeginner, opts ...Option) *TxManager {
+	// All the queries of a transaction go to its connection
+	return &TxManager{db: db, options: append(slices.Clip(opts), WithReplicas())}
+}
+
+// RunInTx runs fn in a transaction with the default options.
+// The transaction is committed when fn returns nil and rolled back otherwise.
+func (m *TxManager) RunInTx(ctx context.Context, fn func(repos *Repositories) error) error {
+	return m.RunInTxWithOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// RunInTxWithOptions runs fn in a transaction started with the given options
+func (m *TxManager) RunInTxWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(repos *Repositories) error) error {
+	tx, err := m.db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	
+	// Rollback is a no-op once the transaction has been committed
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	
+	if err := fn(NewRepositories(tx, m.options...)); err != nil {
+		return err
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return nil
+}
+
+// RetryPolicy controls the retries of RunInTxWithRetry. The delay before a retry
+// doubles on every attempt, starting at MinBackoff and capped at MaxBackoff, and is
+// randomized by up to half its value so that conflicting transactions spread out.
+type RetryPolicy struct {
+	MaxAttempts int           // Maximum number of attempts, including the first one
+	MinBackoff  time.Duration // Delay before the first retry
+	MaxBackoff  time.Duration // Maximum delay between two attempts
+}
+
+// DefaultRetryPolicy is the retry policy used unless WithRetryPolicy is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+}
+
+// backoff returns the delay before the given retry, the first retry being 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.MinBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay - time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// RunInTxWithRetry runs fn in a transaction started with the given options and runs it
+// again in a new transaction when PostgreSQL aborts it with a serialization failure or a
+// deadlock, as it may at the SERIALIZABLE and REPEATABLE READ isolation levels.
+// fn must therefore have no side effects outside the transaction. It receives a context
+// carrying the attempt number, see TxAttempt. The last error is returned once the
+// attempts of the retry policy are exhausted.
+func (m *TxManager) RunInTxWithRetry(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context, repos *Repositories) error) error {
+	policy := newOptions(m.options).retry
+	
+	for attempt := 1; ; attempt++ {
+		attemptCtx := context.WithValue(ctx, txAttemptKey, attempt)
+		err := m.RunInTxWithOptions(attemptCtx, opts, func(repos *Repositories) error {
+			return fn(attemptCtx, repos)
+		})
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("transaction failed after %d attempts: %w", attempt, err)
+		}
+		
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("transaction retry canceled after %d attempts: %w", attempt, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
+
+// TxAttempt returns the attempt number of the transaction run by RunInTxWithRetry with ctx,
+// starting at 1, or 0 when ctx does not come from RunInTxWithRetry
+func TxAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(txAttemptKey).(int)
+	return attempt
+}
+
+// isRetryable reports whether err aborted a transaction that can be run again
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01" // serialization_failure, deadlock_detected
+}
+`
+
+const cacheTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Cache stores the rows read by the cached repositories, e.g. in process with LRUCache
+// or in Redis. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, ok is false when there is none
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	
+	// Set stores value under key for ttl, or until it is deleted when ttl is zero
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	
+	// Delete removes the values stored under keys
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// LRUCache is an in-process Cache holding a bounded number of values, evicting the
+// least recently used ones first
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+	now     func() time.Time
+}
+
+// lruEntry is a value of an LRUCache
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // Zero when the value does not expire
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache creates an LRUCache holding at most size values
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value stored under key unless it expired
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores value under key, evicting the least recently used value when the cache is full
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+	
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete removes the values stored under keys
+func (c *LRUCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of values in the cache, including the expired ones not evicted yet
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove removes an entry, the caller holding the lock
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
+
+// cacheKey returns the key caching the result of a lookup of a table, kind naming the
+// lookup and scope the tenant the rows belong to
+func cacheKey(table, kind string, scope any, values ...any) (string, bool) {
+	encoded, err := json.Marshal(append([]any{scope}, values...))
+	if err != nil {
+		return "", false
+	}
+	return table + ":" + kind + ":" + string(encoded), true
+}
+{{- if eq .Tenancy "column"}}
+
+// cacheScope returns the tenant of ctx, the rows of each tenant being cached apart
+func cacheScope(ctx context.Context) (any, bool) {
+	return TenantID(ctx)
+}
+{{- else if eq .Tenancy "schema"}}
+
+// cacheScope returns the schema of the tenant of ctx, the rows of each schema being cached apart
+func cacheScope(ctx context.Context) (any, bool) {
+	schema, ok := TenantSchema(ctx)
+	return schema, ok
+}
+{{- end}}
+
+// readCache decodes the value cached under key into dst and reports whether there was one.
+// The errors of the cache count as misses, the repository being read instead.
+func readCache(ctx context.Context, cache Cache, key string, dst any) bool {
+	value, ok, err := cache.Get(ctx, key)
+	if err != nil || !ok {
+		return false
+	}
+	return json.Unmarshal(value, dst) == nil
+}
+
+// writeCache caches value under key. Failing to cache only costs a later query, so the
+// errors are ignored.
+func writeCache(ctx context.Context, cache Cache, key string, value any, ttl time.Duration) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	_ = cache.Set(ctx, key, encoded, ttl)
+}
+
+// sameValue reports whether a model field, possibly a pointer, holds the value a finder
+// looked up
+func sameValue(field, value any) bool {
+	v := reflect.ValueOf(field)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		field = v.Elem().Interface()
+	}
+	return reflect.DeepEqual(field, value)
+}
+`
+
+const cachedRepositoryTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+	"time"
+{{- range .CacheImports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+	"github.com/fsvxavier/pgx-goose/repository/interfaces"
+)
+
+// Cached{{.ImplName}} decorates a {{.InterfaceName}} with a Cache: GetByID and the unique
+// finders return the cached {{.StructName}}s, the writes changing a row remove it from the cache.
+// The other methods, and the writes of a BatchBuilder, go to the decorated repository without
+// touching the cache.
+type Cached{{.ImplName}} struct {
+	interfaces.{{.InterfaceName}}
+	cache Cache
+	ttl   time.Duration
+}
+
+var _ interfaces.{{.InterfaceName}} = (*Cached{{.ImplName}})(nil)
+
+// NewCached{{.ImplName}} decorates repo with cache, the rows being kept for ttl.
+// A write made in a transaction removes the row from the cache before the transaction
+// commits, a concurrent read may cache the previous row again until ttl expires.
+{{- if or .Tenant .TenantSchema}}
+// The rows of each tenant are cached apart.
+{{- end}}
+func NewCached{{.ImplName}}(repo interfaces.{{.InterfaceName}}, cache Cache, ttl time.Duration) interfaces.{{.InterfaceName}} {
+	return &Cached{{.ImplName}}{ {{- .InterfaceName}}: repo, cache: cache, ttl: ttl}
+}
+
+// GetByID returns the cached {{.StructName}} with the given ID, or reads and caches it
+func (r *Cached{{.ImplName}}) GetByID(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error) {
+	key, ok := r.readKey(ctx, "id", id)
+	if !ok {
+		return r.{{.InterfaceName}}.GetByID(ctx, id)
+	}
+	
+	var cached models.{{.StructName}}
+	if readCache(ctx, r.cache, key, &cached) {
+		return &cached, nil
+	}
+	
+	{{lower .StructName}}, err := r.{{.InterfaceName}}.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	writeCache(ctx, r.cache, key, {{lower .StructName}}, r.ttl)
+	return {{lower .StructName}}, nil
+}
+
+// Update updates a {{.StructName}} and removes it from the cache
+func (r *Cached{{.ImplName}}) Update(ctx context.Context, {{lower .StructName}} *models.{{.StructName}}) error {
+	if err := r.{{.InterfaceName}}.Update(ctx, {{lower .StructName}}); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, {{lower .StructName}}.{{.PrimaryKeyField}})
+}
+
+// Delete deletes a {{.StructName}} and removes it from the cache
+func (r *Cached{{.ImplName}}) Delete(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) error {
+	if err := r.{{.InterfaceName}}.Delete(ctx, id{{if .Version}}, version{{end}}); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+{{- if .SoftDelete}}
+
+// HardDelete permanently deletes a {{.StructName}} and removes it from the cache
+func (r *Cached{{.ImplName}}) HardDelete(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	if err := r.{{.InterfaceName}}.HardDelete(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+
+// Restore restores a soft deleted {{.StructName}} and removes it from the cache
+func (r *Cached{{.ImplName}}) Restore(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	if err := r.{{.InterfaceName}}.Restore(ctx, id); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, id)
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// Patch updates the columns set in patch and removes the {{.StructName}} from the cache
+func (r *Cached{{.ImplName}}) Patch(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}, patch models.{{.StructName}}Patch) (*models.{{.StructName}}, error) {
+	{{lower .StructName}}, err := r.{{.InterfaceName}}.Patch(ctx, id{{if .Version}}, version{{end}}, patch)
+	if err != nil {
+		return nil, err
+	}
+	return {{lower .StructName}}, r.invalidate(ctx, id)
+}
+{{- end}}
+{{- range .Upserts}}
+
+// {{.Name}} inserts or updates a {{$.StructName}} and removes it from the cache
+func (r *Cached{{$.ImplName}}) {{.Name}}(ctx context.Context, {{lower $.StructName}} *models.{{$.StructName}}) error {
+	if err := r.{{$.InterfaceName}}.{{.Name}}(ctx, {{lower $.StructName}}); err != nil {
+		return err
+	}
+	return r.invalidate(ctx, {{lower $.StructName}}.{{$.PrimaryKeyField}})
+}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+// GetBy{{.Name}} returns the cached {{$.StructName}} with the given {{.Description}}, or reads and caches it
+func (r *Cached{{$.ImplName}}) GetBy{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error) {
+	return r.find(ctx, "{{.Name}}", []any{ {{- .Args}}},
+		func({{lower $.StructName}} *models.{{$.StructName}}) bool {
+			return {{range $i, $col := .Columns}}{{if $i}} && {{end}}sameValue({{lower $.StructName}}.{{$col.Field}}, {{$col.Param}}){{end}}
+		},
+		func() (*models.{{$.StructName}}, error) {
+			return r.{{$.InterfaceName}}.GetBy{{.Name}}(ctx, {{.Args}})
+		},
+	)
+}
+{{- end}}
+{{- end}}
+
+// find returns the {{.StructName}} found by a unique finder from the cache, or reads it with get.
+// The ID of the row is cached under the finder values and the row under its ID, so that
+// invalidating the ID covers the finders. A cached row whose finder columns no longer
+// match is read again.
+func (r *Cached{{.ImplName}}) find(ctx context.Context, finder string, values []any, matches func(*models.{{.StructName}}) bool, get func() (*models.{{.StructName}}, error)) (*models.{{.StructName}}, error) {
+	key, ok := r.readKey(ctx, finder, values...)
+	if !ok {
+		return get()
+	}
+	
+	var id {{.PrimaryKeyType}}
+	if readCache(ctx, r.cache, key, &id) {
+		if idKey, ok := r.key(ctx, "id", id); ok {
+			var cached models.{{.StructName}}
+			if readCache(ctx, r.cache, idKey, &cached) && matches(&cached) {
+				return &cached, nil
+			}
+		}
+	}
+	
+	{{lower .StructName}}, err := get()
+	if err != nil {
+		return nil, err
+	}
+	if idKey, ok := r.key(ctx, "id", {{lower .StructName}}.{{.PrimaryKeyField}}); ok {
+		writeCache(ctx, r.cache, key, {{lower .StructName}}.{{.PrimaryKeyField}}, r.ttl)
+		writeCache(ctx, r.cache, idKey, {{lower .StructName}}, r.ttl)
+	}
+	return {{lower .StructName}}, nil
+}
+
+// invalidate removes the {{.StructName}} with the given ID from the cache. The write preceding
+// it is made even when it fails.
+func (r *Cached{{.ImplName}}) invalidate(ctx context.Context, id {{.PrimaryKeyType}}) error {
+	key, ok := r.key(ctx, "id", id)
+	if !ok {
+		return nil
+	}
+	if err := r.cache.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to remove {{lower .StructName}} %v from cache: %w", id, err)
+	}
+	return nil
+}
+
+// readKey returns the cache key of a read, false when the read bypasses the cache
+func (r *Cached{{.ImplName}}) readKey(ctx context.Context, kind string, values ...any) (string, bool) {
+{{- if .SoftDelete}}
+	// Soft deleted rows are never cached
+	if includeDeleted(ctx) {
+		return "", false
+	}
+{{- end}}
+	return r.key(ctx, kind, values...)
+}
+
+// key returns the cache key of a lookup{{if or .Tenant .TenantSchema}}, false when ctx has no tenant{{end}}
+func (r *Cached{{.ImplName}}) key(ctx context.Context, kind string, values ...any) (string, bool) {
+{{- if or .Tenant .TenantSchema}}
+	scope, ok := cacheScope(ctx)
+	if !ok {
+		return "", false
+	}
+	return cacheKey("{{.Table.Name}}", kind, scope, values...)
+{{- else}}
+	return cacheKey("{{.Table.Name}}", kind, nil, values...)
+{{- end}}
+}
+`
+
+const handlersSupportTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+	"github.com/fsvxavier/pgx-goose/repository/postgres"
+)
+
+const (
+	defaultLimit   = 50      // Page size of the lists when the request sets no limit
+	maxLimit       = 1000    // Largest page size of the lists
+	maxRequestBody = 1 << 20 // Largest request body, in bytes
+)
+
+// ValidationError is returned when a request body fails validation, answered with
+// 422 Unprocessable Entity
+type ValidationError struct {
+	Fields map[string]string // Problem of each invalid field
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, problem := range e.Fields {
+		fields = append(fields, field+" "+problem)
+	}
+	sort.Strings(fields)
+	return "invalid request: " + strings.Join(fields, ", ")
+}
+
+// add records the problem of a field
+func (e *ValidationError) add(field, problem string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[field] = problem
+}
+
+// err returns e when a field is invalid, nil otherwise
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// requestError is returned for malformed requests
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// badRequest returns an error answered with 400 Bad Request
+func badRequest(format string, args ...any) error {
+	return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// errorResponse is the body of the error responses
+type errorResponse struct {
+	Error  string            ` + "`" + `json:"error"` + "`" + `
+	Fields map[string]string ` + "`" + `json:"fields,omitempty"` + "`" + `
+}
+
+// listResponse is the body of the list responses
+type listResponse[T any] struct {
+	Items  []T ` + "`" + `json:"items"` + "`" + `
+	Limit  int ` + "`" + `json:"limit"` + "`" + `
+	Offset int ` + "`" + `json:"offset"` + "`" + `
+}
+
+// errorStatus returns the status and the message answering err. The errors of the
+// repositories that are not the client's fault are answered with 500 Internal Server
+// Error, without exposing their message.
+func errorStatus(err error) (int, string) {
+	var (
+		request    *requestError
+		validation *ValidationError
+		unique     *postgres.ErrUniqueViolation
+		foreignKey *postgres.ErrForeignKeyViolation
+		check      *postgres.ErrCheckViolation
+		notNull    *postgres.ErrNotNullViolation
+	)
+	switch {
+	case errors.As(err, &request):
+		return request.status, request.message
+	case errors.As(err, &validation):
+		return http.StatusUnprocessableEntity, validation.Error()
+	case errors.Is(err, postgres.ErrNotFound):
+		return http.StatusNotFound, "not found"
+	case errors.Is(err, postgres.ErrStaleObject):
+		return http.StatusPreconditionFailed, "the resource was modified concurrently"
+{{- if .Tenancy}}
+	case errors.Is(err, postgres.ErrNoTenant):
+		return http.StatusForbidden, "no tenant"
+{{- end}}
+	case errors.As(err, &unique):
+		return http.StatusConflict, unique.Error()
+	case errors.As(err, &foreignKey):
+		return http.StatusConflict, foreignKey.Error()
+	case errors.As(err, &check):
+		return http.StatusUnprocessableEntity, check.Error()
+	case errors.As(err, &notNull):
+		return http.StatusUnprocessableEntity, notNull.Error()
+	case errors.Is(err, postgres.ErrSerialization):
+		return http.StatusServiceUnavailable, "the request conflicted with another one, retry it"
+	default:
+		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	}
+}
+
+// writeError answers a request with the status and the message of err
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	
+	response := errorResponse{Error: message}
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		response.Fields = validation.Fields
+	}
+	writeJSON(w, status, response)
+}
+
+// writeJSON answers a request with the JSON encoding of body
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// decodeJSON decodes the JSON body of a request into dst, rejecting unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// parseValue parses a path or query parameter into dst, a pointer to a value of a
+// basic type or of a type implementing encoding.TextUnmarshaler
+func parseValue(s string, dst any) error {
+	if u, ok := dst.(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	
+	v := reflect.ValueOf(dst).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %T", dst)
+	}
+	return nil
+}
+
+// pathValue parses the named wildcard of the request path into dst
+func pathValue(r *http.Request, name string, dst any) error {
+	if err := parseValue(r.PathValue(name), dst); err != nil {
+		return badRequest("invalid %s: %v", name, err)
+	}
+	return nil
+}
+
+// queryValue parses the named query parameter into dst, reporting whether it is set
+func queryValue(q url.Values, name string, dst any) (bool, error) {
+	if !q.Has(name) {
+		return false, nil
+	}
+	if err := parseValue(q.Get(name), dst); err != nil {
+		return false, badRequest("invalid %s: %v", name, err)
+	}
+	return true, nil
+}
+
+// pagination returns the limit and offset query parameters of a list
+func pagination(q url.Values) (limit, offset int, err error) {
+	limit = defaultLimit
+	if _, err := queryValue(q, "limit", &limit); err != nil {
+		return 0, 0, err
+	}
+	if _, err := queryValue(q, "offset", &offset); err != nil {
+		return 0, 0, err
+	}
+	if limit < 1 || limit > maxLimit {
+		return 0, 0, badRequest("invalid limit: must be between 1 and %d", maxLimit)
+	}
+	if offset < 0 {
+		return 0, 0, badRequest("invalid offset: must not be negative")
+	}
+	return limit, offset, nil
+}
+
+// sortParams returns the sort query parameter of a list, a comma separated list of
+// fields each prefixed with "-" for a descending order
+func sortParams[F interface {
+	~string
+	Valid() bool
+}](q url.Values) ([]models.Sort[F], error) {
+	var sorts []models.Sort[F]
+	for _, name := range strings.Split(q.Get("sort"), ",") {
+		if name == "" {
+			continue
+		}
+		field, desc := strings.CutPrefix(name, "-")
+		if !F(field).Valid() {
+			return nil, badRequest("invalid sort: cannot sort by %s", field)
+		}
+		sorts = append(sorts, models.Sort[F]{Field: F(field), Desc: desc})
+	}
+	return sorts, nil
+}
+
+// filterParams reads the predicates on a column from the query parameters named after
+// it: column (equal to), column.in (comma separated), column.lt, column.gt and
+// column.null. dst is left nil when none is set.
+func filterParams[T any](q url.Values, column string, dst **models.Filter[T]) error {
+	var f models.Filter[T]
+	set, err := readFilter(q, column, &f)
+	if set {
+		*dst = &f
+	}
+	return err
+}
+
+// stringFilterParams reads the predicates on a text column like filterParams, and the
+// pattern of column.like
+func stringFilterParams(q url.Values, column string, dst **models.StringFilter) error {
+	var f models.StringFilter
+	set, err := readFilter(q, column, &f.Filter)
+	if err != nil {
+		return err
+	}
+	if q.Has(column + ".like") {
+		like := q.Get(column + ".like")
+		f.Like, set = &like, true
+	}
+	if set {
+		*dst = &f
+	}
+	return nil
+}
+
+// timeFilterParams reads the predicates on a time column like filterParams, and the
+// range of column.from and column.to
+func timeFilterParams(q url.Values, column string, dst **models.TimeFilter) error {
+	var f models.TimeFilter
+	set, err := readFilter(q, column, &f.Filter)
+	if err != nil {
+		return err
+	}
+	for suffix, ptr := range map[string]**time.Time{".from": &f.From, ".to": &f.To} {
+		ok, err := readPredicate(q, column+suffix, ptr)
+		if err != nil {
+			return err
+		}
+		set = set || ok
+	}
+	if set {
+		*dst = &f
+	}
+	return nil
+}
+
+// readFilter fills f with the predicates on a column and reports whether any is set
+func readFilter[T any](q url.Values, column string, f *models.Filter[T]) (bool, error) {
+	set := false
+	for suffix, ptr := range map[string]**T{"": &f.Eq, ".lt": &f.Lt, ".gt": &f.Gt} {
+		ok, err := readPredicate(q, column+suffix, ptr)
+		if err != nil {
+			return false, err
+		}
+		set = set || ok
+	}
+	
+	if q.Has(column + ".in") {
+		for _, s := range strings.Split(q.Get(column+".in"), ",") {
+			var v T
+			if err := parseValue(s, &v); err != nil {
+				return false, badRequest("invalid %s.in: %v", column, err)
+			}
+			f.In = append(f.In, v)
+		}
+		set = true
+	}
+	
+	ok, err := readPredicate(q, column+".null", &f.IsNull)
+	return set || ok, err
+}
+
+// readPredicate parses the named query parameter into a new value stored in dst,
+// reporting whether it is set
+func readPredicate[T any](q url.Values, name string, dst **T) (bool, error) {
+	var v T
+	ok, err := queryValue(q, name, &v)
+	if ok {
+		*dst = &v
+	}
+	return ok, err
+}
+
+// etag returns the entity tag of a version
+func etag(version any) string {
+	return strconv.Quote(fmt.Sprint(version))
+}
+
+// ifMatch parses the version of the If-Match header of a request into dst. Writes
+// guarded by a version are answered with 428 Precondition Required without it.
+func ifMatch(r *http.Request, dst any) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return &requestError{status: http.StatusPreconditionRequired, message: "the If-Match header is required"}
+	}
+	if err := parseValue(strings.Trim(strings.TrimPrefix(header, "W/"), ` + "`" + `"` + "`" + `), dst); err != nil {
+		return badRequest("invalid If-Match: %v", err)
+	}
+	return nil
+}
+`
+
+const handlerTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+{{- range .ResponseImports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+	"github.com/fsvxavier/pgx-goose/repository/interfaces"
+)
+
+// Create{{.StructName}}Request is the body of the requests creating a {{.StructName}}
+type Create{{.StructName}}Request struct {
+{{- range .CreateFields}}
+	{{.Field}} *{{.GoType}} ` + "`" + `json:"{{.Column}}"` + "`" + `
+{{- end}}
+}
+
+// Validate checks that the required fields are set
+func (req *Create{{.StructName}}Request) Validate() error {
+	var v ValidationError
+{{- range .CreateFields}}
+{{- if .Required}}
+	if req.{{.Field}} == nil {
+		v.add("{{.Column}}", "is required")
+	}
+{{- end}}
+{{- end}}
+	return v.err()
+}
+
+// model returns the {{.StructName}} created by the request
+func (req *Create{{.StructName}}Request) model() *models.{{.StructName}} {
+	{{lower .StructName}} := &models.{{.StructName}}{}
+{{- range .CreateFields}}
+{{- if .Pointer}}
+	{{lower $.StructName}}.{{.Field}} = req.{{.Field}}
+{{- else}}
+	if req.{{.Field}} != nil {
+		{{lower $.StructName}}.{{.Field}} = *req.{{.Field}}
+	}
+{{- end}}
+{{- end}}
+	return {{lower .StructName}}
+}
+{{- if .UpdateFields}}
+
+// Update{{.StructName}}Request is the body of the requests replacing a {{.StructName}}
+type Update{{.StructName}}Request struct {
+{{- range .UpdateFields}}
+	{{.Field}} *{{.GoType}} ` + "`" + `json:"{{.Column}}"` + "`" + `
+{{- end}}
+}
+
+// Validate checks that the required fields are set
+func (req *Update{{.StructName}}Request) Validate() error {
+	var v ValidationError
+{{- range .UpdateFields}}
+{{- if .Required}}
+	if req.{{.Field}} == nil {
+		v.add("{{.Column}}", "is required")
+	}
+{{- end}}
+{{- end}}
+	return v.err()
+}
+
+// model returns the {{.StructName}} with the given ID replaced by the request
+func (req *Update{{.StructName}}Request) model(id {{.PrimaryKeyType}}) *models.{{.StructName}} {
+	{{lower .StructName}} := &models.{{.StructName}}{ {{- .PrimaryKeyField}}: id}
+{{- range .UpdateFields}}
+{{- if .Pointer}}
+	{{lower $.StructName}}.{{.Field}} = req.{{.Field}}
+{{- else}}
+	if req.{{.Field}} != nil {
+		{{lower $.StructName}}.{{.Field}} = *req.{{.Field}}
+	}
+{{- end}}
+{{- end}}
+	return {{lower .StructName}}
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// Patch{{.StructName}}Request is the body of the requests updating some fields of a {{.StructName}},
+// the fields left out keep their stored value
+type Patch{{.StructName}}Request struct {
+{{- range .UpdateFields}}
+	{{.Field}} models.Optional[{{if .Pointer}}*{{end}}{{.GoType}}] ` + "`" + `json:"{{.Column}}"` + "`" + `
+{{- end}}
+}
+
+// patch returns the {{.StructName}} columns changed by the request
+func (req *Patch{{.StructName}}Request) patch() models.{{.StructName}}Patch {
+	return models.{{.StructName}}Patch{
+{{- range .UpdateFields}}
+		{{.Field}}: req.{{.Field}},
+{{- end}}
+	}
+}
+{{- end}}
+
+// {{.StructName}}Response is the body of the responses returning a {{.StructName}}
+type {{.StructName}}Response struct {
+{{- range .Table.Columns}}
+	{{toPascalCase .Name}} {{.GoType}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+
+// new{{.StructName}}Response returns the response body of a {{.StructName}}
+func new{{.StructName}}Response({{lower .StructName}} *models.{{.StructName}}) {{.StructName}}Response {
+	return {{.StructName}}Response{
+{{- range .Table.Columns}}
+		{{toPascalCase .Name}}: {{lower $.StructName}}.{{toPascalCase .Name}},
+{{- end}}
+	}
+}
+
+// new{{.StructName}}List returns the response body of a page of {{.StructName}}s
+func new{{.StructName}}List({{lower .StructName}}s []*models.{{.StructName}}, limit, offset int) listResponse[{{.StructName}}Response] {
+	items := make([]{{.StructName}}Response, len({{lower .StructName}}s))
+	for i, {{lower .StructName}} := range {{lower .StructName}}s {
+		items[i] = new{{.StructName}}Response({{lower .StructName}})
+	}
+	return listResponse[{{.StructName}}Response]{Items: items, Limit: limit, Offset: offset}
+}
+
+// {{.StructName}}Handler serves the {{.Table.Name}} table as a REST resource backed by a {{.InterfaceName}}
+type {{.StructName}}Handler struct {
+	repo interfaces.{{.InterfaceName}}
+}
+
+// New{{.StructName}}Handler creates a handler serving the {{.StructName}}s of repo
+func New{{.StructName}}Handler(repo interfaces.{{.InterfaceName}}) *{{.StructName}}Handler {
+	return &{{.StructName}}Handler{repo: repo}
+}
+
+// Register registers the routes of the resource on mux:
+//
+//	POST   {{.Path}}
+//	GET    {{.Path}}?limit=&offset=&sort=&<column>[.in|.lt|.gt|.null]=
+//	GET    {{.Path}}/{id}
+{{- if .UpdateFields}}
+//	PUT    {{.Path}}/{id}
+{{- end}}
+{{- if .UpdateColumns}}
+//	PATCH  {{.Path}}/{id}
+{{- end}}
+//	DELETE {{.Path}}/{id}
+{{- range .Finders}}
+//	GET    {{$.FinderPath .}}{{if not .Unique}}?limit=&offset={{end}}
+{{- end}}
+{{- if .Version}}
+//
+// The responses returning a single {{.StructName}} carry its {{.Version.Column}} as ETag, PUT{{if .UpdateColumns}}, PATCH{{end}}
+// and DELETE require it as If-Match.
+{{- end}}
+func (h *{{.StructName}}Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST {{.Path}}", h.create)
+	mux.HandleFunc("GET {{.Path}}", h.list)
+	mux.HandleFunc("GET {{.Path}}/{id}", h.get)
+{{- if .UpdateFields}}
+	mux.HandleFunc("PUT {{.Path}}/{id}", h.update)
+{{- end}}
+{{- if .UpdateColumns}}
+	mux.HandleFunc("PATCH {{.Path}}/{id}", h.patch)
+{{- end}}
+	mux.HandleFunc("DELETE {{.Path}}/{id}", h.delete)
+{{- range .Finders}}
+	mux.HandleFunc("GET {{$.FinderPath .}}", h.{{if .Unique}}get{{else}}list{{end}}By{{.Name}})
+{{- end}}
+}
+
+// create serves POST {{.Path}}
+func (h *{{.StructName}}Handler) create(w http.ResponseWriter, r *http.Request) {
+	var req Create{{.StructName}}Request
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	
+	{{lower .StructName}} := req.model()
+	if err := h.repo.Create(r.Context(), {{lower .StructName}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "{{.Path}}/"+url.PathEscape(fmt.Sprint({{lower .StructName}}.{{.PrimaryKeyField}})))
+{{- if .Version}}
+	w.Header().Set("ETag", etag({{lower .StructName}}.{{.Version.Field}}))
+{{- end}}
+	writeJSON(w, http.StatusCreated, new{{.StructName}}Response({{lower .StructName}}))
+}
+
+// list serves GET {{.Path}}
+func (h *{{.StructName}}Handler) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	
+	var filter models.{{.StructName}}Filter
+{{- range .Filters}}
+	if err := {{if eq .Kind "string"}}stringFilterParams{{else if eq .Kind "time"}}timeFilterParams{{else}}filterParams{{end}}(q, "{{.Column}}", &filter.{{.Field}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- end}}
+	sorts, err := sortParams[models.{{.StructName}}SortField](q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	limit, offset, err := pagination(q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	
+	opts := models.FindOptions[models.{{.StructName}}SortField]{Sort: sorts, Limit: limit, Offset: offset}
+	{{lower .StructName}}s, err := h.repo.Find(r.Context(), filter, opts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, new{{.StructName}}List({{lower .StructName}}s, limit, offset))
+}
+
+// get serves GET {{.Path}}/{id}
+func (h *{{.StructName}}Handler) get(w http.ResponseWriter, r *http.Request) {
+	var id {{.PrimaryKeyType}}
+	if err := pathValue(r, "id", &id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	
+	{{lower .StructName}}, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- if .Version}}
+	w.Header().Set("ETag", etag({{lower .StructName}}.{{.Version.Field}}))
+{{- end}}
+	writeJSON(w, http.StatusOK, new{{.StructName}}Response({{lower .StructName}}))
+}
+{{- if .UpdateFields}}
+
+// update serves PUT {{.Path}}/{id}
+func (h *{{.StructName}}Handler) update(w http.ResponseWriter, r *http.Request) {
+	var id {{.PrimaryKeyType}}
+	if err := pathValue(r, "id", &id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var req Update{{.StructName}}Request
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	
+	{{lower .StructName}} := req.model(id)
+{{- if .Version}}
+	if err := ifMatch(r, &{{lower .StructName}}.{{.Version.Field}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- end}}
+	if err := h.repo.Update(r.Context(), {{lower .StructName}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- if .Version}}
+	w.Header().Set("ETag", etag({{lower .StructName}}.{{.Version.Field}}))
+{{- end}}
+	w.WriteHeader(http.StatusNoContent)
+}
+{{- end}}
+{{- if .UpdateColumns}}
+
+// patch serves PATCH {{.Path}}/{id}, updating the fields set in the body
+func (h *{{.StructName}}Handler) patch(w http.ResponseWriter, r *http.Request) {
+	var id {{.PrimaryKeyType}}
+	if err := pathValue(r, "id", &id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var req Patch{{.StructName}}Request
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- if .Version}}
+	var version {{.Version.GoType}}
+	if err := ifMatch(r, &version); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- end}}
+	
+	{{lower .StructName}}, err := h.repo.Patch(r.Context(), id{{if .Version}}, version{{end}}, req.patch())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- if .Version}}
+	w.Header().Set("ETag", etag({{lower .StructName}}.{{.Version.Field}}))
+{{- end}}
+	writeJSON(w, http.StatusOK, new{{.StructName}}Response({{lower .StructName}}))
+}
+{{- end}}
+
+// delete serves DELETE {{.Path}}/{id}
+func (h *{{.StructName}}Handler) delete(w http.ResponseWriter, r *http.Request) {
+	var id {{.PrimaryKeyType}}
+	if err := pathValue(r, "id", &id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- if .Version}}
+	var version {{.Version.GoType}}
+	if err := ifMatch(r, &version); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- end}}
+	
+	if err := h.repo.Delete(r.Context(), id{{if .Version}}, version{{end}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+{{- range .Finders}}
+
+// {{if .Unique}}get{{else}}list{{end}}By{{.Name}} serves GET {{$.FinderPath .}}
+func (h *{{$.StructName}}Handler) {{if .Unique}}get{{else}}list{{end}}By{{.Name}}(w http.ResponseWriter, r *http.Request) {
+{{- range .Columns}}
+	var {{.Param}} {{.ValueType}}
+	if err := pathValue(r, "{{.Name}}", &{{.Param}}); err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- end}}
+{{- if .Unique}}
+	
+	{{lower $.StructName}}, err := h.repo.GetBy{{.Name}}(r.Context(), {{.Args}})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+{{- with $.Version}}
+	w.Header().Set("ETag", etag({{lower $.StructName}}.{{.Field}}))
+{{- end}}
+	writeJSON(w, http.StatusOK, new{{$.StructName}}Response({{lower $.StructName}}))
+{{- else}}
+	limit, offset, err := pagination(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	
+	{{lower $.StructName}}s, err := h.repo.ListBy{{.Name}}(r.Context(), {{.ValueArgs}}, limit, offset)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, new{{$.StructName}}List({{lower $.StructName}}s, limit, offset))
+{{- end}}
+}
+{{- end}}
+`
+
+const handlerTestTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+{{- range .TestImports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/stretchr/testify/assert"
+{{- if eq .MockProvider "mock"}}
+	"go.uber.org/mock/gomock"
+{{- else}}
+	mocklib "github.com/stretchr/testify/mock"
+{{- end}}
+	"github.com/stretchr/testify/require"
+	
+	"github.com/fsvxavier/pgx-goose/mocks"
+	"github.com/fsvxavier/pgx-goose/models"
+{{- if .TestID}}
+	"github.com/fsvxavier/pgx-goose/repository/postgres"
+{{- end}}
+)
+
+// new{{.StructName}}TestServer returns a mux serving a {{.StructName}}Handler backed by a mock repository
+func new{{.StructName}}TestServer(t *testing.T) (*mocks.{{.MockName}}, *http.ServeMux) {
+{{- if eq .MockProvider "mock"}}
+	repo := mocks.New{{.MockName}}(gomock.NewController(t))
+{{- else}}
+	repo := &mocks.{{.MockName}}{}
+	t.Cleanup(func() { repo.AssertExpectations(t) })
+{{- end}}
+	
+	mux := http.NewServeMux()
+	New{{.StructName}}Handler(repo).Register(mux)
+	return repo, mux
+}
+
+// serve{{.StructName}} sends a request to mux and returns the recorded response
+func serve{{.StructName}}(mux *http.ServeMux, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test{{.StructName}}Handler_List(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		opts := models.FindOptions[models.{{.StructName}}SortField]{Limit: 10, Offset: 20}
+		{{.Expect "Find"}}, models.{{.StructName}}Filter{}, opts).Return([]*models.{{.StructName}}{ {} }, nil)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}?limit=10&offset=20", "")
+		
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body listResponse[{{.StructName}}Response]
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Len(t, body.Items, 1)
+		assert.Equal(t, 10, body.Limit)
+	})
+	
+	t.Run("invalid sort", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}?sort=-unknown", "")
+		
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	
+	t.Run("invalid limit", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}?limit=0", "")
+		
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	
+	t.Run("repository error", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "Find"}}, {{.Any}}, {{.Any}}).Return([]*models.{{.StructName}}(nil), assert.AnError)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}", "")
+		
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), assert.AnError.Error())
+	})
+}
+
+func Test{{.StructName}}Handler_Create(t *testing.T) {
+	t.Run("invalid body", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodPost, "{{.Path}}", "{")
+		
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	
+	t.Run("unknown field", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodPost, "{{.Path}}", ` + "`" + `{"unknown": 1}` + "`" + `)
+		
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+{{- with .FirstRequired}}
+	
+	t.Run("missing fields", func(t *testing.T) {
+		_, mux := new{{$.StructName}}TestServer(t)
+		
+		rec := serve{{$.StructName}}(mux, http.MethodPost, "{{$.Path}}", "{}")
+		
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var body errorResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Contains(t, body.Fields, "{{.Column}}")
+	})
+{{- else}}
+	
+	t.Run("success", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "Create"}}, {{.Any}}).Return(nil)
+		
+		rec := serve{{.StructName}}(mux, http.MethodPost, "{{.Path}}", "{}")
+		
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Location"), "{{.Path}}/"))
+	})
+{{- end}}
+}
+{{- if .TestID}}
+
+func Test{{.StructName}}Handler_Get(t *testing.T) {
+	id := {{.TestID}}
+	
+	t.Run("success", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "GetByID"}}, id).Return(&models.{{.StructName}}{ {{- .PrimaryKeyField}}: id}, nil)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}/{{.TestIDPath}}", "")
+		
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body {{.StructName}}Response
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, id, body.{{.PrimaryKeyField}})
+	})
+	
+	t.Run("not found", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "GetByID"}}, id).Return((*models.{{.StructName}})(nil), postgres.ErrNotFound)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}/{{.TestIDPath}}", "")
+		
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+{{- if ne .PrimaryKeyType "string"}}
+	
+	t.Run("invalid id", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodGet, "{{.Path}}/invalid", "")
+		
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+{{- end}}
+}
+
+func Test{{.StructName}}Handler_Delete(t *testing.T) {
+	id := {{.TestID}}
+	
+	t.Run("success", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "Delete"}}, id{{with .Version}}, {{.GoType}}(1){{end}}).Return(nil)
+		
+		rec := serve{{.StructName}}(mux, http.MethodDelete, "{{.Path}}/{{.TestIDPath}}", ""{{if .Version}}, "If-Match", ` + "`" + `"1"` + "`" + `{{end}})
+		
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+{{- if .Version}}
+	
+	t.Run("missing version", func(t *testing.T) {
+		_, mux := new{{.StructName}}TestServer(t)
+		
+		rec := serve{{.StructName}}(mux, http.MethodDelete, "{{.Path}}/{{.TestIDPath}}", "")
+		
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	})
+	
+	t.Run("stale version", func(t *testing.T) {
+		repo, mux := new{{.StructName}}TestServer(t)
+		{{.Expect "Delete"}}, id, {{.Version.GoType}}(1)).Return(postgres.ErrStaleObject)
+		
+		rec := serve{{.StructName}}(mux, http.MethodDelete, "{{.Path}}/{{.TestIDPath}}", "", "If-Match", ` + "`" + `"1"` + "`" + `)
+		
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+{{- end}}
+}
+{{- end}}
+`
+
+const protoTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+syntax = "proto3";
+
+package {{.ProtoPackage}};
+{{range .Imports}}
+import "{{.}}";
+{{- end}}
+
+option go_package = "{{.GoPackage}}";
+
+// {{.StructName}} is a row of the {{.Table.Name}} table{{if .Table.Comment}}: {{.Table.Comment}}{{end}}
+message {{.StructName}} {
+{{- range .Fields}}
+  {{.Type}} {{.Column}} = {{.Number}};
+{{- end}}
+{{- range .Skipped}}
+  // {{.Name}} ({{.Type}}) has no proto type
+{{- end}}
+}
+
+// {{.StructName}}Service exposes the {{.InterfaceName}} operations
+service {{.StructName}}Service {
+  rpc Create{{.StructName}}(Create{{.StructName}}Request) returns ({{.StructName}});
+{{- if .PrimaryKey}}
+  rpc Get{{.StructName}}(Get{{.StructName}}Request) returns ({{.StructName}});
+  rpc Update{{.StructName}}(Update{{.StructName}}Request) returns ({{.StructName}});
+  rpc Delete{{.StructName}}(Delete{{.StructName}}Request) returns (google.protobuf.Empty);
+{{- end}}
+  rpc List{{.StructName}}(List{{.StructName}}Request) returns (List{{.StructName}}Response);
+}
+
+message Create{{.StructName}}Request {
+  {{.StructName}} {{.MessageField}} = 1;
+}
+{{- with .PrimaryKey}}
+
+message Get{{$.StructName}}Request {
+  {{.Type}} {{.Column}} = 1;
+}
+
+message Update{{$.StructName}}Request {
+  {{$.StructName}} {{$.MessageField}} = 1;
+}
+
+message Delete{{$.StructName}}Request {
+  {{.Type}} {{.Column}} = 1;
+{{- if $.Version}}
+  {{$.VersionProto}} {{$.Version.Column}} = 2;
+{{- end}}
+}
+{{- end}}
+
+message List{{.StructName}}Request {
+  int32 limit = 1;
+  int32 offset = 2;
+}
+
+message List{{.StructName}}Response {
+  repeated {{.StructName}} items = 1;
+}
+`
+
+const protoConvertTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- if .Fallible}}
+	"fmt"
+	{{end}}
+	"github.com/fsvxavier/pgx-goose/models"
+{{- range .GoImports}}
+	"{{.}}"
+{{- end}}
+)
+
+// {{.StructName}}ToProto converts a {{.StructName}} model to its proto message
+func {{.StructName}}ToProto(m *models.{{.StructName}}) *{{.StructName}} {
+	if m == nil {
+		return nil
+	}
+	return &{{.StructName}}{
+{{- range .Fields}}
+		{{.GoName}}: {{.ToProto}},
+{{- end}}
+	}
+}
+
+// {{.StructName}}FromProto converts a {{.StructName}} proto message to its model
+func {{.StructName}}FromProto(p *{{.StructName}}) (*models.{{.StructName}}, error) {
+	if p == nil {
+		return nil, nil
+	}
+{{- if .Fallible}}
+	
+	var err error
+{{- end}}
+	m := &models.{{.StructName}}{}
+{{- range .Fields}}
+{{- if .Fallible}}
+	if m.{{.Field}}, err = {{.FromProto}}; err != nil {
+		return nil, fmt.Errorf("invalid {{.Column}}: %w", err)
+	}
+{{- else}}
+	m.{{.Field}} = {{.FromProto}}
+{{- end}}
+{{- end}}
+	return m, nil
+}
+`
+
+const protoSupportTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+{{- with .Imports}}
+
+import (
+{{- range .}}
+	{{if .}}"{{.}}"{{end}}
+{{- end}}
+)
+{{- end}}
+{{- if index .Helpers "wrappers"}}
+
+// stringValue converts a nullable string to its wrapper
+func stringValue(v *string) *wrapperspb.StringValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.String(*v)
+}
+
+// stringPtr converts a string wrapper to a nullable string
+func stringPtr(v *wrapperspb.StringValue) *string {
+	if v == nil {
+		return nil
+	}
+	s := v.GetValue()
+	return &s
+}
+
+// int64Value converts a nullable integer to its wrapper
+func int64Value[T ~int | ~int64](v *T) *wrapperspb.Int64Value {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Int64(int64(*v))
+}
+
+// int64Ptr converts an int64 wrapper to a nullable integer
+func int64Ptr[T ~int | ~int64](v *wrapperspb.Int64Value) *T {
+	if v == nil {
+		return nil
+	}
+	i := T(v.GetValue())
+	return &i
+}
+
+// int32Value converts a nullable integer to its wrapper
+func int32Value[T ~int16 | ~int32](v *T) *wrapperspb.Int32Value {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Int32(int32(*v))
+}
+
+// int32Ptr converts an int32 wrapper to a nullable integer
+func int32Ptr[T ~int16 | ~int32](v *wrapperspb.Int32Value) *T {
+	if v == nil {
+		return nil
+	}
+	i := T(v.GetValue())
+	return &i
+}
+
+// floatValue converts a nullable float32 to its wrapper
+func floatValue(v *float32) *wrapperspb.FloatValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Float(*v)
+}
+
+// floatPtr converts a float wrapper to a nullable float32
+func floatPtr(v *wrapperspb.FloatValue) *float32 {
+	if v == nil {
+		return nil
+	}
+	f := v.GetValue()
+	return &f
+}
+
+// doubleValue converts a nullable float64 to its wrapper
+func doubleValue(v *float64) *wrapperspb.DoubleValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Double(*v)
+}
+
+// doublePtr converts a double wrapper to a nullable float64
+func doublePtr(v *wrapperspb.DoubleValue) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := v.GetValue()
+	return &f
+}
+
+// boolValue converts a nullable bool to its wrapper
+func boolValue(v *bool) *wrapperspb.BoolValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Bool(*v)
+}
+
+// boolPtr converts a bool wrapper to a nullable bool
+func boolPtr(v *wrapperspb.BoolValue) *bool {
+	if v == nil {
+		return nil
+	}
+	b := v.GetValue()
+	return &b
+}
+
+// bytesValue converts nullable bytes to their wrapper
+func bytesValue[T ~[]byte](v *T) *wrapperspb.BytesValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.Bytes(*v)
+}
+
+// bytesPtr converts a bytes wrapper to nullable bytes
+func bytesPtr(v *wrapperspb.BytesValue) *[]byte {
+	if v == nil {
+		return nil
+	}
+	b := v.GetValue()
+	return &b
+}
+{{- end}}
+{{- if index .Helpers "time"}}
+
+// timeFromProto converts a timestamp to a time, the zero time when unset
+func timeFromProto(v *timestamppb.Timestamp) time.Time {
+	if v == nil {
+		return time.Time{}
+	}
+	return v.AsTime()
+}
+
+// timestampValue converts a nullable time to a timestamp
+func timestampValue(v *time.Time) *timestamppb.Timestamp {
+	if v == nil {
+		return nil
+	}
+	return timestamppb.New(*v)
+}
+
+// timePtr converts a timestamp to a nullable time
+func timePtr(v *timestamppb.Timestamp) *time.Time {
+	if v == nil {
+		return nil
+	}
+	t := v.AsTime()
+	return &t
+}
+{{- end}}
+{{- if index .Helpers "uuid"}}
+
+// parseUUID parses a UUID, the nil UUID when empty
+func parseUUID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(s)
+}
+{{- if index .Helpers "wrappers"}}
+
+// uuidValue converts a nullable UUID to a string wrapper
+func uuidValue(v *uuid.UUID) *wrapperspb.StringValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.String(v.String())
+}
+
+// uuidPtr converts a string wrapper to a nullable UUID
+func uuidPtr(v *wrapperspb.StringValue) (*uuid.UUID, error) {
+	if v == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+{{- end}}
+{{- end}}
+{{- if index .Helpers "decimal"}}
+
+// parseDecimal parses a decimal, zero when empty
+func parseDecimal(s string) (decimal.Decimal, error) {
+	if s == "" {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(s)
+}
+{{- if index .Helpers "wrappers"}}
+
+// decimalValue converts a nullable decimal to a string wrapper
+func decimalValue(v *decimal.Decimal) *wrapperspb.StringValue {
+	if v == nil {
+		return nil
+	}
+	return wrapperspb.String(v.String())
+}
+
+// decimalPtr converts a string wrapper to a nullable decimal
+func decimalPtr(v *wrapperspb.StringValue) (*decimal.Decimal, error) {
+	if v == nil {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(v.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+{{- end}}
+{{- end}}
+{{- if index .Helpers "json"}}
+
+// rawJSON converts bytes to a JSON document, nil when empty
+func rawJSON(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.RawMessage(b)
+}
+{{- if index .Helpers "wrappers"}}
+
+// rawJSONPtr converts a bytes wrapper to a nullable JSON document
+func rawJSONPtr(v *wrapperspb.BytesValue) *json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	doc := json.RawMessage(v.GetValue())
+	return &doc
+}
+{{- end}}
+{{- end}}
+`
+
+const openAPITemplate = `# Code generated by pgx-goose. DO NOT EDIT.
+
+openapi: 3.1.0
+info:
+  title: {{.Title}}
+  version: {{.Version}}
+tags:
+{{- range .Tables}}
+  - name: {{.Table.Name}}
+{{- end}}
+paths:
+{{- range $table := .Tables}}
+  {{.Path}}:
+    get:
+      operationId: list{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: List the {{.Table.Name}} matching the filters
+      parameters:
+        - $ref: "#/components/parameters/limit"
+        - $ref: "#/components/parameters/offset"
+        - name: sort
+          in: query
+          description: "Comma separated fields to sort by, each prefixed with - for a descending order{{if .SortFields}}: {{range $i, $f := .SortFields}}{{if $i}}, {{end}}{{$f}}{{end}}{{end}}"
+          schema: {"type":"string"}
+{{- range .FilterParams}}
+        - name: {{.Name}}
+          in: {{.In}}
+          description: {{.Doc}}
+          schema: {{.Schema}}
+{{- end}}
+      responses:
+        "200":
+          description: A page of {{.Table.Name}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.StructName}}List"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        default:
+          $ref: "#/components/responses/Error"
+    post:
+      operationId: create{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: Create a {{.StructName}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Create{{.StructName}}Request"
+      responses:
+        "201":
+          description: The created {{.StructName}}
+          headers:
+            Location:
+              description: Path of the created {{.StructName}}
+              schema: {"type":"string"}
+{{- if .Version}}
+            ETag:
+              $ref: "#/components/headers/ETag"
+{{- end}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.StructName}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "409":
+          $ref: "#/components/responses/Conflict"
+        "422":
+          $ref: "#/components/responses/UnprocessableEntity"
+        default:
+          $ref: "#/components/responses/Error"
+  {{.Path}}/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema: {{.IDSchema}}
+    get:
+      operationId: get{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: Get a {{.StructName}} by {{.PrimaryKeyCol}}
+      responses:
+        "200":
+          description: The {{.StructName}}
+{{- if .Version}}
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+{{- end}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.StructName}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        default:
+          $ref: "#/components/responses/Error"
+{{- if .UpdateFields}}
+    put:
+      operationId: update{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: Replace a {{.StructName}}
+{{- if .Version}}
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+{{- end}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Update{{.StructName}}Request"
+      responses:
+        "204":
+          description: The {{.StructName}} was replaced
+{{- if .Version}}
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+{{- end}}
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "409":
+          $ref: "#/components/responses/Conflict"
+{{- if .Version}}
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+{{- end}}
+        "422":
+          $ref: "#/components/responses/UnprocessableEntity"
+{{- if .Version}}
+        "428":
+          $ref: "#/components/responses/PreconditionRequired"
+{{- end}}
+        default:
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- if .UpdateColumns}}
+    patch:
+      operationId: patch{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: Update the fields of a {{.StructName}} set in the body
+{{- if .Version}}
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+{{- end}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Patch{{.StructName}}Request"
+      responses:
+        "200":
+          description: The updated {{.StructName}}
+{{- if .Version}}
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+{{- end}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{.StructName}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+        "409":
+          $ref: "#/components/responses/Conflict"
+{{- if .Version}}
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+{{- end}}
+        "422":
+          $ref: "#/components/responses/UnprocessableEntity"
+{{- if .Version}}
+        "428":
+          $ref: "#/components/responses/PreconditionRequired"
+{{- end}}
+        default:
+          $ref: "#/components/responses/Error"
+{{- end}}
+    delete:
+      operationId: delete{{.StructName}}
+      tags: [{{.Table.Name}}]
+      summary: Delete a {{.StructName}}
+{{- if .Version}}
+      parameters:
+        - $ref: "#/components/parameters/ifMatch"
+{{- end}}
+      responses:
+        "204":
+          description: The {{.StructName}} was deleted
+        "400":
+          $ref: "#/components/responses/BadRequest"
+        "404":
+          $ref: "#/components/responses/NotFound"
+{{- if .Version}}
+        "412":
+          $ref: "#/components/responses/PreconditionFailed"
+        "428":
+          $ref: "#/components/responses/PreconditionRequired"
+{{- end}}
+        default:
+          $ref: "#/components/responses/Error"
+{{- range .Finders}}
+  {{$table.FinderPath .}}:
+    get:
+      operationId: {{if .Unique}}get{{else}}list{{end}}{{$table.StructName}}By{{.Name}}
+      tags: [{{$table.Table.Name}}]
+      summary: {{if .Unique}}Get the {{$table.StructName}}{{else}}List the {{$table.Table.Name}}{{end}} by {{range $i, $c := .Columns}}{{if $i}} and {{end}}{{$c.Name}}{{end}}
+      parameters:
+{{- range $table.FinderParameters .}}
+        - name: {{.Name}}
+          in: {{.In}}
+          required: true
+          schema: {{.Schema}}
+{{- end}}
+{{- if not .Unique}}
+        - $ref: "#/components/parameters/limit"
+        - $ref: "#/components/parameters/offset"
+{{- end}}
+      responses:
+        "200":
+          description: {{if .Unique}}The {{$table.StructName}}{{else}}A page of {{$table.Table.Name}}{{end}}
+{{- if and .Unique $table.Version}}
+          headers:
+            ETag:
+              $ref: "#/components/headers/ETag"
+{{- end}}
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/{{$table.StructName}}{{if not .Unique}}List{{end}}"
+        "400":
+          $ref: "#/components/responses/BadRequest"
+{{- if .Unique}}
+        "404":
+          $ref: "#/components/responses/NotFound"
+{{- end}}
+        default:
+          $ref: "#/components/responses/Error"
+{{- end}}
+{{- end}}
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      description: Maximum number of items returned
+      schema: {"type":"integer","minimum":1,"maximum":1000,"default":50}
+    offset:
+      name: offset
+      in: query
+      description: Number of items skipped
+      schema: {"type":"integer","minimum":0,"default":0}
+    ifMatch:
+      name: If-Match
+      in: header
+      required: true
+      description: ETag of the version being changed
+      schema: {"type":"string"}
+  headers:
+    ETag:
+      description: Version of the returned item, expected as If-Match by the writes
+      schema: {"type":"string"}
+  responses:
+    BadRequest:
+      description: The request is malformed
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    NotFound:
+      description: No item matches the request
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Conflict:
+      description: The item conflicts with a stored one or references a missing one
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    PreconditionFailed:
+      description: The item was changed since the version of If-Match
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    PreconditionRequired:
+      description: The If-Match header is missing
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    UnprocessableEntity:
+      description: The item is invalid
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+    Error:
+      description: Unexpected error
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Error"
+  schemas:
+    Error:
+      type: object
+      required: [error]
+      properties:
+        error: {"type":"string"}
+        fields:
+          description: Messages of the invalid fields
+          type: object
+          additionalProperties: {"type":"string"}
+{{- range .Tables}}
+    {{.StructName}}:
+      description: {{.Description}}
+      type: object
+      properties:
+{{- range .Properties}}
+        {{.Key}}: {{.Schema}}
+{{- end}}
+    Create{{.StructName}}Request:
+      type: object
+      additionalProperties: false
+{{- if .CreateRequired}}
+      required: [{{range $i, $c := .CreateRequired}}{{if $i}}, {{end}}{{$c}}{{end}}]
+{{- end}}
+      properties:{{if not .Create}} {}{{end}}
+{{- range .Create}}
+        {{.Key}}: {{.Schema}}
+{{- end}}
+{{- if .UpdateFields}}
+    Update{{.StructName}}Request:
+      type: object
+      additionalProperties: false
+{{- if .UpdateRequired}}
+      required: [{{range $i, $c := .UpdateRequired}}{{if $i}}, {{end}}{{$c}}{{end}}]
+{{- end}}
+      properties:
+{{- range .Update}}
+        {{.Key}}: {{.Schema}}
+{{- end}}
+{{- end}}
+{{- if .Patch}}
+    Patch{{.StructName}}Request:
+      description: Fields left unset keep their stored value
+      type: object
+      additionalProperties: false
+      properties:
+{{- range .Patch}}
+        {{.Key}}: {{.Schema}}
+{{- end}}
+{{- end}}
+    {{.StructName}}List:
+      type: object
+      required: [items, limit, offset]
+      properties:
+        items:
+          type: array
+          items:
+            $ref: "#/components/schemas/{{.StructName}}"
+        limit: {"type":"integer"}
+        offset: {"type":"integer"}
+{{- end}}
+`
+
+const graphQLSchemaTemplate = `# Code generated by pgx-goose. DO NOT EDIT.
+{{range .Scalars}}
+scalar {{.}}
+{{- end}}
+
+"Position of a page of a connection"
+type PageInfo {
+  hasNextPage: Boolean!
+  hasPreviousPage: Boolean!
+  "Cursor of the preceding page, passed as after"
+  startCursor: String
+  "Cursor of the following page, passed as after"
+  endCursor: String
+}
+{{- range .Tables}}
+
+{{.Description}}
+type {{.StructName}} {
+{{- range .Fields}}
+{{- if .Description}}
+  {{.Description}}
+{{- end}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+{{- range .Parents}}
+  "The {{.Reference}} row referenced by {{.Column}}"
+  {{.Name}}: {{.Table}}{{if not .Nullable}}!{{end}}
+{{- end}}
+{{- range .Children}}
+  "The {{.Table}} referencing this row by {{.Column}}"
+  {{.Name}}(limit: Int = 50, offset: Int = 0): [{{.Table}}!]!
+{{- end}}
+}
+{{- if .Keysets}}
+
+"A page of {{.StructName}} ordered by {{.PrimaryKeyCol}}"
+type {{.StructName}}Connection {
+  nodes: [{{.StructName}}!]!
+  pageInfo: PageInfo!
+}
+{{- end}}
+{{- if .CreateFields}}
+
+input Create{{.StructName}}Input {
+{{- range .CreateFields}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+}
+{{- end}}
+{{- if and .HasKey .UpdateFields}}
+
+input Update{{.StructName}}Input {
+{{- range .UpdateFields}}
+  {{.Name}}: {{.Type}}
+{{- end}}
+}
+{{- end}}
+{{- end}}
+
+type Query {
+{{- range $table := .Tables}}
+{{- if .HasKey}}
+  {{.Query}}(id: {{.IDType}}): {{.StructName}}
+{{- end}}
+{{- if .Keysets}}
+  {{.Query}}Connection(first: Int = 50, after: String): {{.StructName}}Connection!
+{{- else}}
+  {{.Query}}List(limit: Int = 50, offset: Int = 0): [{{.StructName}}!]!
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+  {{$table.FinderName .}}({{$table.FinderArgs .}}): {{$table.StructName}}
+{{- end}}
+{{- end}}
+{{- end}}
+}
+
+type Mutation {
+{{- range .Tables}}
+{{- if .CreateFields}}
+  create{{.StructName}}(input: Create{{.StructName}}Input!): {{.StructName}}!
+{{- end}}
+{{- if .HasKey}}
+{{- if .UpdateFields}}
+  update{{.StructName}}(id: {{.IDType}}, input: Update{{.StructName}}Input!{{with .VersionType}}, version: {{.}}{{end}}): {{.StructName}}!
+{{- end}}
+  delete{{.StructName}}(id: {{.IDType}}{{with .VersionType}}, version: {{.}}{{end}}): Boolean!
+{{- end}}
+{{- end}}
+}
+`
+
+const graphQLResolverTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	
+	"github.com/fsvxavier/pgx-goose/models"
+	"github.com/fsvxavier/pgx-goose/repository/interfaces"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// Resolver resolves the fields of the schema with the repositories. Its QueryResolver,
+// MutationResolver and object resolvers follow the method signatures of gqlgen, so that
+// the resolvers of a gqlgen server can delegate to them.
+type Resolver struct {
+{{- range .Tables}}
+	{{.StructName}}Repository interfaces.{{.InterfaceName}}
+{{- end}}
+}
+
+// QueryResolver resolves the fields of the Query type
+type QueryResolver struct{ *Resolver }
+
+// MutationResolver resolves the fields of the Mutation type
+type MutationResolver struct{ *Resolver }
+
+// Query returns the resolver of the Query type
+func (r *Resolver) Query() *QueryResolver {
+	return &QueryResolver{r}
+}
+
+// Mutation returns the resolver of the Mutation type
+func (r *Resolver) Mutation() *MutationResolver {
+	return &MutationResolver{r}
+}
+{{- range .Tables}}
+{{- if or .Parents .Children}}
+
+// {{.StructName}} returns the resolver of the relations of the {{.StructName}} type
+func (r *Resolver) {{.StructName}}() *{{.StructName}}Resolver {
+	return &{{.StructName}}Resolver{r}
+}
+{{- end}}
+{{- end}}
+
+// Middleware gives every request its own loaders, so that the lookups of the request
+// are batched and cached together
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req.WithContext(WithLoaders(req.Context(), NewLoaders(r))))
+	})
+}
+
+// loaders returns the loaders of the context, or new ones without batching across
+// lookups when the context has none
+func (r *Resolver) loaders(ctx context.Context) *Loaders {
+	if loaders, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return loaders
+	}
+	return NewLoaders(r)
+}
+
+// PageInfo is the position of a page of a connection
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+// newPageInfo returns the position of a keyset paginated page
+func newPageInfo[T any](page *models.Page[T]) PageInfo {
+	var info PageInfo
+	if page.Prev != "" {
+		info.HasPreviousPage, info.StartCursor = true, &page.Prev
+	}
+	if page.Next != "" {
+		info.HasNextPage, info.EndCursor = true, &page.Next
+	}
+	return info
+}
+
+// pageSize returns the number of items requested by a first or limit argument
+func pageSize(n *int) (int, error) {
+	if n == nil {
+		return defaultPageSize, nil
+	}
+	if *n < 1 || *n > maxPageSize {
+		return 0, fmt.Errorf("page size must be between 1 and %d, got %d", maxPageSize, *n)
+	}
+	return *n, nil
+}
+
+// valueOr returns the value of an optional argument, or def when unset
+func valueOr[T any](v *T, def T) T {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+`
+
+const graphQLLoaderTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"sync"
+	"time"
+{{- range .LoaderImports}}
+	"{{.}}"
+{{- end}}
+{{- if .Loaders}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+{{- end}}
+)
+
+const (
+	// loaderWait is how long a loader waits for other keys before fetching a batch
+	loaderWait = 2 * time.Millisecond
+	// loaderMaxBatch is the number of keys fetching a batch without waiting further
+	loaderMaxBatch = 100
+)
+
+// Loader batches the loads of keys requested together into a single fetch, and caches
+// the values for its lifetime, usually a request. Keys missing from the fetched values
+// load the zero value.
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+	
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loaderResult[V]
+	once    sync.Once
+}
+
+// NewLoader returns a loader fetching the values of its batches with fetch
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, cache: make(map[K]*loaderResult[V])}
+}
+
+// Load returns the value of a key, fetched with the other keys loaded in the meantime.
+// The batch is fetched with the context of its first load.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	result, ok := l.cache[key]
+	if !ok {
+		result = &loaderResult[V]{done: make(chan struct{})}
+		l.cache[key] = result
+		
+		if l.batch == nil {
+			batch := &loaderBatch[K, V]{}
+			l.batch = batch
+			time.AfterFunc(loaderWait, func() { l.dispatch(ctx, batch) })
+		}
+		batch := l.batch
+		batch.keys = append(batch.keys, key)
+		batch.results = append(batch.results, result)
+		if len(batch.keys) >= loaderMaxBatch {
+			l.batch = nil
+			go l.dispatch(ctx, batch)
+		}
+	}
+	l.mu.Unlock()
+	
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches a batch once and completes its loads. Failed keys are evicted so
+// that later loads retry them.
+func (l *Loader[K, V]) dispatch(ctx context.Context, batch *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch == batch {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+	
+	batch.once.Do(func() {
+		values, err := l.fetch(ctx, batch.keys)
+		if err != nil {
+			l.mu.Lock()
+			for _, key := range batch.keys {
+				delete(l.cache, key)
+			}
+			l.mu.Unlock()
+		}
+		for i, key := range batch.keys {
+			batch.results[i].value, batch.results[i].err = values[key], err
+			close(batch.results[i].done)
+		}
+	})
+}
+
+// Loaders holds the loaders of the rows referenced by the relations
+type Loaders struct {
+{{- range .Loaders}}
+	{{.StructName}}ByID *Loader[{{.PrimaryKeyType}}, *models.{{.StructName}}]
+{{- end}}
+}
+
+// NewLoaders returns loaders fetching their batches with GetByIDs
+func NewLoaders(r *Resolver) *Loaders {
+	return &Loaders{
+{{- range .Loaders}}
+		{{.StructName}}ByID: NewLoader(func(ctx context.Context, ids []{{.PrimaryKeyType}}) (map[{{.PrimaryKeyType}}]*models.{{.StructName}}, error) {
+			{{lower .StructName}}s, _, err := r.{{.StructName}}Repository.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[{{.PrimaryKeyType}}]*models.{{.StructName}}, len({{lower .StructName}}s))
+			for _, {{lower .StructName}} := range {{lower .StructName}}s {
+				byID[{{lower .StructName}}.{{.PrimaryKeyField}}] = {{lower .StructName}}
+			}
+			return byID, nil
+		}),
+{{- end}}
+	}
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a context carrying loaders, shared by the lookups using it
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+`
+
+const graphQLTableResolverTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+{{- if .HasKey}}
+	"errors"
+{{- end}}
+{{- if .Parents}}
+	"fmt"
+{{- end}}
+{{- range .ResolverImports}}
+	"{{.}}"
+{{- end}}
+	
+	"github.com/fsvxavier/pgx-goose/models"
+{{- if .HasKey}}
+	"github.com/fsvxavier/pgx-goose/repository/postgres"
+{{- end}}
+)
+{{- if .CreateFields}}
+
+// Create{{.StructName}}Input is the input of the create{{.StructName}} mutation
+{{- if .CreateDefaults}}, the
+// columns with a default are left to the database while their field is unset
+{{- end}}
+type Create{{.StructName}}Input struct {
+{{- range .CreateFields}}
+	{{.Field}} {{.InputType}}
+{{- end}}
+}
+
+// model returns the {{.StructName}} created by the input
+func (in Create{{.StructName}}Input) model() *models.{{.StructName}} {
+	{{lower .StructName}} := &models.{{.StructName}}{}
+{{- range .CreateFields}}
+{{- if or .Required .Pointer (eq .GoType "interface{}")}}
+	{{lower $.StructName}}.{{.Field}} = in.{{.Field}}
+{{- else}}
+	if in.{{.Field}} != nil {
+		{{lower $.StructName}}.{{.Field}} = *in.{{.Field}}
+	}
+{{- end}}
+{{- end}}
+	return {{lower .StructName}}
+}
+{{- end}}
+{{- if and .HasKey .UpdateFields}}
+
+// Update{{.StructName}}Input is the input of the update{{.StructName}} mutation
+type Update{{.StructName}}Input struct {
+{{- range .UpdateFields}}
+	{{.Field}} {{.InputType}}
+{{- end}}
+}
+
+// model returns the {{.StructName}} replaced by the input
+func (in Update{{.StructName}}Input) model(id {{.PrimaryKeyType}}) *models.{{.StructName}} {
+	{{lower .StructName}} := &models.{{.StructName}}{ {{- .PrimaryKeyField}}: id}
+{{- range .UpdateFields}}
+{{- if or .Required .Pointer (eq .GoType "interface{}")}}
+	{{lower $.StructName}}.{{.Field}} = in.{{.Field}}
+{{- else}}
+	if in.{{.Field}} != nil {
+		{{lower $.StructName}}.{{.Field}} = *in.{{.Field}}
+	}
+{{- end}}
+{{- end}}
+	return {{lower .StructName}}
+}
+{{- end}}
+{{- if .Keysets}}
+
+// {{.StructName}}Connection is a page of {{.StructName}}s
+type {{.StructName}}Connection struct {
+	Nodes    []*models.{{.StructName}}
+	PageInfo PageInfo
+}
+{{- end}}
+{{- if .HasKey}}
+
+// {{.StructName}} resolves the {{.Query}} query, nil when no {{.StructName}} has the id
+func (r *QueryResolver) {{.StructName}}(ctx context.Context, id {{.PrimaryKeyType}}) (*models.{{.StructName}}, error) {
+	{{lower .StructName}}, err := r.{{.StructName}}Repository.GetByID(ctx, id)
+	if errors.Is(err, postgres.ErrNotFound) {
+		return nil, nil
+	}
+	return {{lower .StructName}}, err
+}
+{{- end}}
+{{- if .Keysets}}
+
+// {{.StructName}}Connection resolves the {{.Query}}Connection query
+func (r *QueryResolver) {{.StructName}}Connection(ctx context.Context, first *int, after *string) (*{{.StructName}}Connection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.{{.StructName}}Repository.ListAfter(ctx, valueOr(after, ""), limit)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.StructName}}Connection{Nodes: page.Items, PageInfo: newPageInfo(page)}, nil
+}
+{{- else}}
+
+// {{.StructName}}List resolves the {{.Query}}List query
+func (r *QueryResolver) {{.StructName}}List(ctx context.Context, limit *int, offset *int) ([]*models.{{.StructName}}, error) {
+	n, err := pageSize(limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.{{.StructName}}Repository.List(ctx, n, valueOr(offset, 0))
+}
+{{- end}}
+{{- range .Finders}}
+{{- if .Unique}}
+
+// {{$.StructName}}By{{.Name}} resolves the {{$.FinderName .}} query, nil when no {{$.StructName}} matches
+func (r *QueryResolver) {{$.StructName}}By{{.Name}}(ctx context.Context, {{.Signature}}) (*models.{{$.StructName}}, error) {
+	{{lower $.StructName}}, err := r.{{$.StructName}}Repository.GetBy{{.Name}}(ctx, {{.Args}})
+	if errors.Is(err, postgres.ErrNotFound) {
+		return nil, nil
+	}
+	return {{lower $.StructName}}, err
+}
+{{- end}}
+{{- end}}
+{{- if .CreateFields}}
+
+// Create{{.StructName}} resolves the create{{.StructName}} mutation
+func (r *MutationResolver) Create{{.StructName}}(ctx context.Context, input Create{{.StructName}}Input) (*models.{{.StructName}}, error) {
+	{{lower .StructName}} := input.model()
+	if err := r.{{.StructName}}Repository.Create(ctx, {{lower .StructName}}); err != nil {
+		return nil, err
+	}
+{{- if .HasKey}}
+	
+	// Read back the columns set by the database
+	return r.{{.StructName}}Repository.GetByID(ctx, {{lower .StructName}}.{{.PrimaryKeyField}})
+{{- else}}
+	return {{lower .StructName}}, nil
+{{- end}}
+}
+{{- end}}
+{{- if .HasKey}}
+{{- if .UpdateFields}}
+
+// Update{{.StructName}} resolves the update{{.StructName}} mutation
+func (r *MutationResolver) Update{{.StructName}}(ctx context.Context, id {{.PrimaryKeyType}}, input Update{{.StructName}}Input{{with .Version}}, version {{.GoType}}{{end}}) (*models.{{.StructName}}, error) {
+	{{lower .StructName}} := input.model(id)
+{{- with .Version}}
+	{{lower $.StructName}}.{{.Field}} = version
+{{- end}}
+	if err := r.{{.StructName}}Repository.Update(ctx, {{lower .StructName}}); err != nil {
+		return nil, err
+	}
+	return r.{{.StructName}}Repository.GetByID(ctx, id)
+}
+{{- end}}
+
+// Delete{{.StructName}} resolves the delete{{.StructName}} mutation
+func (r *MutationResolver) Delete{{.StructName}}(ctx context.Context, id {{.PrimaryKeyType}}{{with .Version}}, version {{.GoType}}{{end}}) (bool, error) {
+	if err := r.{{.StructName}}Repository.Delete(ctx, id{{if .Version}}, version{{end}}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+{{- end}}
+{{- if or .Parents .Children}}
+
+// {{.StructName}}Resolver resolves the relations of the {{.StructName}} type
+type {{.StructName}}Resolver struct{ *Resolver }
+{{- end}}
+{{- range .Parents}}
+
+// {{.Method}} resolves the {{.Reference}} row referenced by {{.Column}}, loaded in batches
+func (r *{{$.StructName}}Resolver) {{.Method}}(ctx context.Context, obj *models.{{$.StructName}}) (*models.{{.Table}}, error) {
+{{- if .Nullable}}
+	if obj.{{.Field}} == nil {
+		return nil, nil
+	}
+	{{lower .Table}}, err := r.loaders(ctx).{{.Table}}ByID.Load(ctx, *obj.{{.Field}})
+{{- else}}
+	{{lower .Table}}, err := r.loaders(ctx).{{.Table}}ByID.Load(ctx, obj.{{.Field}})
+{{- end}}
+	if err != nil {
+		return nil, err
+	}
+	if {{lower .Table}} == nil {
+		return nil, fmt.Errorf("{{.Reference}} %v referenced by {{.Column}}: %w", obj.{{.Field}}, postgres.ErrNotFound)
+	}
+	return {{lower .Table}}, nil
+}
+{{- end}}
+{{- range .Children}}
+
+// {{.Method}} resolves the {{.Table}}s referencing the {{$.StructName}} by {{.Column}}
+func (r *{{$.StructName}}Resolver) {{.Method}}(ctx context.Context, obj *models.{{$.StructName}}, limit *int, offset *int) ([]*models.{{.Table}}, error) {
+	n, err := pageSize(limit)
+	if err != nil {
+		return nil, err
+	}
+	filter := models.{{.Table}}Filter{ {{- .Field}}: {{.FilterValue (print "&obj." .KeyField)}}}
+	return r.{{.Table}}Repository.Find(ctx, filter, models.FindOptions[models.{{.Table}}SortField]{Limit: n, Offset: valueOr(offset, 0)})
+}
+{{- end}}
+`
+
+const jsonSchemaTemplate = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$comment": "Code generated by pgx-goose. DO NOT EDIT.",
+  "title": "{{.StructName}}",
+  "description": {{.Description}},
+  "type": "object",
+  "properties": {
+{{- range $i, $p := .Properties}}{{if $i}},{{end}}
+    {{$p.Key}}: {{$p.Schema}}
+{{- end}}
+  },
+{{- if .Required}}
+  "required": [{{range $i, $name := .Required}}{{if $i}}, {{end}}{{$name}}{{end}}],
+{{- end}}
+  "additionalProperties": false
+}
+`
+
+const typeScriptTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+
+/** {{.Description}} */
+export interface {{.StructName}} {
+{{- range .Properties}}
+{{- if .Doc}}
+  /** {{.Doc}} */
+{{- end}}
+  {{.Key}}{{if .Optional}}?{{end}}: {{.Type}};
+{{- end}}
+}
+`
+
+const typeScriptIndexTemplate = `// Code generated by pgx-goose. DO NOT EDIT.
+{{range .}}
+export * from "./{{.}}";
+{{- end}}
 `
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// Markers of the tenant in the generated SQL, bound from the context of the queries by
+// the withTenancy querier of tenancy.tmpl
+const (
+	tenantParam  = "$tenant"  // Replaced by a parameter holding the tenant ID
+	tenantSchema = "$schema." // Replaced by the schema of the tenant
+)
+
+// Tenant describes the column scoping the rows of a table to the tenant of the queries
+type Tenant struct {
+	Column string
+	Field  string // Model field of the column
+}
+
+// newTenant returns the tenant column of a table, or nil when the table does not have it
+func newTenant(table introspector.Table, column string) *Tenant {
+	for _, col := range table.Columns {
+		if col.Name == column {
+			return &Tenant{Column: col.Name, Field: toPascalCase(col.Name)}
+		}
+	}
+	return nil
+}
+
+// Condition returns the SQL condition matching the rows of the tenant
+func (t *Tenant) Condition() string {
+	return t.Column + " = " + tenantParam
+}
+
+// Param returns the marker of the tenant ID, the value inserted in the tenant column
+func (t *Tenant) Param() string {
+	return tenantParam
+}
+
+// tenantTable returns a table as referenced by the generated SQL, qualified with the
+// schema of the tenant when every tenant has its own schema
+func tenantTable(name string, schemaPerTenant bool) string {
+	if schemaPerTenant {
+		return tenantSchema + name
+	}
+	return name
+}
+
+// scopeFinders removes the tenant column from the finders of a tenant scoped table, the
+// tenant being matched by every query. Finders on the tenant column alone are dropped.
+func scopeFinders(finders []Finder, tenant *Tenant) []Finder {
+	seen := make(map[string]bool)
+	var scoped []Finder
+	for _, finder := range finders {
+		var columns []FinderColumn
+		var names []string
+		for _, col := range finder.Columns {
+			if col.Name != tenant.Column {
+				columns = append(columns, col)
+				names = append(names, col.Name)
+			}
+		}
+
+		key := strings.Join(names, ",")
+		if len(columns) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		finder.Name = finderName(names)
+		finder.Columns = columns
+		scoped = append(scoped, finder)
+	}
+	return scoped
+}
+
+// scopeUpserts makes the upserts of a tenant scoped table insert the tenant ID rather
+// than a value of the model. The conflict targets keep the tenant column, but it no
+// longer names the unique index upserts.
+func scopeUpserts(upserts []Upsert, tenant *Tenant) []Upsert {
+	var scoped []Upsert
+	for _, upsert := range upserts {
+		upsert.Tenant = tenant.Column
+		upsert.InsertColumns = withoutColumn(upsert.InsertColumns, tenant.Column)
+		upsert.UpdateColumns = withoutColumn(upsert.UpdateColumns, tenant.Column)
+
+		if upsert.Name != "Upsert" {
+			var names []string
+			for _, name := range upsert.ConflictColumns {
+				if name != tenant.Column {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				continue
+			}
+			upsert.Name = "UpsertBy" + finderName(names)
+		}
+		scoped = append(scoped, upsert)
+	}
+	return scoped
+}
+
+// withoutColumn returns the columns except the named one
+func withoutColumn(columns []introspector.Column, name string) []introspector.Column {
+	var kept []introspector.Column
+	for _, col := range columns {
+		if col.Name != name {
+			kept = append(kept, col)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestNewTenant(t *testing.T) {
+	tenant := newTenant(testTable("projects"), "tenant_id")
+	require.NotNil(t, tenant)
+	assert.Equal(t, "TenantId", tenant.Field)
+	assert.Equal(t, "tenant_id = $tenant", tenant.Condition())
+
+	assert.Nil(t, newTenant(testTable("projects"), "org_id"))
+	assert.Equal(t, "$schema.projects", tenantTable("projects", true))
+	assert.Equal(t, "projects", tenantTable("projects", false))
+}
+
+func TestScopeFindersAndUpserts(t *testing.T) {
+	table := testTable("projects")
+	tenant := newTenant(table, "tenant_id")
+
+	finders := scopeFinders(buildFinders(table), tenant)
+	require.Len(t, finders, 1, "the finder on the tenant alone is dropped")
+	assert.Equal(t, "Slug", finders[0].Name)
+	assert.Equal(t, "slug = $1", finders[0].Where())
+
+	upserts := scopeUpserts(buildUpserts(table, nil), tenant)
+	require.Len(t, upserts, 1)
+	assert.Equal(t, "UpsertBySlug", upserts[0].Name)
+	assert.Equal(t, "tenant_id, slug", upserts[0].ConflictTarget())
+	assert.Equal(t, "slug, name, tenant_id", upserts[0].ColumnList())
+	assert.Equal(t, "$1, $2, $tenant", upserts[0].Placeholders())
+	assert.Equal(t, "name = EXCLUDED.name", upserts[0].SetClause())
+}
+
+func TestRepositoryTemplates_Tenancy(t *testing.T) {
+	cfg := &config.Config{Tenancy: config.TenancyConfig{Mode: "column"}}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	data := g.newRepositoryTemplateData(testTable("projects"), "postgres")
+	require.NotNil(t, data.Tenant)
+	for _, col := range append(data.InsertColumns, data.UpdateColumns...) {
+		assert.NotEqual(t, "tenant_id", col.Name, "the tenant is never written from the model")
+	}
+
+	src := renderGoSource(t, g, "repository_postgres.tmpl", data)
+	assert.Contains(t, src.Doc("NewProjectsRepository"), "Its queries only read and write the rows of the tenant of their context, see WithTenant.")
+	assert.Contains(t, src.Calls("NewProjectsRepository", "withTenancy"), `withHooks(db, o.hooks, "projects")`)
+	assert.Equal(t, []string{"INSERT INTO projects (slug, name, tenant_id ) VALUES ($1, $2, $tenant ) RETURNING id, tenant_id"}, src.Strings("createProjectsQuery"))
+	assert.Contains(t, src.Calls("ProjectsRepository.Create", "Scan"), "&projects.Id, &projects.TenantId")
+	assert.Equal(t, []string{"UPDATE projects SET slug = $1, name = $2 WHERE id = $3 AND tenant_id = $tenant"}, src.Strings("updateProjectsQuery"))
+	assert.Equal(t, []string{"DELETE FROM projects WHERE id = $1 AND tenant_id = $tenant"}, src.Strings("deleteProjectsQuery"))
+	assert.Contains(t, src.Strings("ProjectsRepository.Count"), "SELECT COUNT(*) FROM projects WHERE tenant_id = $tenant")
+	assert.Contains(t, src.Strings("ProjectsRepository.where"), "tenant_id = $tenant")
+	assert.Contains(t, src.Strings("ProjectsRepository.Patch"), "UPDATE projects SET %s WHERE id = $%d AND tenant_id = $tenant RETURNING id, tenant_id, slug, name")
+	assert.Contains(t, src.Strings("ProjectsRepository.InsertMany"), "INSERT INTO projects (slug, name, tenant_id) VALUES")
+	assert.True(t, src.Uses("ProjectsRepository.InsertMany", "tenantParam"))
+	assert.Contains(t, src.Strings("ProjectsRepository.CreateMany"), "tenant_id", "the tenant is copied too")
+	assert.Contains(t, src.Strings("ProjectsRepository.UpsertBySlug"), "INSERT INTO projects (slug, name, tenant_id) VALUES ($1, $2, $tenant) "+
+		"ON CONFLICT (tenant_id, slug) DO UPDATE SET name = EXCLUDED.name WHERE projects.tenant_id = EXCLUDED.tenant_id RETURNING id, tenant_id, slug, name")
+	assert.Equal(t, "func(ctx context.Context, slug string) (*models.Projects, error)", src.Signature("ProjectsRepository.GetBySlug"))
+	assert.Contains(t, src.Strings("ProjectsRepository.GetBySlug"), "SELECT id, tenant_id, slug, name FROM projects WHERE slug = $1 AND tenant_id = $tenant")
+
+	// Tables without the tenant column are left unscoped
+	data = g.newRepositoryTemplateData(testTable("posts"), "postgres")
+	assert.Nil(t, data.Tenant)
+	src = renderGoSource(t, g, "repository_postgres.tmpl", data)
+	assert.Equal(t, []string{"DELETE FROM posts WHERE id = $1"}, src.Strings("deletePostsQuery"))
+}
+
+func TestGenerator_GenerateTenancy(t *testing.T) {
+	cfg := &config.Config{
+		OutputDir:    t.TempDir(),
+		MockProvider: "testify",
+		Tenancy:      config.TenancyConfig{Mode: "schema"},
+	}
+	cfg.ApplyDefaults()
+	g := New(cfg)
+
+	require.NoError(t, g.Generate(&introspector.Schema{Tables: []introspector.Table{testTable("projects")}}))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetReposDir(), "tenancy.go"))
+	require.NoError(t, err)
+	src := parseGoSource(t, string(content))
+	assert.Equal(t, "func(ctx context.Context, schema string) context.Context", src.Signature("WithTenantSchema"))
+	assert.False(t, src.Has("WithTenant"), "tenants are schemas")
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetReposDir(), "projects_repository.go"))
+	require.NoError(t, err)
+	src = parseGoSource(t, string(content))
+	assert.Contains(t, src.Doc("NewProjectsRepository"), "Its queries run on the table of the schema of their context, see WithTenantSchema.")
+	assert.Equal(t, []string{"INSERT INTO $schema.projects (tenant_id, slug, name ) VALUES ($1, $2, $3 ) RETURNING id"}, src.Strings("createProjectsQuery"), "the tenant column is written as any other")
+	assert.Contains(t, src.Strings("ProjectsRepository.GetByID"), "SELECT id, tenant_id, slug, name FROM $schema.projects WHERE id = $1")
+	assert.False(t, src.Uses("ProjectsRepository.InsertMany", "tenantParam"))
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetReposDir(), "context.go"))
+	require.NoError(t, err)
+	assert.True(t, parseGoSource(t, string(content)).Has("tenantKey"))
+}
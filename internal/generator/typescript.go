@@ -0,0 +1,121 @@
+package generator
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// typeScriptIdentifier matches the property names written without quotes
+var typeScriptIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// TypeScriptProperty is a property of the interface of a model
+type TypeScriptProperty struct {
+	Name     string // JSON name of the field, the column name
+	Type     string // TypeScript type, e.g. "string | null"
+	Doc      string // Column comment, empty without comment
+	Optional bool   // The field is omitted when empty
+}
+
+// Key returns the name of the property, quoted unless it is an identifier
+func (p TypeScriptProperty) Key() string {
+	if typeScriptIdentifier.MatchString(p.Name) {
+		return p.Name
+	}
+	return jsonString(p.Name)
+}
+
+// typeScriptTemplateData holds the data of the TypeScript declarations of a model
+type typeScriptTemplateData struct {
+	StructName  string
+	Description string
+	Properties  []TypeScriptProperty
+}
+
+// newTypeScriptTemplateData builds the interface of the JSON encoding of the model of a table
+func newTypeScriptTemplateData(table introspector.Table) typeScriptTemplateData {
+	data := typeScriptTemplateData{
+		StructName:  toPascalCase(table.Name),
+		Description: typeScriptDoc(tableDescription(table)),
+	}
+	for _, col := range table.Columns {
+		data.Properties = append(data.Properties, TypeScriptProperty{
+			Name:     col.Name,
+			Type:     typeScriptColumnType(col),
+			Doc:      typeScriptDoc(col.Comment),
+			Optional: omitsEmpty(col.GoType),
+		})
+	}
+	return data
+}
+
+// typeScriptColumnType returns the TypeScript type of the values of a column as encoded
+// by the models. Enums are unions of their labels, nullable columns also allow null.
+func typeScriptColumnType(col introspector.Column) string {
+	var tsType string
+	if len(col.EnumValues) > 0 {
+		labels := make([]string, len(col.EnumValues))
+		for i, label := range col.EnumValues {
+			labels[i] = jsonString(label)
+		}
+		tsType = strings.Join(labels, " | ")
+	} else {
+		switch strings.TrimPrefix(col.GoType, "*") {
+		case "int", "int16", "int32", "int64", "uint32", "float32", "float64":
+			tsType = "number"
+		case "bool":
+			tsType = "boolean"
+		case "string", "time.Time", "uuid.UUID", "decimal.Decimal", "[]byte":
+			// Times are RFC 3339, decimals are quoted and bytes are base64
+			tsType = "string"
+		default:
+			// JSON documents and unknown types hold any value, null included
+			return "unknown"
+		}
+	}
+
+	if col.IsNullable {
+		tsType += " | null"
+	}
+	return tsType
+}
+
+// typeScriptDoc returns s for a JSDoc comment, which it must not end
+func typeScriptDoc(s string) string {
+	return strings.ReplaceAll(s, "*/", "*\\/")
+}
+
+// generateTypeScript generates the TypeScript declarations of the JSON encoding of each
+// model, and an index exporting them all
+func (g *Generator) generateTypeScript(schema *introspector.Schema) error {
+	slog.Info("Generating TypeScript declarations...")
+
+	tmpl, err := g.getTemplate("typescript.tmpl")
+	if err != nil {
+		return err
+	}
+
+	var modules []string
+	for _, table := range schema.Tables {
+		table = withSystemVersion(table, g.getVersionColumnName(table))
+
+		module := toSnakeCase(table.Name)
+		if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetTypeScriptDir(), module+".d.ts"), newTypeScriptTemplateData(table)); err != nil {
+			return fmt.Errorf("failed to generate TypeScript declarations for table %s: %w", table.Name, err)
+		}
+		modules = append(modules, module)
+	}
+
+	tmpl, err = g.getTemplate("typescript_index.tmpl")
+	if err != nil {
+		return err
+	}
+	if err := g.writeTemplate(tmpl, filepath.Join(g.config.GetTypeScriptDir(), "index.d.ts"), modules); err != nil {
+		return fmt.Errorf("failed to generate index.d.ts: %w", err)
+	}
+	return nil
+}
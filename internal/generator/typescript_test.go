@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestTypeScriptColumnType(t *testing.T) {
+	tests := []struct {
+		name string
+		col  introspector.Column
+		want string
+	}{
+		{name: "bigint", col: introspector.Column{GoType: "int64"}, want: "number"},
+		{name: "nullable timestamp", col: introspector.Column{GoType: "*time.Time", IsNullable: true}, want: "string | null"},
+		{name: "numeric", col: introspector.Column{GoType: "decimal.Decimal"}, want: "string"},
+		{name: "enum", col: introspector.Column{GoType: "interface{}", EnumValues: []string{"open", `"quoted"`}}, want: `"open" | "\"quoted\""`},
+		{name: "nullable enum", col: introspector.Column{GoType: "interface{}", IsNullable: true, EnumValues: []string{"full"}}, want: `"full" | null`},
+		{name: "nullable json", col: introspector.Column{GoType: "*json.RawMessage", IsNullable: true}, want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, typeScriptColumnType(tt.col))
+		})
+	}
+}
+
+func TestTypeScriptProperty_Key(t *testing.T) {
+	assert.Equal(t, "placed_at", TypeScriptProperty{Name: "placed_at"}.Key())
+	assert.Equal(t, `"ship-to"`, TypeScriptProperty{Name: "ship-to"}.Key())
+	assert.Equal(t, `"2fa"`, TypeScriptProperty{Name: "2fa"}.Key())
+}
+
+func TestGenerator_GenerateTypeScript(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		OutputDir:  dir,
+		OutputDirs: config.OutputDirs{TypeScript: filepath.Join(dir, "types")},
+	}
+	cfg.ApplyDefaults()
+
+	schema := testTables("orders", "accounts")
+	schema.Tables[0].Columns = append(schema.Tables[0].Columns, introspector.Column{Name: "gift-note", Type: "text", GoType: "*string", IsNullable: true, Position: 14})
+	require.NoError(t, New(cfg).Generate(schema))
+
+	content, err := os.ReadFile(filepath.Join(cfg.GetTypeScriptDir(), "orders.d.ts"))
+	require.NoError(t, err)
+	blocks := schemaBlocks(string(content))
+	assert.Equal(t, []string{"/** Orders of the shop */"}, blocks[""])
+	assert.Equal(t, []string{
+		"id: string;",
+		"number?: number;",
+		`status?: "open" | "paid";`,
+		`refund?: "partial" | "full" | null;`,
+		"items?: number;",
+		"total: string;",
+		`/** Free text, not /* parsed *\/ */`,
+		"note?: string | null;",
+		"placed_at: string;",
+		"paid_at?: string | null;",
+		"due_on: string;",
+		"receipt?: string;",
+		"ship_to?: unknown;",
+		`"gift-note"?: string | null;`,
+	}, blocks["export interface Orders"])
+
+	content, err = os.ReadFile(filepath.Join(cfg.GetTypeScriptDir(), "index.d.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by pgx-goose. DO NOT EDIT.\n\nexport * from \"./orders\";\nexport * from \"./accounts\";\n", string(content))
+}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// Upsert describes an INSERT ... ON CONFLICT method derived from the primary key or a unique index
+type Upsert struct {
+	Name            string                // Method name, "Upsert" for the primary key or e.g. "UpsertByEmail"
+	Source          string                // Name of the index the conflict target comes from
+	ConflictColumns []string              // Columns of the conflict target, in index order
+	InsertColumns   []introspector.Column // Columns written by the INSERT
+	UpdateColumns   []introspector.Column // Columns overwritten when the row already exists
+	Version         string                // Assignment bumping the row version, empty without optimistic locking
+	Tenant          string                // Tenant column inserted with the tenant ID, empty unless the table is tenant scoped
+}
+
+// ConflictTarget returns the comma separated conflict target columns
+func (u Upsert) ConflictTarget() string {
+	return strings.Join(u.ConflictColumns, ", ")
+}
+
+// Description returns a human readable list of the conflict target columns
+func (u Upsert) Description() string {
+	return strings.Join(u.ConflictColumns, " and ")
+}
+
+// ColumnList returns the comma separated inserted columns
+func (u Upsert) ColumnList() string {
+	names := make([]string, len(u.InsertColumns))
+	for i, col := range u.InsertColumns {
+		names[i] = col.Name
+	}
+	if u.Tenant != "" {
+		names = append(names, u.Tenant)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Placeholders returns the VALUES placeholders matching ColumnList
+func (u Upsert) Placeholders() string {
+	placeholders := make([]string, len(u.InsertColumns))
+	for i := range u.InsertColumns {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	if u.Tenant != "" {
+		placeholders = append(placeholders, tenantParam)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// SetClause returns the DO UPDATE assignments taking the values proposed for insertion,
+// followed by the version increment. When every column is excluded the first conflict
+// column is reassigned, so that the statement still locks and returns the existing row.
+func (u Upsert) SetClause() string {
+	names := make([]string, 0, len(u.UpdateColumns))
+	for _, col := range u.UpdateColumns {
+		names = append(names, col.Name)
+	}
+	if len(names) == 0 && u.Version == "" && len(u.ConflictColumns) > 0 {
+		names = append(names, u.ConflictColumns[0])
+	}
+
+	parts := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		parts = append(parts, name+" = EXCLUDED."+name)
+	}
+	if u.Version != "" {
+		parts = append(parts, u.Version)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildUpserts derives upsert methods from the primary key and the unique indexes of a table.
+// The primary key upsert writes the key supplied by the caller, while unique index upserts
+// let the database fill keys with a default. Columns listed in excludeColumns are inserted
+// but never overwritten, and targets covering generated columns are skipped since those
+// values cannot be proposed for insertion.
+func buildUpserts(table introspector.Table, excludeColumns []string) []Upsert {
+	columns := make(map[string]introspector.Column, len(table.Columns))
+	for _, col := range table.Columns {
+		columns[col.Name] = col
+	}
+
+	excluded := make(map[string]bool, len(excludeColumns))
+	for _, name := range excludeColumns {
+		excluded[strings.ToLower(name)] = true
+	}
+
+	pks := primaryKeyColumns(table)
+	isPrimaryKey := make(map[string]bool, len(pks))
+	for _, name := range pks {
+		isPrimaryKey[name] = true
+	}
+
+	seen := make(map[string]bool)
+	var upserts []Upsert
+
+	add := func(name, source string, conflict []string, insert []introspector.Column) {
+		key := strings.Join(conflict, ",")
+		if len(conflict) == 0 || seen[key] {
+			return
+		}
+
+		inserted := make(map[string]bool, len(insert))
+		for _, col := range insert {
+			inserted[col.Name] = true
+		}
+		isConflict := make(map[string]bool, len(conflict))
+		for _, name := range conflict {
+			if _, ok := columns[name]; !ok || !inserted[name] {
+				return
+			}
+			isConflict[name] = true
+		}
+
+		upsert := Upsert{Name: name, Source: source, ConflictColumns: conflict, InsertColumns: insert}
+		for _, col := range insert {
+			if isConflict[col.Name] || isPrimaryKey[col.Name] || excluded[strings.ToLower(col.Name)] {
+				continue
+			}
+			upsert.UpdateColumns = append(upsert.UpdateColumns, col)
+		}
+
+		seen[key] = true
+		upserts = append(upserts, upsert)
+	}
+
+	var withKey, withoutKey []introspector.Column
+	for _, col := range table.Columns {
+		if col.IsGenerated {
+			continue
+		}
+		withKey = append(withKey, col)
+		if !(col.IsPrimaryKey && col.DefaultValue != nil) {
+			withoutKey = append(withoutKey, col)
+		}
+	}
+
+	add("Upsert", "primary key", pks, withKey)
+	for _, idx := range sortedIndexes(table.Indexes) {
+		if idx.IsUnique {
+			add("UpsertBy"+finderName(idx.Columns), idx.Name, idx.Columns, withoutKey)
+		}
+	}
+
+	return upserts
+}
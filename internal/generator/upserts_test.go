@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fsvxavier/pgx-goose/internal/config"
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+func TestBuildUpserts(t *testing.T) {
+	table := testTable("accounts")
+	table.Columns = append(table.Columns, introspector.Column{Name: "search", GoType: "*string", IsGenerated: true})
+	table.Indexes = append(table.Indexes, introspector.Index{Name: "accounts_search_key", Columns: []string{"search"}, IsUnique: true})
+	upserts := buildUpserts(table, []string{"DELETED_AT", "REVISION"})
+
+	// Generated columns cannot be a conflict target and non-unique indexes are skipped
+	require.Len(t, upserts, 2)
+
+	t.Run("primary key", func(t *testing.T) {
+		u := upserts[0]
+		assert.Equal(t, "Upsert", u.Name)
+		assert.Equal(t, "id", u.ConflictTarget())
+		assert.Equal(t, "id, email, nickname, plan, balance, revision, version, deleted_at", u.ColumnList())
+		assert.Equal(t, "$1, $2, $3, $4, $5, $6, $7, $8", u.Placeholders())
+		assert.Equal(t, "email = EXCLUDED.email, nickname = EXCLUDED.nickname, plan = EXCLUDED.plan, balance = EXCLUDED.balance, version = EXCLUDED.version", u.SetClause())
+	})
+
+	t.Run("unique index", func(t *testing.T) {
+		u := upserts[1]
+		assert.Equal(t, "UpsertByEmail", u.Name)
+		assert.Equal(t, "accounts_email_key", u.Source)
+		assert.Equal(t, "email, nickname, plan, balance, revision, version, deleted_at", u.ColumnList(), "keys with a default are left to the database")
+		assert.Equal(t, "nickname = EXCLUDED.nickname, plan = EXCLUDED.plan, balance = EXCLUDED.balance, version = EXCLUDED.version", u.SetClause())
+	})
+}
+
+func TestBuildUpserts_EverythingExcluded(t *testing.T) {
+	table := introspector.Table{
+		Name: "tags",
+		Columns: []introspector.Column{
+			{Name: "name", GoType: "string", IsPrimaryKey: true},
+			{Name: "created_at", GoType: "time.Time"},
+		},
+		PrimaryKeys: []string{"name"},
+	}
+
+	upserts := buildUpserts(table, []string{"created_at"})
+	require.Len(t, upserts, 1)
+	assert.Equal(t, "name = EXCLUDED.name", upserts[0].SetClause())
+}
+
+func TestUpsert_SetClauseWithVersion(t *testing.T) {
+	u := Upsert{
+		ConflictColumns: []string{"id"},
+		UpdateColumns:   []introspector.Column{{Name: "name"}},
+		Version:         "version = users.version + 1",
+	}
+	assert.Equal(t, "name = EXCLUDED.name, version = users.version + 1", u.SetClause())
+
+	u.UpdateColumns = nil
+	assert.Equal(t, "version = users.version + 1", u.SetClause(), "the version increment is enough to lock the row")
+}
+
+func TestBuildUpserts_NoPrimaryKey(t *testing.T) {
+	table := introspector.Table{
+		Name: "logs",
+		Columns: []introspector.Column{
+			{Name: "message", GoType: "string"},
+		},
+	}
+
+	assert.Empty(t, buildUpserts(table, nil))
+}
+
+func TestRepositoryTemplateData_UpsertsKeepVersionAndSoftDelete(t *testing.T) {
+	cfg := &config.Config{
+		SoftDelete:        config.SoftDeleteConfig{Enabled: true},
+		OptimisticLocking: config.OptimisticLockingConfig{Enabled: true},
+	}
+	cfg.ApplyDefaults()
+	data := New(cfg).newRepositoryTemplateData(testTable("accounts"), "postgres")
+
+	require.NotEmpty(t, data.Upserts)
+	assert.Equal(t, "email = EXCLUDED.email, nickname = EXCLUDED.nickname, plan = EXCLUDED.plan, balance = EXCLUDED.balance, revision = EXCLUDED.revision, version = accounts.version + 1", data.Upserts[0].SetClause(),
+		"the version is bumped and deleted_at is only changed by Delete and Restore")
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/fsvxavier/pgx-goose/internal/introspector"
+)
+
+// xminColumn is the system column holding the id of the transaction that last wrote a row.
+// PostgreSQL changes it on every update, so it can serve as a version without a schema change.
+const xminColumn = "xmin"
+
+// VersionColumn describes the column guarding updates and deletes with optimistic locking
+type VersionColumn struct {
+	Table  string // Table name, qualifying the column in increments
+	Column string // Column name
+	Field  string // Model field name
+	GoType string // Go type of the version, also used by the Delete parameter
+	System bool   // The xmin system column, maintained by PostgreSQL
+}
+
+// Increment returns the SET assignment bumping the version, or an empty string
+// for the xmin system column which PostgreSQL bumps itself. The current value is
+// qualified so that the assignment can also be used by ON CONFLICT DO UPDATE.
+func (v VersionColumn) Increment() string {
+	if v.System {
+		return ""
+	}
+	return v.Column + " = " + v.Table + "." + v.Column + " + 1"
+}
+
+// withSystemVersion adds the xmin system column to a table using it as its version.
+// Introspection only lists user columns, so the model would otherwise have no field
+// to carry the version between a read and the following update.
+func withSystemVersion(table introspector.Table, column string) introspector.Table {
+	if column != xminColumn || len(primaryKeyColumns(table)) == 0 {
+		return table
+	}
+	for _, col := range table.Columns {
+		if col.Name == xminColumn {
+			return table
+		}
+	}
+
+	table.Columns = append(slices.Clip(table.Columns), introspector.Column{
+		Name:        xminColumn,
+		Type:        "xid",
+		GoType:      "uint32",
+		IsGenerated: true,
+	})
+	return table
+}
+
+// detectVersionColumn returns the version column of a table: the xmin system column or
+// a non-nullable integer column. It returns nil when the table has no primary key to
+// match rows with or no usable column of that name.
+func detectVersionColumn(table introspector.Table, column string) *VersionColumn {
+	if column == "" || len(primaryKeyColumns(table)) == 0 {
+		return nil
+	}
+
+	for _, col := range table.Columns {
+		if col.Name != column {
+			continue
+		}
+
+		if col.Name == xminColumn {
+			return &VersionColumn{Table: table.Name, Column: col.Name, Field: toPascalCase(col.Name), GoType: col.GoType, System: true}
+		}
+
+		switch {
+		case col.IsPrimaryKey || col.IsGenerated:
+			slog.Warn("Skipping version column", "table", table.Name, "column", column, "reason", "column is written by the database")
+		case !slices.Contains([]string{"int", "int16", "int32", "int64"}, col.GoType):
+			slog.Warn("Skipping version column", "table", table.Name, "column", column, "reason", "column is not a non-nullable integer")
+		default:
+			return &VersionColumn{Table: table.Name, Column: col.Name, Field: toPascalCase(col.Name), GoType: col.GoType}
+		}
+		return nil
+	}
+
+	return nil
+}
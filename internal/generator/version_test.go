@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectVersionColumn(t *testing.T) {
+	table := testTable("accounts")
+
+	v := detectVersionColumn(table, "version")
+	require.NotNil(t, v)
+	assert.Equal(t, "Version", v.Field)
+	assert.Equal(t, "int32", v.GoType)
+	assert.False(t, v.System)
+	assert.Equal(t, "version = accounts.version + 1", v.Increment())
+
+	assert.Nil(t, detectVersionColumn(table, "revision"), "nullable columns cannot be compared")
+	assert.Nil(t, detectVersionColumn(table, "id"), "the primary key cannot be bumped")
+	assert.Nil(t, detectVersionColumn(table, "missing"))
+	assert.Nil(t, detectVersionColumn(table, ""))
+	assert.Nil(t, detectVersionColumn(table, "xmin"), "xmin must be added to the table first")
+}
+
+func TestWithSystemVersion(t *testing.T) {
+	table := testTable("accounts")
+
+	withXmin := withSystemVersion(table, "xmin")
+	require.Len(t, withXmin.Columns, len(table.Columns)+1)
+	assert.Len(t, table.Columns, 8, "the original table is left untouched")
+
+	xmin := withXmin.Columns[len(withXmin.Columns)-1]
+	assert.Equal(t, "xmin", xmin.Name)
+	assert.Equal(t, "uint32", xmin.GoType)
+	assert.True(t, xmin.IsGenerated, "xmin is never written")
+
+	v := detectVersionColumn(withXmin, "xmin")
+	require.NotNil(t, v)
+	assert.True(t, v.System)
+	assert.Equal(t, "Xmin", v.Field)
+	assert.Empty(t, v.Increment(), "PostgreSQL bumps xmin itself")
+
+	assert.Len(t, withSystemVersion(withXmin, "xmin").Columns, len(withXmin.Columns), "xmin is added once")
+	assert.Equal(t, table, withSystemVersion(table, "version"))
+
+	table.PrimaryKeys = nil
+	table.Columns[0].IsPrimaryKey = false
+	assert.Len(t, withSystemVersion(table, "xmin").Columns, 8, "rows without a key cannot be versioned")
+}
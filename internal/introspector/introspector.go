@@ -16,9 +16,12 @@ type Column struct {
 	GoType       string
 	IsPrimaryKey bool
 	IsNullable   bool
+	IsGenerated  bool // Identity or generated column whose value is produced by the database
 	DefaultValue *string
 	Comment      string
 	Position     int
+	MaxLength    int      // Maximum length of character columns, 0 when unbounded
+	EnumValues   []string // Labels of enum columns, in their sort order
 }
 
 // Index represents a database index
@@ -213,7 +216,17 @@ func (i *Introspector) getColumns(ctx context.Context, pool *pgxpool.Pool, table
 			is_nullable,
 			column_default,
 			ordinal_position,
-			COALESCE(col_description(pgc.oid, ordinal_position), '') as column_comment
+			COALESCE(col_description(pgc.oid, ordinal_position), '') as column_comment,
+			is_identity,
+			is_generated,
+			COALESCE(character_maximum_length, 0) as max_length,
+			COALESCE((
+				SELECT array_agg(e.enumlabel::text ORDER BY e.enumsortorder)
+				FROM pg_type t
+				JOIN pg_namespace tn ON tn.oid = t.typnamespace
+				JOIN pg_enum e ON e.enumtypid = t.oid
+				WHERE t.typname = isc.udt_name AND tn.nspname = isc.udt_schema
+			), '{}'::text[]) as enum_values
 		FROM information_schema.columns isc
 		LEFT JOIN pg_class pgc ON pgc.relname = isc.table_name
 		LEFT JOIN pg_namespace pgn ON pgn.oid = pgc.relnamespace AND pgn.nspname = isc.table_schema
@@ -230,15 +243,16 @@ func (i *Introspector) getColumns(ctx context.Context, pool *pgxpool.Pool, table
 	var columns []Column
 	for rows.Next() {
 		var col Column
-		var isNullable string
+		var isNullable, isIdentity, isGenerated string
 		var defaultValue *string
 
-		err := rows.Scan(&col.Name, &col.Type, &isNullable, &defaultValue, &col.Position, &col.Comment)
+		err := rows.Scan(&col.Name, &col.Type, &isNullable, &defaultValue, &col.Position, &col.Comment, &isIdentity, &isGenerated, &col.MaxLength, &col.EnumValues)
 		if err != nil {
 			return nil, err
 		}
 
 		col.IsNullable = isNullable == "YES"
+		col.IsGenerated = isIdentity == "YES" || isGenerated == "ALWAYS"
 		col.DefaultValue = defaultValue
 		col.GoType = mapPostgresToGoType(col.Type, col.IsNullable)
 